@@ -1,14 +1,26 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"log"
+	"net/http"
 	"os"
+	"os/signal"
 	"strconv"
+	"strings"
+	"syscall"
 	"time"
 
 	"github.com/demispreviotto/cajitamusical/cajitamusical-backend/internal/api"
 	"github.com/demispreviotto/cajitamusical/cajitamusical-backend/internal/db"
+	"github.com/demispreviotto/cajitamusical/cajitamusical-backend/internal/dto/song"
+	"github.com/demispreviotto/cajitamusical/cajitamusical-backend/internal/mail"
 	"github.com/demispreviotto/cajitamusical/cajitamusical-backend/internal/models"
+	"github.com/demispreviotto/cajitamusical/cajitamusical-backend/internal/scheduler"
+	"github.com/demispreviotto/cajitamusical/cajitamusical-backend/internal/services"
+	"github.com/demispreviotto/cajitamusical/cajitamusical-backend/internal/watcher"
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
@@ -50,7 +62,25 @@ func main() {
 		&models.User{},
 		&models.Authentication{},
 		&models.Session{},
+		&models.LoginThrottle{},
+		&models.Artist{},
+		&models.Album{},
 		&models.Song{},
+		&models.Playlist{},
+		&models.PlaylistItem{},
+		&models.FavoriteSong{},
+		&models.FavoriteAlbum{},
+		&models.FavoriteArtist{},
+		&models.PlayHistory{},
+		&models.APIToken{},
+		&models.PasswordResetToken{},
+		&models.EmailVerificationToken{},
+		&models.Invite{},
+		&models.TwoFactor{},
+		&models.RecoveryCode{},
+		&models.PendingLogin{},
+		&models.Passkey{},
+		&models.WebAuthnChallenge{},
 		// Añade cualquier otro modelo que GORM deba gestionar aquí
 	)
 	if err != nil {
@@ -58,8 +88,50 @@ func main() {
 	}
 	log.Println("GORM auto-migrations completed.")
 
+	if err := db.SetupSearch(context.Background()); err != nil {
+		log.Fatalf("Failed to set up search: %v", err)
+	}
+
+	mailer, err := mail.FromEnv()
+	if err != nil {
+		log.Fatalf("Failed to configure mail: %v", err)
+	}
+
+	// Servicios compartidos entre las rutas y las tareas de mantenimiento
+	verifyURL, err := services.EmailVerificationURLFromEnv()
+	if err != nil {
+		log.Fatalf("Failed to configure email verification: %v", err)
+	}
+	webAuthn, err := services.WebAuthnFromEnv()
+	if err != nil {
+		log.Fatalf("Failed to configure passkeys: %v", err)
+	}
+	emailVerificationService := services.NewEmailVerificationService(db.NewUserDB(), db.NewEmailVerificationDB(), mailer, verifyURL)
+	userService := services.NewUserService(db.NewUserDB(), db.NewSessionDB(), db.NewPasskeyDB(), emailVerificationService, mailer)
+	authService := services.NewAuthService(db.NewUserDB(), db.NewSessionDB(), db.NewLoginThrottleDB(), db.NewTwoFactorDB(), db.NewPasskeyDB(), services.SessionPolicyFromEnv(), webAuthn)
+	passwordResetService := services.NewPasswordResetService(db.NewUserDB(), db.NewPasskeyDB(), db.NewPasswordResetDB(), mailer)
+
+	// Da el rol de administrador a un usuario existente (instalaciones anteriores a los roles)
+	if adminUsername := os.Getenv("BOOTSTRAP_ADMIN_USERNAME"); adminUsername != "" {
+		if err := userService.BootstrapAdmin(context.Background(), adminUsername); err != nil {
+			log.Printf("Failed to bootstrap admin %s: %v", adminUsername, err)
+		}
+	}
+
 	routerEngine := gin.Default()
 
+	// Solo se lee la IP del cliente de X-Forwarded-For si la petición llega de
+	// un proxy de confianza; sin TRUSTED_PROXIES se usa la dirección de la conexión.
+	var trustedProxies []string
+	for _, proxy := range strings.Split(os.Getenv("TRUSTED_PROXIES"), ",") {
+		if proxy = strings.TrimSpace(proxy); proxy != "" {
+			trustedProxies = append(trustedProxies, proxy)
+		}
+	}
+	if err := routerEngine.SetTrustedProxies(trustedProxies); err != nil {
+		log.Fatalf("Invalid TRUSTED_PROXIES: %v", err)
+	}
+
 	// --- Configuración CORS ---
 	config := cors.DefaultConfig()
 
@@ -82,8 +154,93 @@ func main() {
 	routerEngine.Use(cors.New(config))
 	// --- Fin Configuración CORS ---
 
-	api.SetupRoutes(routerEngine)
+	// Se cancela con SIGINT/SIGTERM para detener el servidor y las tareas en segundo plano
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Los escaneos de la biblioteca se comparten entre las rutas de administración, el watcher y el scheduler
+	scanService := services.NewScanService(db.NewSongDB())
+
+	if os.Getenv("WATCH_MUSIC_DIRECTORY") == "true" {
+		debounce := watcher.DefaultDebounce
+		if d, errParse := time.ParseDuration(os.Getenv("WATCH_DEBOUNCE")); errParse == nil && d > 0 {
+			debounce = d
+		}
+		libraryWatcher, err := watcher.New(os.Getenv("MUSIC_DIRECTORY"), debounce, db.NewSongDB(), scanService)
+		if err != nil {
+			log.Fatalf("Failed to watch music directory: %v", err)
+		}
+		go libraryWatcher.Run(ctx)
+	}
+
+	// Tareas de mantenimiento periódicas
+	taskScheduler := scheduler.New()
+	if err := taskScheduler.Add("session-cleanup", scheduleFromEnv("SCHEDULE_SESSION_CLEANUP", "@hourly"), func(ctx context.Context) error {
+		return errors.Join(
+			authService.CleanupExpiredSessions(ctx),
+			passwordResetService.CleanupExpiredTokens(ctx),
+			emailVerificationService.CleanupExpiredTokens(ctx),
+		)
+	}); err != nil {
+		log.Fatalf("Failed to schedule session cleanup: %v", err)
+	}
+	if err := taskScheduler.Add("library-scan", scheduleFromEnv("SCHEDULE_LIBRARY_SCAN", "0 4 * * *"), func(ctx context.Context) error {
+		job, err := scanService.StartScan(ctx)
+		if errors.Is(err, services.ErrScanInProgress) {
+			return nil // Ya hay un escaneo en curso; no es un fallo de la tarea
+		}
+		if err != nil {
+			return err
+		}
+		// La tarea dura lo que el escaneo y termina con su resultado
+		job, err = scanService.WaitScan(ctx, job.ID)
+		if err != nil {
+			return err
+		}
+		switch job.Status {
+		case song.ScanStatusFailed:
+			return fmt.Errorf("library scan failed: %s", job.Error)
+		case song.ScanStatusCancelled:
+			return errors.New("library scan was cancelled")
+		}
+		return nil
+	}); err != nil {
+		log.Fatalf("Failed to schedule library scan: %v", err)
+	}
+	taskScheduler.Start()
+
+	api.SetupRoutes(routerEngine, scanService, taskScheduler, userService, authService, passwordResetService, emailVerificationService, webAuthn)
 
 	port := os.Getenv("PORT")
-	routerEngine.Run(":" + port)
+	server := &http.Server{Addr: ":" + port, Handler: routerEngine}
+	go func() {
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalf("HTTP server failed: %v", err)
+		}
+	}()
+
+	<-ctx.Done()
+	log.Println("Shutting down...")
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Error shutting down HTTP server: %v", err)
+	}
+	if err := taskScheduler.Stop(shutdownCtx); err != nil {
+		log.Printf("Error stopping scheduler: %v", err)
+	}
+	// Después del scheduler, para que no inicie un escaneo nuevo
+	if err := scanService.Stop(shutdownCtx); err != nil {
+		log.Printf("Error stopping library scan: %v", err)
+	}
+}
+
+// scheduleFromEnv devuelve la planificación de una tarea definida en la variable
+// de entorno name, o def si no está definida.
+func scheduleFromEnv(name, def string) string {
+	if schedule := os.Getenv(name); schedule != "" {
+		return schedule
+	}
+	return def
 }
@@ -6,16 +6,36 @@ import (
 	"github.com/demispreviotto/cajitamusical/cajitamusical-backend/internal/db"
 	"github.com/demispreviotto/cajitamusical/cajitamusical-backend/internal/handlers"
 	"github.com/demispreviotto/cajitamusical/cajitamusical-backend/internal/middleware"
+	"github.com/demispreviotto/cajitamusical/cajitamusical-backend/internal/models"
+	"github.com/demispreviotto/cajitamusical/cajitamusical-backend/internal/scheduler"
 	"github.com/demispreviotto/cajitamusical/cajitamusical-backend/internal/services"
 	"github.com/gin-gonic/gin"
+	"github.com/go-webauthn/webauthn/webauthn"
 )
 
-// SetupRoutes configures all API routes for the application.
-func SetupRoutes(router *gin.Engine) {
+// SetupRoutes configures all API routes for the application. scanService is
+// shared with the background tasks that start library scans, and
+// taskScheduler runs those tasks. The user, auth, password reset and email
+// verification services are shared with the startup and the scheduled tasks.
+// webAuthn is the relying party passkeys are registered with.
+func SetupRoutes(router *gin.Engine, scanService services.ScanServicer, taskScheduler *scheduler.Scheduler,
+	userService services.UserServicer, authService services.AuthServicer,
+	passwordResetService services.PasswordResetServicer, emailVerificationService services.EmailVerificationServicer,
+	webAuthn *webauthn.WebAuthn) {
 	// Initialize DB layer implementations
 	userDB := db.NewUserDB()
 	sessionDB := db.NewSessionDB()
 	songDB := db.NewSongDB()
+	playlistDB := db.NewPlaylistDB()
+	favoriteDB := db.NewFavoriteDB()
+	historyDB := db.NewHistoryDB()
+	searchDB := db.NewSearchDB()
+	artistDB := db.NewArtistDB()
+	albumDB := db.NewAlbumDB()
+	apiTokenDB := db.NewAPITokenDB()
+	inviteDB := db.NewInviteDB()
+	twoFactorDB := db.NewTwoFactorDB()
+	passkeyDB := db.NewPasskeyDB()
 
 	// Get environment variable for music directory
 	musicDir := os.Getenv("MUSIC_DIRECTORY")
@@ -24,41 +44,144 @@ func SetupRoutes(router *gin.Engine) {
 	}
 
 	// Initialize service layer implementations
-	userService := services.NewUserService(userDB)
-	authService := services.NewAuthService(userDB, sessionDB)
-	songService := services.NewSongService(songDB)
+	sessionPolicy := services.SessionPolicyFromEnv()
+	songService := services.NewSongService(songDB, favoriteDB, historyDB)
+	playlistService := services.NewPlaylistService(playlistDB, songDB, favoriteDB, historyDB)
+	favoriteService := services.NewFavoriteService(favoriteDB, songDB, artistDB, albumDB, historyDB)
+	historyService := services.NewHistoryService(historyDB, songDB, favoriteDB)
+	searchService := services.NewSearchService(searchDB, favoriteDB, historyDB)
+	catalogService := services.NewCatalogService(artistDB, albumDB, favoriteDB, historyDB)
+	apiTokenService := services.NewAPITokenService(apiTokenDB)
+	inviteService := services.NewInviteService(inviteDB)
+	twoFactorService := services.NewTwoFactorService(userDB, twoFactorDB)
+	passkeyService := services.NewPasskeyService(passkeyDB, userDB, twoFactorDB, webAuthn)
 
 	// Initialize handlers with their service dependencies
-	userHandler := handlers.NewuserHandler(userService)
-	authHandler := handlers.NewauthHandler(authService)
+	cookiePolicy := middleware.CookiePolicyFromEnv()
+	userHandler := handlers.NewuserHandler(userService, cookiePolicy)
+	authHandler := handlers.NewauthHandler(authService, cookiePolicy)
 	songHandler := handlers.NewsongHandler(songService)
+	playlistHandler := handlers.NewplaylistHandler(playlistService)
+	favoriteHandler := handlers.NewfavoriteHandler(favoriteService)
+	historyHandler := handlers.NewhistoryHandler(historyService)
+	searchHandler := handlers.NewsearchHandler(searchService)
+	catalogHandler := handlers.NewcatalogHandler(catalogService)
+	scanHandler := handlers.NewscanHandler(scanService)
+	taskHandler := handlers.NewtaskHandler(taskScheduler)
+	apiTokenHandler := handlers.NewapiTokenHandler(apiTokenService)
+	passwordResetHandler := handlers.NewpasswordResetHandler(passwordResetService)
+	emailVerificationHandler := handlers.NewemailVerificationHandler(emailVerificationService)
+	inviteHandler := handlers.NewinviteHandler(inviteService)
+	twoFactorHandler := handlers.NewtwoFactorHandler(twoFactorService)
+	passkeyHandler := handlers.NewpasskeyHandler(passkeyService)
 
 	// Initialize the AuthMiddleware with its DB dependencies
-	authMiddleware := middleware.NewAuthMiddleware(sessionDB, userDB)
+	authMiddleware := middleware.NewAuthMiddleware(sessionDB, userDB, apiTokenDB, sessionPolicy, cookiePolicy)
 
 	// Public routes (no authentication required)
 	public := router.Group("/api")
 	{
+		public.GET("/registration", userHandler.GetRegistrationMode)
 		public.POST("/register", userHandler.RegisterUser)
 		public.POST("/login", authHandler.LoginUser)
+		public.POST("/login/2fa", authHandler.CompleteTwoFactorLogin)
+		public.POST("/login/passkey/begin", authHandler.BeginPasskeyLogin)
+		public.POST("/login/passkey/finish", authHandler.FinishPasskeyLogin)
+		public.POST("/password/forgot", passwordResetHandler.ForgotPassword)
+		public.POST("/password/reset", passwordResetHandler.ResetPassword)
+		public.GET("/verify-email", emailVerificationHandler.VerifyEmail)
+		public.POST("/verify-email/resend", emailVerificationHandler.ResendVerification)
 	}
 	// Protected routes (require AuthMiddleware)
 	protected := router.Group("/api")
 	protected.Use(authMiddleware.Handler())
 	{
 		protected.GET("/me", userHandler.GetAuthenticatedUser)
+
+		// Account management, not available to API tokens
+		sessionOnly := middleware.RequireSession()
+		protected.PATCH("/me", sessionOnly, userHandler.UpdateProfile)
+		protected.DELETE("/me", sessionOnly, userHandler.DeleteAccount)
+		protected.POST("/me/password", sessionOnly, userHandler.ChangePassword)
+		protected.GET("/me/sessions", sessionOnly, authHandler.ListSessions)
+		protected.DELETE("/me/sessions", sessionOnly, authHandler.RevokeOtherSessions) // Log out everywhere else
+		protected.DELETE("/me/sessions/:id", sessionOnly, authHandler.RevokeSession)
+		protected.GET("/me/tokens", sessionOnly, apiTokenHandler.ListTokens)
+		protected.POST("/me/tokens", sessionOnly, apiTokenHandler.CreateToken)
+		protected.DELETE("/me/tokens/:id", sessionOnly, apiTokenHandler.RevokeToken)
+		protected.GET("/me/2fa", sessionOnly, twoFactorHandler.GetStatus)
+		protected.POST("/me/2fa/setup", sessionOnly, twoFactorHandler.BeginEnrollment)
+		protected.POST("/me/2fa/confirm", sessionOnly, twoFactorHandler.ConfirmEnrollment)
+		protected.POST("/me/2fa/recovery-codes", sessionOnly, twoFactorHandler.RegenerateRecoveryCodes)
+		protected.DELETE("/me/2fa", sessionOnly, twoFactorHandler.Disable)
+		protected.GET("/me/passkeys", sessionOnly, passkeyHandler.ListPasskeys)
+		protected.POST("/me/passkeys/begin", sessionOnly, passkeyHandler.BeginRegistration)
+		protected.POST("/me/passkeys", sessionOnly, passkeyHandler.FinishRegistration)
+		protected.DELETE("/me/passkeys/:id", sessionOnly, passkeyHandler.DeletePasskey)
 		protected.POST("/logout", authHandler.LogoutUser)
 
 		// Song routes
 		protected.GET("/library", songHandler.GetLibrary)
-		protected.GET("/audio/:filename", songHandler.ServeAudio)
+		protected.GET("/search", searchHandler.Search)
+		protected.GET("/audio/:songID", middleware.RequirePermission(models.PermissionStream), songHandler.ServeAudio)
+		protected.GET("/songs/:songID/download", middleware.RequirePermission(models.PermissionDownload), songHandler.DownloadSong)
 		protected.GET("/album-art/*filepath", songHandler.ServeAlbumArt)
+
+		// Artist and album routes
+		protected.GET("/artists", catalogHandler.ListArtists)
+		protected.GET("/artists/:id", catalogHandler.GetArtist)
+		protected.GET("/albums", catalogHandler.ListAlbums)
+		protected.GET("/albums/:id", catalogHandler.GetAlbum)
+
+		// Playlist routes
+		protected.GET("/playlists", playlistHandler.ListPlaylists)
+		protected.POST("/playlists", playlistHandler.CreatePlaylist)
+		protected.GET("/playlists/:id", playlistHandler.GetPlaylist)
+		protected.PATCH("/playlists/:id", playlistHandler.UpdatePlaylist)
+		protected.DELETE("/playlists/:id", playlistHandler.DeletePlaylist)
+		protected.POST("/playlists/:id/songs", playlistHandler.AddSong)
+		protected.DELETE("/playlists/:id/songs/:itemID", playlistHandler.RemoveItem)
+		protected.PUT("/playlists/:id/order", playlistHandler.ReorderPlaylist)
+
+		// Favorite routes
+		protected.GET("/favorites", favoriteHandler.ListFavorites)
+		protected.PUT("/favorites/songs/:id", favoriteHandler.StarSong)
+		protected.DELETE("/favorites/songs/:id", favoriteHandler.UnstarSong)
+		protected.PUT("/favorites/albums/:id", favoriteHandler.StarAlbum)
+		protected.DELETE("/favorites/albums/:id", favoriteHandler.UnstarAlbum)
+		protected.PUT("/favorites/artists/:id", favoriteHandler.StarArtist)
+		protected.DELETE("/favorites/artists/:id", favoriteHandler.UnstarArtist)
+
+		// Play history routes
+		protected.GET("/history", historyHandler.GetHistory)
+		protected.POST("/history/events", historyHandler.RecordPlayEvent)
 	}
 	// Admin routes
-	admin := router.Group("/api/admin")
-	admin.Use(authMiddleware.Handler())
+	admin := protected.Group("/admin", middleware.RequireAdmin())
 	{
 		admin.POST("/cleanup-sessions", authHandler.CleanupExpiredSessions)
-		admin.POST("/scan-music", songHandler.ScanMusicLibrary)
+		admin.GET("/tasks", taskHandler.ListTasks)
+
+		// User management
+		admin.GET("/users", userHandler.ListUsers)
+		admin.GET("/users/:id/sessions", userHandler.GetUserSessions)
+		admin.PUT("/users/:id/access", userHandler.UpdateUserAccess)
+		admin.POST("/users/:id/disable", userHandler.DisableUser)
+		admin.POST("/users/:id/enable", userHandler.EnableUser)
+		admin.POST("/users/:id/password-reset", userHandler.ForcePasswordReset)
+		admin.DELETE("/users/:id", userHandler.DeleteUser)
+		admin.DELETE("/users/:id/2fa", twoFactorHandler.ResetUserTwoFactor)
+
+		// Invites for invite-only registration
+		admin.GET("/invites", inviteHandler.ListInvites)
+		admin.POST("/invites", inviteHandler.CreateInvite)
+		admin.DELETE("/invites/:id", inviteHandler.RevokeInvite)
+	}
+	// Library management, also available to non-admins granted the permission
+	manageLibrary := protected.Group("/admin", middleware.RequirePermission(models.PermissionManageLibrary))
+	{
+		manageLibrary.POST("/scan-music", scanHandler.StartScan)
+		manageLibrary.GET("/scans/:id", scanHandler.GetScan)
+		manageLibrary.DELETE("/scans/:id", scanHandler.CancelScan)
 	}
 }
@@ -0,0 +1,89 @@
+// Package audio reads technical properties of audio files, such as their
+// duration, directly from the container/stream headers.
+package audio
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Errors returned by Duration.
+var (
+	ErrUnsupportedFormat = errors.New("unsupported audio format")
+	ErrInvalidStream     = errors.New("invalid audio stream")
+)
+
+// Duration returns the playing time of the audio in r. ext is the file
+// extension (".mp3", ".flac" or ".m4a") and selects the parser. r is read from
+// the beginning regardless of its current offset.
+func Duration(r io.ReadSeeker, ext string) (time.Duration, error) {
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return 0, err
+	}
+
+	var (
+		d   time.Duration
+		err error
+	)
+	switch strings.ToLower(ext) {
+	case ".mp3":
+		d, err = mp3Duration(r)
+	case ".flac":
+		d, err = flacDuration(r)
+	case ".m4a":
+		d, err = m4aDuration(r)
+	default:
+		return 0, fmt.Errorf("%w: %s", ErrUnsupportedFormat, ext)
+	}
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		// Headers cut short are a bad file, not a read failure
+		err = fmt.Errorf("%w: truncated (%w)", ErrInvalidStream, err)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to read %s duration: %w", ext, err)
+	}
+	return d, nil
+}
+
+// Seconds rounds d to the nearest whole second.
+func Seconds(d time.Duration) int {
+	return int(d.Round(time.Second) / time.Second)
+}
+
+// samplesDuration converts a number of samples at sampleRate Hz into a duration.
+func samplesDuration(samples uint64, sampleRate uint32) time.Duration {
+	if sampleRate == 0 {
+		return 0
+	}
+	return time.Duration(float64(samples) / float64(sampleRate) * float64(time.Second))
+}
+
+// skipID3v2 advances r past an ID3v2 tag at the current position, if any, and
+// returns the number of bytes skipped. r is left at the first byte after the tag.
+func skipID3v2(r io.ReadSeeker) (int64, error) {
+	start, err := r.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return 0, err
+	}
+
+	var header [10]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return 0, err
+	}
+	if string(header[:3]) != "ID3" {
+		_, err := r.Seek(start, io.SeekStart)
+		return 0, err
+	}
+
+	// Tag size is a 28-bit "syncsafe" integer (7 bits per byte), excluding the header
+	size := int64(header[6]&0x7F)<<21 | int64(header[7]&0x7F)<<14 | int64(header[8]&0x7F)<<7 | int64(header[9]&0x7F)
+	size += 10
+	if header[5]&0x10 != 0 { // Footer present
+		size += 10
+	}
+	_, err = r.Seek(start+size, io.SeekStart)
+	return size, err
+}
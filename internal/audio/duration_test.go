@@ -0,0 +1,83 @@
+package audio
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+)
+
+// durationTest is a fixture and the duration read from it, or the error.
+type durationTest struct {
+	name    string
+	data    []byte
+	want    time.Duration
+	wantErr error
+}
+
+// runDurationTests reads every fixture as a file with extension ext. Durations
+// are compared to the microsecond, below the float rounding of samplesDuration.
+func runDurationTests(t *testing.T, ext string, tests []durationTest) {
+	t.Helper()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Duration(bytes.NewReader(tt.data), ext)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Duration error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Duration: %v", err)
+			}
+			if diff := got - tt.want; diff < -time.Microsecond || diff > time.Microsecond {
+				t.Errorf("Duration = %v, want %v", got, tt.want)
+			}
+			if Seconds(got) != Seconds(tt.want) {
+				t.Errorf("Seconds = %d, want %d", Seconds(got), Seconds(tt.want))
+			}
+		})
+	}
+}
+
+// id3v2Tag returns an ID3v2.4 tag with size bytes of (empty) frames.
+func id3v2Tag(size int) []byte {
+	tag := []byte{'I', 'D', '3', 4, 0, 0, byte(size >> 21 & 0x7F), byte(size >> 14 & 0x7F), byte(size >> 7 & 0x7F), byte(size & 0x7F)}
+	return append(tag, make([]byte, size)...)
+}
+
+// concat joins the parts of a fixture.
+func concat(parts ...[]byte) []byte {
+	return bytes.Join(parts, nil)
+}
+
+func TestDurationUnsupportedFormat(t *testing.T) {
+	if _, err := Duration(bytes.NewReader([]byte("OggS")), ".ogg"); !errors.Is(err, ErrUnsupportedFormat) {
+		t.Fatalf("Duration error = %v, want ErrUnsupportedFormat", err)
+	}
+}
+
+func TestDurationExtensionCase(t *testing.T) {
+	data := flacFile(44100, 44100*3)
+	if got, err := Duration(bytes.NewReader(data), ".FLAC"); err != nil || got != 3*time.Second {
+		t.Fatalf("Duration = %v, %v, want 3s", got, err)
+	}
+}
+
+func TestSeconds(t *testing.T) {
+	tests := []struct {
+		d    time.Duration
+		want int
+	}{
+		{0, 0},
+		{499 * time.Millisecond, 0},
+		{500 * time.Millisecond, 1},
+		{3*time.Minute + 29*time.Second + 501*time.Millisecond, 210},
+	}
+	for _, tt := range tests {
+		if got := Seconds(tt.d); got != tt.want {
+			t.Errorf("Seconds(%v) = %d, want %d", tt.d, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package audio
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"time"
+)
+
+// flacStreamInfo is the type of the mandatory first FLAC metadata block.
+const flacStreamInfo = 0
+
+// flacDuration reads the total samples and sample rate from the STREAMINFO block.
+func flacDuration(r io.ReadSeeker) (time.Duration, error) {
+	// Some taggers prepend an ID3v2 tag to FLAC files
+	if _, err := skipID3v2(r); err != nil {
+		return 0, err
+	}
+
+	var magic [4]byte
+	if _, err := io.ReadFull(r, magic[:]); err != nil {
+		return 0, err
+	}
+	if string(magic[:]) != "fLaC" {
+		return 0, fmt.Errorf("%w: missing fLaC marker", ErrInvalidStream)
+	}
+
+	var header [4]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return 0, err
+	}
+	if header[0]&0x7F != flacStreamInfo {
+		return 0, fmt.Errorf("%w: first metadata block is not STREAMINFO", ErrInvalidStream)
+	}
+
+	var info [34]byte
+	if _, err := io.ReadFull(r, info[:]); err != nil {
+		return 0, err
+	}
+
+	// Bytes 10-17: sample rate (20 bits), channels (3), bits per sample (5), total samples (36)
+	packed := binary.BigEndian.Uint64(info[10:18])
+	sampleRate := uint32(packed >> 44)
+	totalSamples := packed & 0xFFFFFFFFF
+	if sampleRate == 0 {
+		return 0, fmt.Errorf("%w: sample rate is zero", ErrInvalidStream)
+	}
+	// A total of zero means "unknown"; there is nothing better to report without decoding
+	return samplesDuration(totalSamples, sampleRate), nil
+}
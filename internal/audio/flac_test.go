@@ -0,0 +1,47 @@
+package audio
+
+import (
+	"encoding/binary"
+	"testing"
+	"time"
+)
+
+// flacStreamInfoBlock returns a STREAMINFO metadata block, header included,
+// for 16-bit stereo audio.
+func flacStreamInfoBlock(sampleRate uint32, totalSamples uint64) []byte {
+	block := []byte{0x80 | flacStreamInfo, 0, 0, 34} // Last block, 34 bytes long
+	info := make([]byte, 34)
+	binary.BigEndian.PutUint16(info[0:2], 4096) // Minimum and maximum block size
+	binary.BigEndian.PutUint16(info[2:4], 4096)
+	packed := uint64(sampleRate)<<44 | 1<<41 | 15<<36 | totalSamples&0xFFFFFFFFF // 2 channels, 16 bits
+	binary.BigEndian.PutUint64(info[10:18], packed)
+	return append(block, info...)
+}
+
+// flacFile returns the start of a FLAC file, up to its first frame.
+func flacFile(sampleRate uint32, totalSamples uint64) []byte {
+	return concat([]byte("fLaC"), flacStreamInfoBlock(sampleRate, totalSamples))
+}
+
+func TestFLACDuration(t *testing.T) {
+	valid := flacFile(44100, 44100*200)
+
+	runDurationTests(t, ".flac", []durationTest{
+		{name: "CD quality", data: valid, want: 200 * time.Second},
+		{name: "fraction of a second", data: flacFile(48000, 48000*61+24000), want: 61500 * time.Millisecond},
+		{name: "total samples over 32 bits", data: flacFile(96000, 96000*50000), want: 50000 * time.Second},
+		{name: "frames after STREAMINFO", data: concat(valid, make([]byte, 4096)), want: 200 * time.Second},
+		{name: "ID3v2 tag before the marker", data: concat(id3v2Tag(300), valid), want: 200 * time.Second},
+		{name: "unknown total samples", data: flacFile(44100, 0), want: 0},
+
+		{name: "empty file", data: nil, wantErr: ErrInvalidStream},
+		{name: "truncated marker", data: []byte("fLa"), wantErr: ErrInvalidStream},
+		{name: "truncated block header", data: valid[:6], wantErr: ErrInvalidStream},
+		{name: "truncated STREAMINFO", data: valid[:30], wantErr: ErrInvalidStream},
+		{name: "truncated ID3v2 tag", data: id3v2Tag(300)[:7], wantErr: ErrInvalidStream},
+		{name: "ID3v2 tag larger than the file", data: concat(id3v2Tag(300)[:10], valid), wantErr: ErrInvalidStream},
+		{name: "corrupt marker", data: concat([]byte("fLaX"), valid[4:]), wantErr: ErrInvalidStream},
+		{name: "first block is not STREAMINFO", data: concat([]byte("fLaC"), []byte{0x84}, valid[5:]), wantErr: ErrInvalidStream},
+		{name: "zero sample rate", data: flacFile(0, 44100), wantErr: ErrInvalidStream},
+	})
+}
@@ -0,0 +1,121 @@
+package audio
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"time"
+)
+
+// m4aDuration reads the duration from the movie header (mvhd) atom, falling
+// back to the media header (mdhd) of the first track when mvhd reports none.
+func m4aDuration(r io.ReadSeeker) (time.Duration, error) {
+	end, err := r.Seek(0, io.SeekEnd)
+	if err != nil {
+		return 0, err
+	}
+
+	moovStart, moovEnd, err := findAtom(r, 0, end, "moov")
+	if err != nil {
+		return 0, err
+	}
+
+	mvhdStart, _, err := findAtom(r, moovStart, moovEnd, "mvhd")
+	if err == nil {
+		if d, err := readMediaHeader(r, mvhdStart); err == nil && d > 0 {
+			return d, nil
+		}
+	}
+
+	// Fall back to moov/trak/mdia/mdhd
+	trakStart, trakEnd, err := findAtom(r, moovStart, moovEnd, "trak")
+	if err != nil {
+		return 0, err
+	}
+	mdiaStart, mdiaEnd, err := findAtom(r, trakStart, trakEnd, "mdia")
+	if err != nil {
+		return 0, err
+	}
+	mdhdStart, _, err := findAtom(r, mdiaStart, mdiaEnd, "mdhd")
+	if err != nil {
+		return 0, err
+	}
+	return readMediaHeader(r, mdhdStart)
+}
+
+// findAtom looks for the first atom named name among the atoms between start
+// and end, and returns the bounds of its payload.
+func findAtom(r io.ReadSeeker, start, end int64, name string) (int64, int64, error) {
+	for offset := start; offset+8 <= end; {
+		if _, err := r.Seek(offset, io.SeekStart); err != nil {
+			return 0, 0, err
+		}
+
+		var header [8]byte
+		if _, err := io.ReadFull(r, header[:]); err != nil {
+			return 0, 0, err
+		}
+		size := int64(binary.BigEndian.Uint32(header[:4]))
+		headerSize := int64(8)
+		switch size {
+		case 0: // Atom extends to the end of its container
+			size = end - offset
+		case 1: // 64-bit size follows the type
+			var ext [8]byte
+			if _, err := io.ReadFull(r, ext[:]); err != nil {
+				return 0, 0, err
+			}
+			size = int64(binary.BigEndian.Uint64(ext[:]))
+			headerSize = 16
+		}
+		if size < headerSize || offset+size > end {
+			return 0, 0, fmt.Errorf("%w: corrupt %q atom", ErrInvalidStream, string(header[4:]))
+		}
+
+		if string(header[4:]) == name {
+			return offset + headerSize, offset + size, nil
+		}
+		offset += size
+	}
+	return 0, 0, fmt.Errorf("%w: %q atom not found", ErrInvalidStream, name)
+}
+
+// readMediaHeader parses an mvhd or mdhd payload starting at offset. Both share
+// the layout version/flags, creation and modification times, time scale and
+// duration; mdhd only differs in what follows.
+func readMediaHeader(r io.ReadSeeker, offset int64) (time.Duration, error) {
+	if _, err := r.Seek(offset, io.SeekStart); err != nil {
+		return 0, err
+	}
+
+	var version [4]byte
+	if _, err := io.ReadFull(r, version[:]); err != nil {
+		return 0, err
+	}
+
+	var timeScale uint32
+	var duration uint64
+	if version[0] == 1 {
+		var buf [28]byte // creation (8), modification (8), time scale (4), duration (8)
+		if _, err := io.ReadFull(r, buf[:]); err != nil {
+			return 0, err
+		}
+		timeScale = binary.BigEndian.Uint32(buf[16:20])
+		duration = binary.BigEndian.Uint64(buf[20:28])
+	} else {
+		var buf [16]byte // creation (4), modification (4), time scale (4), duration (4)
+		if _, err := io.ReadFull(r, buf[:]); err != nil {
+			return 0, err
+		}
+		timeScale = binary.BigEndian.Uint32(buf[8:12])
+		duration = uint64(binary.BigEndian.Uint32(buf[12:16]))
+		if duration == 0xFFFFFFFF { // Unknown duration
+			duration = 0
+		}
+	}
+
+	if timeScale == 0 {
+		return 0, fmt.Errorf("%w: time scale is zero", ErrInvalidStream)
+	}
+	return samplesDuration(duration, timeScale), nil
+}
@@ -0,0 +1,101 @@
+package audio
+
+import (
+	"encoding/binary"
+	"testing"
+	"time"
+)
+
+// atom returns an MP4 atom with a 32-bit size.
+func atom(name string, payload ...[]byte) []byte {
+	body := concat(payload...)
+	header := binary.BigEndian.AppendUint32(nil, uint32(8+len(body)))
+	return concat(header, []byte(name), body)
+}
+
+// largeAtom returns an MP4 atom with a 64-bit size.
+func largeAtom(name string, payload []byte) []byte {
+	header := binary.BigEndian.AppendUint32(nil, 1)
+	header = append(header, name...)
+	header = binary.BigEndian.AppendUint64(header, uint64(16+len(payload)))
+	return concat(header, payload)
+}
+
+// mediaHeaderV0 returns a version 0 mvhd or mdhd payload.
+func mediaHeaderV0(timeScale, duration uint32) []byte {
+	payload := make([]byte, 20, 100)
+	binary.BigEndian.PutUint32(payload[12:], timeScale)
+	binary.BigEndian.PutUint32(payload[16:], duration)
+	return append(payload, make([]byte, 80)...) // Rate, volume, matrix, next track ID
+}
+
+// mediaHeaderV1 returns a version 1 mvhd or mdhd payload.
+func mediaHeaderV1(timeScale uint32, duration uint64) []byte {
+	payload := make([]byte, 32, 112)
+	payload[0] = 1
+	binary.BigEndian.PutUint32(payload[20:], timeScale)
+	binary.BigEndian.PutUint64(payload[24:], duration)
+	return append(payload, make([]byte, 80)...)
+}
+
+// m4aFile returns an M4A file with the given moov children and some audio data.
+func m4aFile(moovChildren ...[]byte) []byte {
+	ftyp := atom("ftyp", []byte("M4A \x00\x00\x02\x00isomiso2"))
+	mdat := atom("mdat", make([]byte, 2048))
+	return concat(ftyp, atom("moov", moovChildren...), mdat)
+}
+
+// trak returns a track whose media header is mdhd.
+func trak(mdhd []byte) []byte {
+	return atom("trak", atom("tkhd", make([]byte, 84)), atom("mdia", atom("mdhd", mdhd), atom("hdlr", make([]byte, 25))))
+}
+
+func TestM4ADuration(t *testing.T) {
+	mvhd := atom("mvhd", mediaHeaderV0(44100, 44100*245))
+	valid := m4aFile(mvhd, trak(mediaHeaderV0(44100, 44100*245)))
+
+	runDurationTests(t, ".m4a", []durationTest{
+		{name: "mvhd", data: valid, want: 245 * time.Second},
+		{name: "mvhd with millisecond time scale", data: m4aFile(atom("mvhd", mediaHeaderV0(1000, 187_450))), want: 187450 * time.Millisecond},
+		{name: "version 1 mvhd", data: m4aFile(atom("mvhd", mediaHeaderV1(48000, 48000*7200))), want: 2 * time.Hour},
+		{
+			name: "moov after mdat",
+			data: concat(atom("ftyp", []byte("M4A ")), atom("mdat", make([]byte, 4096)), atom("moov", mvhd)),
+			want: 245 * time.Second,
+		},
+		{
+			name: "64-bit atom size",
+			data: concat(atom("ftyp", []byte("M4A ")), largeAtom("mdat", make([]byte, 100)), atom("moov", mvhd)),
+			want: 245 * time.Second,
+		},
+		{
+			name: "moov extending to the end of the file",
+			data: concat(atom("ftyp", []byte("M4A ")), []byte{0, 0, 0, 0}, []byte("moov"), mvhd),
+			want: 245 * time.Second,
+		},
+		{
+			name: "mdhd when mvhd has no duration",
+			data: m4aFile(atom("mvhd", mediaHeaderV0(600, 0)), trak(mediaHeaderV0(48000, 48000*61+24000))),
+			want: 61500 * time.Millisecond,
+		},
+		{
+			name: "mdhd when mvhd duration is unknown",
+			data: m4aFile(atom("mvhd", mediaHeaderV0(600, 0xFFFFFFFF)), trak(mediaHeaderV1(22050, 22050*30))),
+			want: 30 * time.Second,
+		},
+		{
+			name: "mdhd when mvhd is missing",
+			data: m4aFile(atom("udta"), trak(mediaHeaderV0(44100, 44100*3))),
+			want: 3 * time.Second,
+		},
+
+		{name: "empty file", data: nil, wantErr: ErrInvalidStream},
+		{name: "no moov", data: concat(atom("ftyp", []byte("M4A ")), atom("mdat", make([]byte, 100))), wantErr: ErrInvalidStream},
+		{name: "truncated moov", data: valid[:len(valid)-2048-8-50], wantErr: ErrInvalidStream},
+		{name: "truncated 64-bit size", data: largeAtom("mdat", make([]byte, 100))[:12], wantErr: ErrInvalidStream},
+		{name: "truncated mvhd without track", data: m4aFile(atom("mvhd", mediaHeaderV0(44100, 0))[:16]), wantErr: ErrInvalidStream},
+		{name: "corrupt atom size", data: concat([]byte{0, 0, 0, 4}, []byte("ftyp"), atom("moov", mvhd)), wantErr: ErrInvalidStream},
+		{name: "zero time scale", data: m4aFile(atom("mvhd", mediaHeaderV0(0, 1000)), trak(mediaHeaderV0(0, 1000))), wantErr: ErrInvalidStream},
+		{name: "no duration anywhere", data: m4aFile(atom("mvhd", mediaHeaderV0(600, 0))), wantErr: ErrInvalidStream},
+	})
+}
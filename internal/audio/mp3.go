@@ -0,0 +1,231 @@
+package audio
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// MPEG audio versions, as encoded in the frame header.
+const (
+	mpeg25 = 0
+	mpeg2  = 2
+	mpeg1  = 3
+)
+
+// MPEG audio layers, as encoded in the frame header.
+const (
+	layer3 = 1
+	layer2 = 2
+	layer1 = 3
+)
+
+// mp3Bitrates holds the bitrates in kbps indexed by [MPEG1?][layer][index].
+var mp3Bitrates = [2][4][16]int{
+	{ // MPEG 2 and 2.5
+		{},
+		{0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160, 0},
+		{0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160, 0},
+		{0, 32, 48, 56, 64, 80, 96, 112, 128, 144, 160, 176, 192, 224, 256, 0},
+	},
+	{ // MPEG 1
+		{},
+		{0, 32, 40, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320, 0},
+		{0, 32, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320, 384, 0},
+		{0, 32, 64, 96, 128, 160, 192, 224, 256, 288, 320, 352, 384, 416, 448, 0},
+	},
+}
+
+// mp3SampleRates holds the sample rates in Hz indexed by [version][index].
+var mp3SampleRates = [4][3]uint32{
+	mpeg25: {11025, 12000, 8000},
+	mpeg2:  {22050, 24000, 16000},
+	mpeg1:  {44100, 48000, 32000},
+}
+
+// mp3MaxSyncSearch bounds how far past the tags the first frame is looked for.
+const mp3MaxSyncSearch = 64 * 1024
+
+var errNoFrameHeader = errors.New("not an MPEG audio frame header")
+
+// mp3Frame is a decoded MPEG audio frame header.
+type mp3Frame struct {
+	version    int
+	layer      int
+	sampleRate uint32
+	samples    uint32 // Samples per frame
+	size       int    // Frame size in bytes, header included
+	mono       bool
+}
+
+func parseMP3Frame(header uint32) (mp3Frame, error) {
+	if header&0xFFE00000 != 0xFFE00000 {
+		return mp3Frame{}, errNoFrameHeader
+	}
+	version := int(header>>19) & 0x3
+	layer := int(header>>17) & 0x3
+	bitrateIndex := int(header>>12) & 0xF
+	sampleRateIndex := int(header>>10) & 0x3
+	padding := int(header>>9) & 0x1
+	if version == 1 || layer == 0 || bitrateIndex == 0 || bitrateIndex == 15 || sampleRateIndex == 3 {
+		// Reserved values, or "free format" bitrate which cannot be sized from the header
+		return mp3Frame{}, errNoFrameHeader
+	}
+
+	isMPEG1 := 0
+	if version == mpeg1 {
+		isMPEG1 = 1
+	}
+	bitrate := mp3Bitrates[isMPEG1][layer][bitrateIndex] * 1000
+	frame := mp3Frame{
+		version:    version,
+		layer:      layer,
+		sampleRate: mp3SampleRates[version][sampleRateIndex],
+		mono:       (header>>6)&0x3 == 3,
+	}
+
+	switch {
+	case layer == layer1:
+		frame.samples = 384
+		frame.size = (12*bitrate/int(frame.sampleRate) + padding) * 4
+	case layer == layer3 && version != mpeg1:
+		frame.samples = 576
+		frame.size = 72*bitrate/int(frame.sampleRate) + padding
+	default:
+		frame.samples = 1152
+		frame.size = 144*bitrate/int(frame.sampleRate) + padding
+	}
+	if frame.size < 4 {
+		return mp3Frame{}, errNoFrameHeader
+	}
+	return frame, nil
+}
+
+// sideInfoSize is the size of the Layer III side information that follows the
+// frame header, where a Xing/Info header is stored.
+func (f mp3Frame) sideInfoSize() int {
+	switch {
+	case f.version == mpeg1 && f.mono:
+		return 17
+	case f.version == mpeg1:
+		return 32
+	case f.mono:
+		return 9
+	default:
+		return 17
+	}
+}
+
+// mp3Duration computes the duration of an MP3 stream. VBR files written by
+// most encoders carry the total number of frames in a Xing/Info or VBRI header
+// in their first frame; otherwise every frame is counted.
+func mp3Duration(r io.ReadSeeker) (time.Duration, error) {
+	end, err := r.Seek(0, io.SeekEnd)
+	if err != nil {
+		return 0, err
+	}
+	end -= id3v1Size(r, end)
+
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return 0, err
+	}
+	start, err := skipID3v2(r)
+	if err != nil {
+		return 0, err
+	}
+
+	br := bufio.NewReaderSize(io.LimitReader(r, end-start), 8192)
+	first, err := syncMP3(br, mp3MaxSyncSearch)
+	if err != nil {
+		return 0, err
+	}
+
+	frameData := make([]byte, first.size)
+	if _, err := io.ReadFull(br, frameData); err != nil {
+		return 0, err
+	}
+	if frames, ok := vbrFrameCount(first, frameData[4:]); ok {
+		return samplesDuration(uint64(frames)*uint64(first.samples), first.sampleRate), nil
+	}
+
+	// No VBR header: count the frames, including the first one
+	samples := uint64(first.samples)
+	for {
+		frame, err := syncMP3(br, mp3MaxSyncSearch)
+		if err != nil {
+			break // End of stream, or only junk left
+		}
+		if n, _ := br.Discard(frame.size); n < frame.size {
+			break // Truncated last frame
+		}
+		samples += uint64(frame.samples)
+	}
+	return samplesDuration(samples, first.sampleRate), nil
+}
+
+// syncMP3 skips bytes of br until a frame header is found, skipping at most
+// maxSearch bytes, and leaves br at the start of the frame. A header is only
+// accepted when the next frame also starts with one (or the stream ends right
+// after it), which avoids false syncs inside junk data.
+func syncMP3(br *bufio.Reader, maxSearch int) (mp3Frame, error) {
+	for skipped := 0; skipped <= maxSearch; skipped++ {
+		header, err := br.Peek(4)
+		if err != nil {
+			return mp3Frame{}, fmt.Errorf("%w: no MPEG audio frame found", ErrInvalidStream)
+		}
+		if frame, err := parseMP3Frame(binary.BigEndian.Uint32(header)); err == nil {
+			next, err := br.Peek(frame.size + 4)
+			if err != nil {
+				return frame, nil // Last frame of the stream
+			}
+			if _, err := parseMP3Frame(binary.BigEndian.Uint32(next[frame.size:])); err == nil {
+				return frame, nil
+			}
+		}
+		if _, err := br.Discard(1); err != nil {
+			return mp3Frame{}, err
+		}
+	}
+	return mp3Frame{}, fmt.Errorf("%w: no MPEG audio frame found", ErrInvalidStream)
+}
+
+// vbrFrameCount returns the number of frames declared by a Xing/Info or VBRI
+// header in the first frame. data is the frame without its 4-byte header.
+func vbrFrameCount(frame mp3Frame, data []byte) (uint32, bool) {
+	// Xing/Info: right after the side information, flags then frame count
+	if i := frame.sideInfoSize(); len(data) >= i+12 {
+		tag := string(data[i : i+4])
+		flags := binary.BigEndian.Uint32(data[i+4 : i+8])
+		if (tag == "Xing" || tag == "Info") && flags&0x1 != 0 {
+			if frames := binary.BigEndian.Uint32(data[i+8 : i+12]); frames > 0 {
+				return frames, true
+			}
+		}
+	}
+
+	// VBRI (Fraunhofer): 32 bytes after the header; version, delay, quality, bytes, frames
+	if len(data) >= 32+18 && string(data[32:36]) == "VBRI" {
+		if frames := binary.BigEndian.Uint32(data[46:50]); frames > 0 {
+			return frames, true
+		}
+	}
+	return 0, false
+}
+
+// id3v1Size returns 128 when the stream ends with an ID3v1 tag, 0 otherwise.
+func id3v1Size(r io.ReadSeeker, end int64) int64 {
+	if end < 128 {
+		return 0
+	}
+	if _, err := r.Seek(end-128, io.SeekStart); err != nil {
+		return 0
+	}
+	var marker [3]byte
+	if _, err := io.ReadFull(r, marker[:]); err != nil || string(marker[:]) != "TAG" {
+		return 0
+	}
+	return 128
+}
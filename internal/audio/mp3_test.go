@@ -0,0 +1,165 @@
+package audio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+	"time"
+)
+
+// Frame headers used by the fixtures.
+const (
+	// MPEG-1 Layer III, 128 kbps, 44100 Hz, stereo: 417 bytes, 1152 samples
+	mpeg1Header = 0xFFFB9000
+	// MPEG-2 Layer III, 64 kbps, 22050 Hz, mono: 208 bytes, 576 samples
+	mpeg2MonoHeader = 0xFFF380C0
+)
+
+// mp3TestFrame returns one frame with the given header and a silent payload.
+func mp3TestFrame(header uint32) []byte {
+	frame, err := parseMP3Frame(header)
+	if err != nil {
+		panic(err)
+	}
+	return mp3RawFrames(header, frame.size, 1)
+}
+
+// mp3RawFrames returns n blocks of size bytes starting with header, which
+// need not be valid.
+func mp3RawFrames(header uint32, size, n int) []byte {
+	data := make([]byte, size*n)
+	for i := range n {
+		binary.BigEndian.PutUint32(data[i*size:], header)
+	}
+	return data
+}
+
+// mp3Frames returns n consecutive frames.
+func mp3Frames(header uint32, n int) []byte {
+	return bytes.Repeat(mp3TestFrame(header), n)
+}
+
+// xingFrame returns a first frame holding a Xing or Info header with the
+// frame count of the stream.
+func xingFrame(header uint32, tag string, frames uint32) []byte {
+	data := mp3TestFrame(header)
+	parsed, _ := parseMP3Frame(header)
+	i := 4 + parsed.sideInfoSize()
+	copy(data[i:], tag)
+	binary.BigEndian.PutUint32(data[i+4:], 0x1) // Frames field present
+	binary.BigEndian.PutUint32(data[i+8:], frames)
+	return data
+}
+
+// vbriFrame returns a first frame holding a Fraunhofer VBRI header with the
+// frame count of the stream.
+func vbriFrame(header uint32, frames uint32) []byte {
+	data := mp3TestFrame(header)
+	copy(data[36:], "VBRI")
+	binary.BigEndian.PutUint16(data[40:], 1) // Version
+	binary.BigEndian.PutUint32(data[50:], frames)
+	return data
+}
+
+// id3v1Tag returns a 128-byte ID3v1 tag, which holds no frame headers.
+func id3v1Tag() []byte {
+	tag := make([]byte, 128)
+	copy(tag, "TAG")
+	return tag
+}
+
+// mp3FramesDuration is the duration of n frames of samples samples each.
+func mp3FramesDuration(n int, samples, sampleRate int) time.Duration {
+	return time.Duration(n) * time.Duration(samples) * time.Second / time.Duration(sampleRate)
+}
+
+func TestMP3Duration(t *testing.T) {
+	cbr := mp3Frames(mpeg1Header, 383)
+	cbrDuration := mp3FramesDuration(383, 1152, 44100) // 10.005s
+
+	runDurationTests(t, ".mp3", []durationTest{
+		{name: "counted frames", data: cbr, want: cbrDuration},
+		{name: "single frame", data: mp3TestFrame(mpeg1Header), want: mp3FramesDuration(1, 1152, 44100)},
+		{name: "counted MPEG-2 frames", data: mp3Frames(mpeg2MonoHeader, 500), want: mp3FramesDuration(500, 576, 22050)},
+		{name: "ID3v2 and ID3v1 tags", data: concat(id3v2Tag(1000), cbr, id3v1Tag()), want: cbrDuration},
+		{name: "junk before the first frame", data: concat([]byte{0xFF, 0xFB, 0x00, 0x42, 0x13}, cbr), want: cbrDuration},
+		{name: "truncated last frame", data: concat(cbr, mp3TestFrame(mpeg1Header)[:100]), want: cbrDuration},
+		{
+			name: "Xing header",
+			data: concat(xingFrame(mpeg1Header, "Xing", 1149), mp3Frames(mpeg1Header, 2)),
+			want: mp3FramesDuration(1149, 1152, 44100), // 30.015s; the file holds 3 frames
+		},
+		{
+			name: "Info header",
+			data: concat(id3v2Tag(20), xingFrame(mpeg1Header, "Info", 383), cbr[:417*5]),
+			want: cbrDuration,
+		},
+		{
+			name: "Xing header of mono MPEG-2",
+			data: concat(xingFrame(mpeg2MonoHeader, "Xing", 7656), mp3Frames(mpeg2MonoHeader, 2)),
+			want: mp3FramesDuration(7656, 576, 22050), // 199.99s
+		},
+		{
+			name: "VBRI header",
+			data: concat(vbriFrame(mpeg1Header, 2297), mp3Frames(mpeg1Header, 2)),
+			want: mp3FramesDuration(2297, 1152, 44100), // 60.003s
+		},
+		{
+			name: "Xing header without frame count",
+			data: concat(xingFrame(mpeg1Header, "Xing", 0), mp3Frames(mpeg1Header, 9)),
+			want: mp3FramesDuration(10, 1152, 44100),
+		},
+
+		{name: "empty file", data: nil, wantErr: ErrInvalidStream},
+		{name: "only tags", data: concat(id3v2Tag(100), id3v1Tag()), wantErr: ErrInvalidStream},
+		{name: "truncated frame header", data: mp3TestFrame(mpeg1Header)[:3], wantErr: ErrInvalidStream},
+		{name: "truncated first frame", data: xingFrame(mpeg1Header, "Xing", 1149)[:60], wantErr: ErrInvalidStream},
+		{name: "truncated ID3v2 tag", data: id3v2Tag(100)[:8], wantErr: ErrInvalidStream},
+		{name: "ID3v2 tag larger than the file", data: concat(id3v2Tag(5000)[:10], cbr[:417*2]), wantErr: ErrInvalidStream},
+		{name: "corrupt frame headers", data: mp3RawFrames(0xFFEB9000, 417, 20), wantErr: ErrInvalidStream}, // Reserved version
+		{name: "free format bitrate", data: mp3RawFrames(0xFFFB0000, 417, 20), wantErr: ErrInvalidStream},
+		{name: "no frames", data: bytes.Repeat([]byte("junk"), 1000), wantErr: ErrInvalidStream},
+	})
+}
+
+func TestParseMP3Frame(t *testing.T) {
+	tests := []struct {
+		name    string
+		header  uint32
+		samples uint32
+		rate    uint32
+		size    int
+	}{
+		{"MPEG-1 Layer III", mpeg1Header, 1152, 44100, 417},
+		{"MPEG-1 Layer III padded", mpeg1Header | 1<<9, 1152, 44100, 418},
+		{"MPEG-1 Layer III 320 kbps 48 kHz", 0xFFFBE400, 1152, 48000, 960},
+		{"MPEG-2 Layer III", mpeg2MonoHeader, 576, 22050, 208},
+		{"MPEG-2.5 Layer III", 0xFFE38000, 576, 11025, 417},
+		{"MPEG-1 Layer II 160 kbps", 0xFFFD9000, 1152, 44100, 522},
+		{"MPEG-1 Layer I 288 kbps", 0xFFFF9000, 384, 44100, 312},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			frame, err := parseMP3Frame(tt.header)
+			if err != nil {
+				t.Fatalf("parseMP3Frame(%08X): %v", tt.header, err)
+			}
+			if frame.samples != tt.samples || frame.sampleRate != tt.rate || frame.size != tt.size {
+				t.Errorf("parseMP3Frame(%08X) = %d samples, %d Hz, %d bytes; want %d, %d, %d",
+					tt.header, frame.samples, frame.sampleRate, frame.size, tt.samples, tt.rate, tt.size)
+			}
+		})
+	}
+
+	for _, header := range []uint32{
+		0x00000000, // No sync
+		0xFFEB9000, // Reserved version
+		0xFFF99000, // Reserved layer
+		0xFFFBF000, // Bad bitrate
+		0xFFFB9C00, // Reserved sample rate
+	} {
+		if _, err := parseMP3Frame(header); err == nil {
+			t.Errorf("parseMP3Frame(%08X) accepted a bad header", header)
+		}
+	}
+}
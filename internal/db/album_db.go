@@ -0,0 +1,96 @@
+package db
+
+import (
+	"context"
+
+	"github.com/demispreviotto/cajitamusical/cajitamusical-backend/internal/models"
+	"github.com/google/uuid"
+)
+
+// AlbumDBer defines the interface for album database operations.
+type AlbumDBer interface {
+	ListAlbums(ctx context.Context, artistID *uuid.UUID, limit, offset int) ([]AlbumSummary, int64, error)
+	GetAlbumByID(ctx context.Context, albumID uuid.UUID) (*AlbumSummary, error)
+	GetAlbumTracks(ctx context.Context, albumID uuid.UUID) ([]models.Song, error)
+}
+
+// AlbumSummary is an album plus its artist name and aggregates over its songs.
+type AlbumSummary struct {
+	models.Album
+	ArtistName           string
+	Year                 int // Latest year among its songs
+	TrackCount           int
+	TotalDurationSeconds int
+	SamplePath           string // FilePath of one of its songs, used to derive the album art
+}
+
+// albumDB is the concrete implementation of AlbumDBer.
+type albumDB struct{}
+
+// NewAlbumDB creates a new instance of AlbumDB.
+func NewAlbumDB() AlbumDBer {
+	return &albumDB{}
+}
+
+// albumSummarySelect computes the fields of AlbumSummary; it expects artists and songs to be JOINed.
+const albumSummarySelect = `albums.*, artists.name AS artist_name,
+	COALESCE(MAX(songs.year), 0) AS year,
+	COUNT(songs.id) AS track_count,
+	COALESCE(SUM(songs.duration_seconds), 0) AS total_duration_seconds,
+	COALESCE(MIN(songs.file_path), '') AS sample_path`
+
+// ListAlbums retrieves a page of albums, optionally only those of an artist,
+// ordered by artist and title (or by year for a single artist), plus the total.
+func (adb *albumDB) ListAlbums(ctx context.Context, artistID *uuid.UUID, limit, offset int) ([]AlbumSummary, int64, error) {
+	countQuery := DB.WithContext(ctx).Model(&models.Album{})
+	if artistID != nil {
+		countQuery = countQuery.Where("artist_id = ?", *artistID)
+	}
+	var total int64
+	if err := countQuery.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	query := DB.WithContext(ctx).Model(&models.Album{}).
+		Select(albumSummarySelect).
+		Joins("JOIN artists ON artists.id = albums.artist_id").
+		Joins("LEFT JOIN songs ON songs.album_id = albums.id").
+		Group("albums.id, artists.name")
+	if artistID != nil {
+		query = query.Where("albums.artist_id = ?", *artistID).Order("year ASC").Order("albums.title ASC")
+	} else {
+		query = query.Order("artists.name ASC").Order("albums.title ASC")
+	}
+
+	var albums []AlbumSummary
+	err := query.Limit(limit).Offset(offset).Scan(&albums).Error
+	return albums, total, err
+}
+
+// GetAlbumByID retrieves an album with its artist name and aggregates.
+func (adb *albumDB) GetAlbumByID(ctx context.Context, albumID uuid.UUID) (*AlbumSummary, error) {
+	var album AlbumSummary
+	err := DB.WithContext(ctx).Model(&models.Album{}).
+		Select(albumSummarySelect).
+		Joins("JOIN artists ON artists.id = albums.artist_id").
+		Joins("LEFT JOIN songs ON songs.album_id = albums.id").
+		Where("albums.id = ?", albumID).
+		Group("albums.id, artists.name").
+		Take(&album).Error
+	if err != nil {
+		return nil, err
+	}
+	return &album, nil
+}
+
+// GetAlbumTracks retrieves the songs of an album sorted by disc and track number.
+func (adb *albumDB) GetAlbumTracks(ctx context.Context, albumID uuid.UUID) ([]models.Song, error) {
+	var songs []models.Song
+	err := DB.WithContext(ctx).
+		Where("album_id = ?", albumID).
+		Order("disc_number ASC").
+		Order("track_number ASC").
+		Order("title ASC").
+		Find(&songs).Error
+	return songs, err
+}
@@ -0,0 +1,66 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/demispreviotto/cajitamusical/cajitamusical-backend/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// APITokenDBer defines the interface for API token database operations.
+type APITokenDBer interface {
+	CreateAPIToken(ctx context.Context, token *models.APIToken) error
+	GetAPITokenByHash(ctx context.Context, tokenHash string) (*models.APIToken, error)
+	ListAPITokens(ctx context.Context, userID uuid.UUID) ([]models.APIToken, error)
+	DeleteAPIToken(ctx context.Context, userID, tokenID uuid.UUID) error
+	TouchAPIToken(ctx context.Context, tokenID uuid.UUID, lastUsedAt time.Time) error
+}
+
+// apiTokenDB is the concrete implementation of APITokenDBer.
+type apiTokenDB struct{}
+
+// NewAPITokenDB creates a new instance of APITokenDB.
+func NewAPITokenDB() APITokenDBer {
+	return &apiTokenDB{}
+}
+
+// CreateAPIToken stores a new token.
+func (adb *apiTokenDB) CreateAPIToken(ctx context.Context, token *models.APIToken) error {
+	return DB.WithContext(ctx).Create(token).Error
+}
+
+// GetAPITokenByHash retrieves a token by the hash of its secret, expired or not.
+func (adb *apiTokenDB) GetAPITokenByHash(ctx context.Context, tokenHash string) (*models.APIToken, error) {
+	var token models.APIToken
+	if err := DB.WithContext(ctx).Where("token_hash = ?", tokenHash).First(&token).Error; err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// ListAPITokens returns the tokens of a user, newest first.
+func (adb *apiTokenDB) ListAPITokens(ctx context.Context, userID uuid.UUID) ([]models.APIToken, error) {
+	var tokens []models.APIToken
+	err := DB.WithContext(ctx).Where("user_id = ?", userID).Order("created_at DESC").Find(&tokens).Error
+	return tokens, err
+}
+
+// DeleteAPIToken removes a token of the user. It returns gorm.ErrRecordNotFound
+// if the token does not exist or belongs to another user.
+func (adb *apiTokenDB) DeleteAPIToken(ctx context.Context, userID, tokenID uuid.UUID) error {
+	res := DB.WithContext(ctx).Where("id = ? AND user_id = ?", tokenID, userID).Delete(&models.APIToken{})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// TouchAPIToken records when a token was last used.
+func (adb *apiTokenDB) TouchAPIToken(ctx context.Context, tokenID uuid.UUID, lastUsedAt time.Time) error {
+	return DB.WithContext(ctx).Model(&models.APIToken{}).Where("id = ?", tokenID).Update("last_used_at", lastUsedAt).Error
+}
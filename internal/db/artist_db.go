@@ -0,0 +1,70 @@
+package db
+
+import (
+	"context"
+
+	"github.com/demispreviotto/cajitamusical/cajitamusical-backend/internal/models"
+	"github.com/google/uuid"
+)
+
+// ArtistDBer defines the interface for artist database operations.
+type ArtistDBer interface {
+	ListArtists(ctx context.Context, limit, offset int) ([]ArtistSummary, int64, error)
+	GetArtistByID(ctx context.Context, artistID uuid.UUID) (*ArtistSummary, error)
+}
+
+// ArtistSummary is an artist plus aggregates over its albums and songs.
+type ArtistSummary struct {
+	models.Artist
+	AlbumCount           int
+	TrackCount           int
+	TotalDurationSeconds int
+}
+
+// artistDB is the concrete implementation of ArtistDBer.
+type artistDB struct{}
+
+// NewArtistDB creates a new instance of ArtistDB.
+func NewArtistDB() ArtistDBer {
+	return &artistDB{}
+}
+
+// artistSummarySelect computes the aggregates of ArtistSummary; it expects songs to be LEFT JOINed.
+const artistSummarySelect = `artists.*,
+	(SELECT COUNT(*) FROM albums WHERE albums.artist_id = artists.id) AS album_count,
+	COUNT(songs.id) AS track_count,
+	COALESCE(SUM(songs.duration_seconds), 0) AS total_duration_seconds`
+
+// ListArtists retrieves a page of artists ordered by name, plus the total number of artists.
+func (adb *artistDB) ListArtists(ctx context.Context, limit, offset int) ([]ArtistSummary, int64, error) {
+	var total int64
+	if err := DB.WithContext(ctx).Model(&models.Artist{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var artists []ArtistSummary
+	err := DB.WithContext(ctx).Model(&models.Artist{}).
+		Select(artistSummarySelect).
+		Joins("LEFT JOIN songs ON songs.artist_id = artists.id").
+		Group("artists.id").
+		Order("artists.name ASC").
+		Limit(limit).
+		Offset(offset).
+		Scan(&artists).Error
+	return artists, total, err
+}
+
+// GetArtistByID retrieves an artist with its aggregates.
+func (adb *artistDB) GetArtistByID(ctx context.Context, artistID uuid.UUID) (*ArtistSummary, error) {
+	var artist ArtistSummary
+	err := DB.WithContext(ctx).Model(&models.Artist{}).
+		Select(artistSummarySelect).
+		Joins("LEFT JOIN songs ON songs.artist_id = artists.id").
+		Where("artists.id = ?", artistID).
+		Group("artists.id").
+		Take(&artist).Error
+	if err != nil {
+		return nil, err
+	}
+	return &artist, nil
+}
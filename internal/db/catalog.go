@@ -0,0 +1,131 @@
+package db
+
+import (
+	"strings"
+
+	"github.com/demispreviotto/cajitamusical/cajitamusical-backend/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// catalogResolver finds or creates the Artist and Album rows a song belongs to.
+// It caches the IDs it resolves, so a library scan does not look them up once
+// per song. It is not safe for concurrent use.
+type catalogResolver struct {
+	artists map[string]uuid.UUID
+	albums  map[albumKey]uuid.UUID
+}
+
+type albumKey struct {
+	artistID uuid.UUID
+	title    string
+}
+
+func newCatalogResolver() *catalogResolver {
+	return &catalogResolver{
+		artists: make(map[string]uuid.UUID),
+		albums:  make(map[albumKey]uuid.UUID),
+	}
+}
+
+// link sets ArtistID and AlbumID on song from its Artist, AlbumArtist and Album
+// fields. The album belongs to the album artist, or to the track artist when
+// the album artist tag is empty.
+func (r *catalogResolver) link(tx *gorm.DB, song *models.Song) error {
+	song.ArtistID, song.AlbumID = nil, nil
+
+	artistName := strings.TrimSpace(song.Artist)
+	if artistName != "" {
+		artistID, err := r.artistID(tx, artistName)
+		if err != nil {
+			return err
+		}
+		song.ArtistID = &artistID
+	}
+
+	albumTitle := strings.TrimSpace(song.Album)
+	albumArtistName := strings.TrimSpace(song.AlbumArtist)
+	if albumArtistName == "" {
+		albumArtistName = artistName
+	}
+	if albumTitle == "" || albumArtistName == "" {
+		return nil
+	}
+
+	albumArtistID, err := r.artistID(tx, albumArtistName)
+	if err != nil {
+		return err
+	}
+	albumID, err := r.albumID(tx, albumArtistID, albumTitle)
+	if err != nil {
+		return err
+	}
+	song.AlbumID = &albumID
+	return nil
+}
+
+func (r *catalogResolver) artistID(tx *gorm.DB, name string) (uuid.UUID, error) {
+	if id, ok := r.artists[name]; ok {
+		return id, nil
+	}
+
+	// Insert if missing; on conflict nothing is returned, so read the existing row
+	artist := models.Artist{Name: name}
+	if err := tx.Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "name"}}, DoNothing: true}).Create(&artist).Error; err != nil {
+		return uuid.Nil, err
+	}
+	if artist.ID == uuid.Nil {
+		if err := tx.Where("name = ?", name).First(&artist).Error; err != nil {
+			return uuid.Nil, err
+		}
+	}
+
+	r.artists[name] = artist.ID
+	return artist.ID, nil
+}
+
+func (r *catalogResolver) albumID(tx *gorm.DB, artistID uuid.UUID, title string) (uuid.UUID, error) {
+	key := albumKey{artistID: artistID, title: title}
+	if id, ok := r.albums[key]; ok {
+		return id, nil
+	}
+
+	album := models.Album{Title: title, ArtistID: artistID}
+	if err := tx.Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "title"}, {Name: "artist_id"}}, DoNothing: true}).Create(&album).Error; err != nil {
+		return uuid.Nil, err
+	}
+	if album.ID == uuid.Nil {
+		if err := tx.Where("title = ? AND artist_id = ?", title, artistID).First(&album).Error; err != nil {
+			return uuid.Nil, err
+		}
+	}
+
+	r.albums[key] = album.ID
+	return album.ID, nil
+}
+
+// pruneCatalog removes albums without songs and artists without songs or albums.
+func pruneCatalog(tx *gorm.DB) error {
+	if err := tx.Exec(`DELETE FROM albums WHERE NOT EXISTS (SELECT 1 FROM songs WHERE songs.album_id = albums.id)`).Error; err != nil {
+		return err
+	}
+	return tx.Exec(`DELETE FROM artists
+		WHERE NOT EXISTS (SELECT 1 FROM songs WHERE songs.artist_id = artists.id)
+		AND NOT EXISTS (SELECT 1 FROM albums WHERE albums.artist_id = artists.id)`).Error
+}
+
+// pruneCatalogUnlessScanning prunes like pruneCatalog unless a library scan is
+// running. The scan prunes when it ends; pruning earlier could delete artists
+// and albums it has resolved but not written yet. The scan lock is taken in
+// shared mode, which only scans hold exclusively (see TryShareScanLock).
+func pruneCatalogUnlessScanning(tx *gorm.DB) error {
+	var acquired bool
+	if err := tx.Raw("SELECT pg_try_advisory_xact_lock_shared(?)", libraryScanLockKey).Scan(&acquired).Error; err != nil {
+		return err
+	}
+	if !acquired {
+		return nil
+	}
+	return pruneCatalog(tx)
+}
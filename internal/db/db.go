@@ -10,6 +10,10 @@ import (
 	"gorm.io/gorm/logger"
 )
 
+// maxInClauseIDs es el mayor número de IDs que se envía como parámetro de un IN.
+// Para listas más grandes se consulta sin filtrar por ID (límite de parámetros de Postgres).
+const maxInClauseIDs = 1000
+
 // DB es la instancia global de la conexión a la base de datos GORM.
 var DB *gorm.DB
 
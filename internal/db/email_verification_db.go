@@ -0,0 +1,85 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/demispreviotto/cajitamusical/cajitamusical-backend/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// EmailVerificationDBer defines the interface for email verification operations.
+type EmailVerificationDBer interface {
+	CreateEmailVerificationToken(ctx context.Context, token *models.EmailVerificationToken) error
+	GetLatestEmailVerificationToken(ctx context.Context, userID uuid.UUID) (*models.EmailVerificationToken, error)
+	VerifyEmail(ctx context.Context, tokenHash string, now time.Time) (uuid.UUID, error)
+	DeleteExpiredEmailVerificationTokens(ctx context.Context, before time.Time) error
+}
+
+// emailVerificationDB is the concrete implementation of EmailVerificationDBer.
+type emailVerificationDB struct{}
+
+// NewEmailVerificationDB creates a new instance of EmailVerificationDB.
+func NewEmailVerificationDB() EmailVerificationDBer {
+	return &emailVerificationDB{}
+}
+
+// CreateEmailVerificationToken stores a new token, discarding the previous
+// ones of the same user so only the latest link works.
+func (edb *emailVerificationDB) CreateEmailVerificationToken(ctx context.Context, token *models.EmailVerificationToken) error {
+	return DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", token.UserID).Delete(&models.EmailVerificationToken{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(token).Error
+	})
+}
+
+// GetLatestEmailVerificationToken returns the most recent token of the user.
+func (edb *emailVerificationDB) GetLatestEmailVerificationToken(ctx context.Context, userID uuid.UUID) (*models.EmailVerificationToken, error) {
+	var token models.EmailVerificationToken
+	if err := DB.WithContext(ctx).Where("user_id = ?", userID).Order("created_at DESC").First(&token).Error; err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// VerifyEmail consumes an unexpired token and marks the email of its user as
+// verified, if it is still the address the token was sent to. It returns the
+// ID of the user, or gorm.ErrRecordNotFound when the token is unknown,
+// expired or for an old address.
+func (edb *emailVerificationDB) VerifyEmail(ctx context.Context, tokenHash string, now time.Time) (uuid.UUID, error) {
+	var userID uuid.UUID
+	err := DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var tokens []models.EmailVerificationToken
+		res := tx.Clauses(clause.Returning{}).
+			Where("token_hash = ? AND expires_at > ?", tokenHash, now).
+			Delete(&tokens)
+		if res.Error != nil {
+			return res.Error
+		}
+		if len(tokens) == 0 {
+			return gorm.ErrRecordNotFound
+		}
+
+		res = tx.Model(&models.User{}).
+			Where("id = ? AND email = ?", tokens[0].UserID, tokens[0].Email).
+			Update("email_verified_at", now)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		userID = tokens[0].UserID
+		return nil
+	})
+	return userID, err
+}
+
+// DeleteExpiredEmailVerificationTokens removes the tokens that expired before before.
+func (edb *emailVerificationDB) DeleteExpiredEmailVerificationTokens(ctx context.Context, before time.Time) error {
+	return DB.WithContext(ctx).Where("expires_at < ?", before).Delete(&models.EmailVerificationToken{}).Error
+}
@@ -0,0 +1,115 @@
+package db
+
+import (
+	"context"
+
+	"github.com/demispreviotto/cajitamusical/cajitamusical-backend/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm/clause"
+)
+
+// FavoriteDBer defines the interface for favorites database operations.
+type FavoriteDBer interface {
+	AddFavoriteSong(ctx context.Context, userID, songID uuid.UUID) error
+	RemoveFavoriteSong(ctx context.Context, userID, songID uuid.UUID) error
+	GetFavoriteSongs(ctx context.Context, userID uuid.UUID) ([]models.Song, error)
+	GetFavoriteSongIDs(ctx context.Context, userID uuid.UUID, songIDs []uuid.UUID) (map[uuid.UUID]bool, error)
+	AddFavoriteAlbum(ctx context.Context, userID, albumID uuid.UUID) error
+	RemoveFavoriteAlbum(ctx context.Context, userID, albumID uuid.UUID) error
+	GetFavoriteAlbums(ctx context.Context, userID uuid.UUID) ([]models.FavoriteAlbum, error)
+	AddFavoriteArtist(ctx context.Context, userID, artistID uuid.UUID) error
+	RemoveFavoriteArtist(ctx context.Context, userID, artistID uuid.UUID) error
+	GetFavoriteArtists(ctx context.Context, userID uuid.UUID) ([]models.FavoriteArtist, error)
+}
+
+// favoriteDB is the concrete implementation of FavoriteDBer.
+type favoriteDB struct{}
+
+// NewFavoriteDB creates a new instance of FavoriteDB.
+func NewFavoriteDB() FavoriteDBer {
+	return &favoriteDB{}
+}
+
+// AddFavoriteSong stars a song for a user. Starring twice is a no-op.
+func (fdb *favoriteDB) AddFavoriteSong(ctx context.Context, userID, songID uuid.UUID) error {
+	favorite := models.FavoriteSong{UserID: userID, SongID: songID}
+	return DB.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&favorite).Error
+}
+
+// RemoveFavoriteSong unstars a song for a user.
+func (fdb *favoriteDB) RemoveFavoriteSong(ctx context.Context, userID, songID uuid.UUID) error {
+	return DB.WithContext(ctx).Where("user_id = ? AND song_id = ?", userID, songID).Delete(&models.FavoriteSong{}).Error
+}
+
+// GetFavoriteSongs retrieves the songs starred by a user, most recent first.
+func (fdb *favoriteDB) GetFavoriteSongs(ctx context.Context, userID uuid.UUID) ([]models.Song, error) {
+	var songs []models.Song
+	err := DB.WithContext(ctx).
+		Joins("JOIN favorite_songs ON favorite_songs.song_id = songs.id").
+		Where("favorite_songs.user_id = ?", userID).
+		Order("favorite_songs.created_at DESC").
+		Find(&songs).Error
+	return songs, err
+}
+
+// GetFavoriteSongIDs returns which of songIDs are starred by a user.
+func (fdb *favoriteDB) GetFavoriteSongIDs(ctx context.Context, userID uuid.UUID, songIDs []uuid.UUID) (map[uuid.UUID]bool, error) {
+	favorites := make(map[uuid.UUID]bool)
+	if len(songIDs) == 0 {
+		return favorites, nil
+	}
+
+	query := DB.WithContext(ctx).Model(&models.FavoriteSong{}).Where("user_id = ?", userID)
+	// For big lists (e.g. the whole library) loading every favorite of the user
+	// is cheaper than a huge IN clause, and stays under Postgres' parameter limit.
+	if len(songIDs) <= maxInClauseIDs {
+		query = query.Where("song_id IN ?", songIDs)
+	}
+
+	var ids []uuid.UUID
+	err := query.Pluck("song_id", &ids).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range ids {
+		favorites[id] = true
+	}
+	return favorites, nil
+}
+
+// AddFavoriteAlbum stars an album for a user. Starring twice is a no-op.
+func (fdb *favoriteDB) AddFavoriteAlbum(ctx context.Context, userID, albumID uuid.UUID) error {
+	favorite := models.FavoriteAlbum{UserID: userID, AlbumID: albumID}
+	return DB.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&favorite).Error
+}
+
+// RemoveFavoriteAlbum unstars an album for a user.
+func (fdb *favoriteDB) RemoveFavoriteAlbum(ctx context.Context, userID, albumID uuid.UUID) error {
+	return DB.WithContext(ctx).Where("user_id = ? AND album_id = ?", userID, albumID).Delete(&models.FavoriteAlbum{}).Error
+}
+
+// GetFavoriteAlbums retrieves the albums starred by a user, with their artist,
+// most recent first.
+func (fdb *favoriteDB) GetFavoriteAlbums(ctx context.Context, userID uuid.UUID) ([]models.FavoriteAlbum, error) {
+	var albums []models.FavoriteAlbum
+	err := DB.WithContext(ctx).Preload("Album.Artist").Where("user_id = ?", userID).Order("created_at DESC").Find(&albums).Error
+	return albums, err
+}
+
+// AddFavoriteArtist stars an artist for a user. Starring twice is a no-op.
+func (fdb *favoriteDB) AddFavoriteArtist(ctx context.Context, userID, artistID uuid.UUID) error {
+	favorite := models.FavoriteArtist{UserID: userID, ArtistID: artistID}
+	return DB.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&favorite).Error
+}
+
+// RemoveFavoriteArtist unstars an artist for a user.
+func (fdb *favoriteDB) RemoveFavoriteArtist(ctx context.Context, userID, artistID uuid.UUID) error {
+	return DB.WithContext(ctx).Where("user_id = ? AND artist_id = ?", userID, artistID).Delete(&models.FavoriteArtist{}).Error
+}
+
+// GetFavoriteArtists retrieves the artists starred by a user, most recent first.
+func (fdb *favoriteDB) GetFavoriteArtists(ctx context.Context, userID uuid.UUID) ([]models.FavoriteArtist, error) {
+	var artists []models.FavoriteArtist
+	err := DB.WithContext(ctx).Preload("Artist").Where("user_id = ?", userID).Order("created_at DESC").Find(&artists).Error
+	return artists, err
+}
@@ -0,0 +1,98 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/demispreviotto/cajitamusical/cajitamusical-backend/internal/models"
+	"github.com/google/uuid"
+)
+
+// HistoryDBer defines the interface for play history database operations.
+type HistoryDBer interface {
+	CreatePlay(ctx context.Context, play *models.PlayHistory) error
+	GetPlayByID(ctx context.Context, playID uuid.UUID) (*models.PlayHistory, error)
+	UpdatePlay(ctx context.Context, play *models.PlayHistory) error
+	GetHistoryByUser(ctx context.Context, userID uuid.UUID, limit, offset int) ([]models.PlayHistory, int64, error)
+	GetPlayStats(ctx context.Context, userID uuid.UUID, songIDs []uuid.UUID) (map[uuid.UUID]PlayStats, error)
+}
+
+// PlayStats aggregates the play history of a song for one user.
+type PlayStats struct {
+	SongID       uuid.UUID
+	PlayCount    int // Number of completed plays
+	LastPlayedAt *time.Time
+}
+
+// historyDB is the concrete implementation of HistoryDBer.
+type historyDB struct{}
+
+// NewHistoryDB creates a new instance of HistoryDB.
+func NewHistoryDB() HistoryDBer {
+	return &historyDB{}
+}
+
+// CreatePlay stores a new play history entry.
+func (hdb *historyDB) CreatePlay(ctx context.Context, play *models.PlayHistory) error {
+	return DB.WithContext(ctx).Create(play).Error
+}
+
+// GetPlayByID retrieves a play history entry by its ID.
+func (hdb *historyDB) GetPlayByID(ctx context.Context, playID uuid.UUID) (*models.PlayHistory, error) {
+	var play models.PlayHistory
+	if err := DB.WithContext(ctx).First(&play, "id = ?", playID).Error; err != nil {
+		return nil, err
+	}
+	return &play, nil
+}
+
+// UpdatePlay saves the status, position and end time of a play history entry.
+func (hdb *historyDB) UpdatePlay(ctx context.Context, play *models.PlayHistory) error {
+	return DB.WithContext(ctx).Model(play).
+		Select("status", "position_seconds", "ended_at", "updated_at").
+		Updates(play).Error
+}
+
+// GetHistoryByUser retrieves a page of the play history of a user, most recent
+// first, with their songs, plus the total number of entries.
+func (hdb *historyDB) GetHistoryByUser(ctx context.Context, userID uuid.UUID, limit, offset int) ([]models.PlayHistory, int64, error) {
+	var total int64
+	if err := DB.WithContext(ctx).Model(&models.PlayHistory{}).Where("user_id = ?", userID).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var plays []models.PlayHistory
+	err := DB.WithContext(ctx).
+		Preload("Song").
+		Where("user_id = ?", userID).
+		Order("started_at DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&plays).Error
+	return plays, total, err
+}
+
+// GetPlayStats returns play counts and last play times of songIDs for a user.
+// Songs never played by the user are absent from the map.
+func (hdb *historyDB) GetPlayStats(ctx context.Context, userID uuid.UUID, songIDs []uuid.UUID) (map[uuid.UUID]PlayStats, error) {
+	stats := make(map[uuid.UUID]PlayStats)
+	if len(songIDs) == 0 {
+		return stats, nil
+	}
+
+	query := DB.WithContext(ctx).Model(&models.PlayHistory{}).
+		Select("song_id, COUNT(*) FILTER (WHERE status = ?) AS play_count, MAX(started_at) AS last_played_at", models.PlayStatusCompleted).
+		Where("user_id = ?", userID)
+	if len(songIDs) <= maxInClauseIDs {
+		query = query.Where("song_id IN ?", songIDs)
+	}
+
+	var rows []PlayStats
+	if err := query.Group("song_id").Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		stats[row.SongID] = row
+	}
+	return stats, nil
+}
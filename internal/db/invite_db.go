@@ -0,0 +1,49 @@
+package db
+
+import (
+	"context"
+
+	"github.com/demispreviotto/cajitamusical/cajitamusical-backend/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// InviteDBer defines the interface for invite database operations. Invites
+// are redeemed by UserDBer.CreateUser, in the same transaction as the user.
+type InviteDBer interface {
+	CreateInvite(ctx context.Context, invite *models.Invite) error
+	ListInvites(ctx context.Context) ([]models.Invite, error)
+	DeleteInvite(ctx context.Context, inviteID uuid.UUID) error
+}
+
+// inviteDB is the concrete implementation of InviteDBer.
+type inviteDB struct{}
+
+// NewInviteDB creates a new instance of InviteDB.
+func NewInviteDB() InviteDBer {
+	return &inviteDB{}
+}
+
+// CreateInvite stores a new invite.
+func (idb *inviteDB) CreateInvite(ctx context.Context, invite *models.Invite) error {
+	return DB.WithContext(ctx).Create(invite).Error
+}
+
+// ListInvites returns every invite, newest first.
+func (idb *inviteDB) ListInvites(ctx context.Context) ([]models.Invite, error) {
+	var invites []models.Invite
+	err := DB.WithContext(ctx).Order("created_at DESC").Find(&invites).Error
+	return invites, err
+}
+
+// DeleteInvite removes an invite. It returns gorm.ErrRecordNotFound if it does not exist.
+func (idb *inviteDB) DeleteInvite(ctx context.Context, inviteID uuid.UUID) error {
+	res := DB.WithContext(ctx).Delete(&models.Invite{}, "id = ?", inviteID)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
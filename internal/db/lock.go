@@ -0,0 +1,72 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"log"
+)
+
+// ErrLockHeld is returned by tryAdvisoryLock when another session holds the lock.
+var ErrLockHeld = errors.New("lock is held by another process")
+
+// Claves de los advisory locks de PostgreSQL usados por la aplicación.
+const (
+	libraryScanLockKey   int64 = 0x63616a6974610001 // Escaneo de la biblioteca
+	userBootstrapLockKey int64 = 0x63616a6974610002 // Alta del primer usuario (administrador)
+)
+
+// tryAdvisoryLock takes the session-level advisory lock key without waiting.
+// The lock is tied to a dedicated connection, which is kept out of the pool
+// until the returned unlock function is called, so it is held across
+// instances for as long as needed. ctx is only used to take the lock.
+func tryAdvisoryLock(ctx context.Context, key int64) (func(), error) {
+	return tryAdvisoryLockMode(ctx, key, false)
+}
+
+// tryAdvisoryLockShared is tryAdvisoryLock in shared mode: it only conflicts
+// with the exclusive lock.
+func tryAdvisoryLockShared(ctx context.Context, key int64) (func(), error) {
+	return tryAdvisoryLockMode(ctx, key, true)
+}
+
+func tryAdvisoryLockMode(ctx context.Context, key int64, shared bool) (func(), error) {
+	lockFunc, unlockFunc := "pg_try_advisory_lock", "pg_advisory_unlock"
+	if shared {
+		lockFunc, unlockFunc = "pg_try_advisory_lock_shared", "pg_advisory_unlock_shared"
+	}
+
+	sqlDB, err := DB.DB()
+	if err != nil {
+		return nil, err
+	}
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get connection for advisory lock: %w", err)
+	}
+
+	var acquired bool
+	if err := conn.QueryRowContext(ctx, "SELECT "+lockFunc+"($1)", key).Scan(&acquired); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to take advisory lock: %w", err)
+	}
+	if !acquired {
+		conn.Close()
+		return nil, ErrLockHeld
+	}
+
+	return func() { releaseAdvisoryLock(conn, unlockFunc, key) }, nil
+}
+
+func releaseAdvisoryLock(conn *sql.Conn, unlockFunc string, key int64) {
+	if _, err := conn.ExecContext(context.Background(), "SELECT "+unlockFunc+"($1)", key); err != nil {
+		log.Printf("Error releasing advisory lock %d: %v", key, err)
+		// Discard the connection instead of returning it to the pool still holding the lock
+		_ = conn.Raw(func(any) error { return driver.ErrBadConn })
+	}
+	if err := conn.Close(); err != nil {
+		log.Printf("Error closing advisory lock connection: %v", err)
+	}
+}
@@ -0,0 +1,95 @@
+package db
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/demispreviotto/cajitamusical/cajitamusical-backend/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// LoginThrottleDBer defines the interface for tracking failed logins.
+type LoginThrottleDBer interface {
+	GetLoginThrottles(ctx context.Context, keys []string) ([]models.LoginThrottle, error)
+	RecordLoginFailure(ctx context.Context, key string, at, windowStart time.Time) error
+	ReserveLoginAttempt(ctx context.Context, keys []string, at, windowStart time.Time, check func([]models.LoginThrottle) error) error
+	ReleaseLoginAttempt(ctx context.Context, key string) error
+	ResetLoginThrottle(ctx context.Context, key string) error
+	DeleteStaleLoginThrottles(ctx context.Context, before time.Time) error
+}
+
+// loginThrottleDB is the concrete implementation of LoginThrottleDBer.
+type loginThrottleDB struct{}
+
+// NewLoginThrottleDB creates a new instance of LoginThrottleDB.
+func NewLoginThrottleDB() LoginThrottleDBer {
+	return &loginThrottleDB{}
+}
+
+// GetLoginThrottles returns the counters of the given keys that exist.
+func (ldb *loginThrottleDB) GetLoginThrottles(ctx context.Context, keys []string) ([]models.LoginThrottle, error) {
+	var throttles []models.LoginThrottle
+	err := DB.WithContext(ctx).Where("key IN ?", keys).Find(&throttles).Error
+	return throttles, err
+}
+
+// RecordLoginFailure counts a failed login for key, forgetting the failures
+// older than windowStart.
+func (ldb *loginThrottleDB) RecordLoginFailure(ctx context.Context, key string, at, windowStart time.Time) error {
+	throttle := models.LoginThrottle{Key: key, Failures: 1, LastFailureAt: at}
+	return DB.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "key"}},
+		DoUpdates: clause.Assignments(map[string]any{
+			"failures":        gorm.Expr("CASE WHEN login_throttles.last_failure_at < ? THEN 1 ELSE login_throttles.failures + 1 END", windowStart),
+			"last_failure_at": at,
+		}),
+	}).Create(&throttle).Error
+}
+
+// ReserveLoginAttempt counts an attempt as failed for each key, unless check
+// returns an error for the locked counters; that error is returned as is.
+func (ldb *loginThrottleDB) ReserveLoginAttempt(ctx context.Context, keys []string, at, windowStart time.Time, check func([]models.LoginThrottle) error) error {
+	keys = slices.Sorted(slices.Values(keys)) // Same lock order for every attempt
+	return DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Rows to lock, for keys without failures yet
+		for _, key := range keys {
+			throttle := models.LoginThrottle{Key: key, LastFailureAt: at}
+			if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&throttle).Error; err != nil {
+				return err
+			}
+		}
+
+		var throttles []models.LoginThrottle
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("key IN ?", keys).Order("key").Find(&throttles).Error; err != nil {
+			return err
+		}
+		if err := check(throttles); err != nil {
+			return err
+		}
+
+		return tx.Model(&models.LoginThrottle{}).Where("key IN ?", keys).Updates(map[string]any{
+			"failures":        gorm.Expr("CASE WHEN last_failure_at < ? THEN 1 ELSE failures + 1 END", windowStart),
+			"last_failure_at": at,
+		}).Error
+	})
+}
+
+// ReleaseLoginAttempt takes back an attempt counted by ReserveLoginAttempt.
+func (ldb *loginThrottleDB) ReleaseLoginAttempt(ctx context.Context, key string) error {
+	return DB.WithContext(ctx).Model(&models.LoginThrottle{}).
+		Where("key = ? AND failures > 0", key).
+		Update("failures", gorm.Expr("failures - 1")).Error
+}
+
+// ResetLoginThrottle forgets the failures of key.
+func (ldb *loginThrottleDB) ResetLoginThrottle(ctx context.Context, key string) error {
+	return DB.WithContext(ctx).Delete(&models.LoginThrottle{}, "key = ?", key).Error
+}
+
+// DeleteStaleLoginThrottles removes counters whose last failure is older than before.
+func (ldb *loginThrottleDB) DeleteStaleLoginThrottles(ctx context.Context, before time.Time) error {
+	return DB.WithContext(ctx).Where("last_failure_at < ?", before).Delete(&models.LoginThrottle{}).Error
+}
@@ -0,0 +1,110 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/demispreviotto/cajitamusical/cajitamusical-backend/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// PasskeyDBer defines the interface for passkeys and WebAuthn challenges.
+type PasskeyDBer interface {
+	CreatePasskey(ctx context.Context, passkey *models.Passkey) error
+	GetPasskeyByCredentialID(ctx context.Context, credentialID []byte) (*models.Passkey, error)
+	ListPasskeys(ctx context.Context, userID uuid.UUID) ([]models.Passkey, error)
+	DeletePasskey(ctx context.Context, userID, passkeyID uuid.UUID) error
+	RecordPasskeyUse(ctx context.Context, passkeyID uuid.UUID, oldSignCount, newSignCount int64, usedAt time.Time) error
+
+	CreateWebAuthnChallenge(ctx context.Context, challenge *models.WebAuthnChallenge) error
+	ConsumeWebAuthnChallenge(ctx context.Context, challengeID uuid.UUID, ceremony string, now time.Time) (*models.WebAuthnChallenge, error)
+	DeleteExpiredWebAuthnChallenges(ctx context.Context, before time.Time) error
+}
+
+// passkeyDB is the concrete implementation of PasskeyDBer.
+type passkeyDB struct{}
+
+// NewPasskeyDB creates a new instance of PasskeyDB.
+func NewPasskeyDB() PasskeyDBer {
+	return &passkeyDB{}
+}
+
+// CreatePasskey stores a new passkey.
+func (pdb *passkeyDB) CreatePasskey(ctx context.Context, passkey *models.Passkey) error {
+	return DB.WithContext(ctx).Create(passkey).Error
+}
+
+// GetPasskeyByCredentialID returns the passkey with the given WebAuthn credential ID.
+func (pdb *passkeyDB) GetPasskeyByCredentialID(ctx context.Context, credentialID []byte) (*models.Passkey, error) {
+	var passkey models.Passkey
+	if err := DB.WithContext(ctx).Where("credential_id = ?", credentialID).First(&passkey).Error; err != nil {
+		return nil, err
+	}
+	return &passkey, nil
+}
+
+// ListPasskeys returns the passkeys of the user, newest first.
+func (pdb *passkeyDB) ListPasskeys(ctx context.Context, userID uuid.UUID) ([]models.Passkey, error) {
+	var passkeys []models.Passkey
+	err := DB.WithContext(ctx).Where("user_id = ?", userID).Order("created_at DESC").Find(&passkeys).Error
+	return passkeys, err
+}
+
+// DeletePasskey deletes a passkey of the user. It returns
+// gorm.ErrRecordNotFound if the user has no such passkey.
+func (pdb *passkeyDB) DeletePasskey(ctx context.Context, userID, passkeyID uuid.UUID) error {
+	res := DB.WithContext(ctx).Where("id = ? AND user_id = ?", passkeyID, userID).Delete(&models.Passkey{})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// RecordPasskeyUse stores the new signature counter of a passkey and when it
+// was used, if the counter is still oldSignCount. It returns
+// gorm.ErrRecordNotFound otherwise, so two logins signed with the same
+// counter cannot both succeed.
+func (pdb *passkeyDB) RecordPasskeyUse(ctx context.Context, passkeyID uuid.UUID, oldSignCount, newSignCount int64, usedAt time.Time) error {
+	res := DB.WithContext(ctx).Model(&models.Passkey{}).
+		Where("id = ? AND sign_count = ?", passkeyID, oldSignCount).
+		Updates(map[string]any{"sign_count": newSignCount, "last_used_at": usedAt})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// CreateWebAuthnChallenge stores the challenge of a ceremony in progress.
+func (pdb *passkeyDB) CreateWebAuthnChallenge(ctx context.Context, challenge *models.WebAuthnChallenge) error {
+	return DB.WithContext(ctx).Create(challenge).Error
+}
+
+// ConsumeWebAuthnChallenge deletes an unexpired challenge of the ceremony and
+// returns it, or returns gorm.ErrRecordNotFound.
+func (pdb *passkeyDB) ConsumeWebAuthnChallenge(ctx context.Context, challengeID uuid.UUID, ceremony string, now time.Time) (*models.WebAuthnChallenge, error) {
+	var challenges []models.WebAuthnChallenge
+	res := DB.WithContext(ctx).
+		Clauses(clause.Returning{}).
+		Where("id = ? AND ceremony = ? AND expires_at > ?", challengeID, ceremony, now).
+		Delete(&challenges)
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	if len(challenges) == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &challenges[0], nil
+}
+
+// DeleteExpiredWebAuthnChallenges removes the challenges that expired before before.
+func (pdb *passkeyDB) DeleteExpiredWebAuthnChallenges(ctx context.Context, before time.Time) error {
+	return DB.WithContext(ctx).Where("expires_at < ?", before).Delete(&models.WebAuthnChallenge{}).Error
+}
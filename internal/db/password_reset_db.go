@@ -0,0 +1,75 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/demispreviotto/cajitamusical/cajitamusical-backend/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// PasswordResetDBer defines the interface for password reset token operations.
+type PasswordResetDBer interface {
+	CreatePasswordResetToken(ctx context.Context, token *models.PasswordResetToken) error
+	GetLatestPasswordResetToken(ctx context.Context, userID uuid.UUID) (*models.PasswordResetToken, error)
+	ResetPassword(ctx context.Context, tokenHash string, now time.Time, hashedPassword string) (*models.PasswordResetToken, error)
+	DeleteExpiredPasswordResetTokens(ctx context.Context, before time.Time) error
+}
+
+// passwordResetDB is the concrete implementation of PasswordResetDBer.
+type passwordResetDB struct{}
+
+// NewPasswordResetDB creates a new instance of PasswordResetDB.
+func NewPasswordResetDB() PasswordResetDBer {
+	return &passwordResetDB{}
+}
+
+// CreatePasswordResetToken stores a new token, discarding the unused ones of
+// the same user so only the latest link works.
+func (pdb *passwordResetDB) CreatePasswordResetToken(ctx context.Context, token *models.PasswordResetToken) error {
+	return DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ? AND used_at IS NULL", token.UserID).Delete(&models.PasswordResetToken{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(token).Error
+	})
+}
+
+// GetLatestPasswordResetToken returns the most recent token of the user.
+func (pdb *passwordResetDB) GetLatestPasswordResetToken(ctx context.Context, userID uuid.UUID) (*models.PasswordResetToken, error) {
+	var token models.PasswordResetToken
+	if err := DB.WithContext(ctx).Where("user_id = ?", userID).Order("created_at DESC").First(&token).Error; err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// ResetPassword consumes an unused, unexpired token and updates the password
+// of its user as UpdatePassword does, or returns gorm.ErrRecordNotFound.
+func (pdb *passwordResetDB) ResetPassword(ctx context.Context, tokenHash string, now time.Time, hashedPassword string) (*models.PasswordResetToken, error) {
+	var tokens []models.PasswordResetToken
+	err := DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&tokens).
+			Clauses(clause.Returning{}).
+			Where("token_hash = ? AND used_at IS NULL AND expires_at > ?", tokenHash, now).
+			Update("used_at", now)
+		if res.Error != nil {
+			return res.Error
+		}
+		if len(tokens) == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return updatePassword(tx, tokens[0].UserID, hashedPassword, uuid.Nil)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &tokens[0], nil
+}
+
+// DeleteExpiredPasswordResetTokens removes the tokens that expired before before.
+func (pdb *passwordResetDB) DeleteExpiredPasswordResetTokens(ctx context.Context, before time.Time) error {
+	return DB.WithContext(ctx).Where("expires_at < ?", before).Delete(&models.PasswordResetToken{}).Error
+}
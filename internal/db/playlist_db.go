@@ -0,0 +1,185 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/demispreviotto/cajitamusical/cajitamusical-backend/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// PlaylistDBer defines the interface for playlist database operations.
+type PlaylistDBer interface {
+	CreatePlaylist(ctx context.Context, playlist *models.Playlist) error
+	GetPlaylistsByUser(ctx context.Context, userID uuid.UUID) ([]PlaylistSummary, error)
+	GetPlaylistByID(ctx context.Context, playlistID uuid.UUID) (*models.Playlist, error)
+	UpdatePlaylist(ctx context.Context, playlist *models.Playlist) error
+	DeletePlaylist(ctx context.Context, playlistID uuid.UUID) error
+	AddSong(ctx context.Context, playlistID, songID uuid.UUID) (*models.PlaylistItem, error)
+	RemoveItem(ctx context.Context, playlistID, itemID uuid.UUID) error
+	ReorderItems(ctx context.Context, playlistID uuid.UUID, itemIDs []uuid.UUID) error
+}
+
+// PlaylistSummary is a playlist (without items) plus aggregates over its tracks.
+type PlaylistSummary struct {
+	models.Playlist
+	TrackCount           int
+	TotalDurationSeconds int
+}
+
+// playlistDB is the concrete implementation of PlaylistDBer.
+type playlistDB struct{}
+
+// NewPlaylistDB creates a new instance of PlaylistDB.
+func NewPlaylistDB() PlaylistDBer {
+	return &playlistDB{}
+}
+
+// CreatePlaylist adds a new, empty playlist to the database.
+func (pdb *playlistDB) CreatePlaylist(ctx context.Context, playlist *models.Playlist) error {
+	return DB.WithContext(ctx).Create(playlist).Error
+}
+
+// GetPlaylistsByUser retrieves the playlists owned by a user with their track
+// count and total duration, without loading the tracks themselves.
+func (pdb *playlistDB) GetPlaylistsByUser(ctx context.Context, userID uuid.UUID) ([]PlaylistSummary, error) {
+	var summaries []PlaylistSummary
+	err := DB.WithContext(ctx).Model(&models.Playlist{}).
+		Select("playlists.*, COUNT(playlist_items.id) AS track_count, COALESCE(SUM(songs.duration_seconds), 0) AS total_duration_seconds").
+		Joins("LEFT JOIN playlist_items ON playlist_items.playlist_id = playlists.id").
+		Joins("LEFT JOIN songs ON songs.id = playlist_items.song_id").
+		Where("playlists.user_id = ?", userID).
+		Group("playlists.id").
+		Order("playlists.name ASC").
+		Scan(&summaries).Error
+	return summaries, err
+}
+
+// GetPlaylistByID retrieves a playlist with its items (ordered by position) and their songs.
+func (pdb *playlistDB) GetPlaylistByID(ctx context.Context, playlistID uuid.UUID) (*models.Playlist, error) {
+	var playlist models.Playlist
+	err := DB.WithContext(ctx).
+		Preload("Items", func(tx *gorm.DB) *gorm.DB {
+			return tx.Order("position ASC")
+		}).
+		Preload("Items.Song").
+		First(&playlist, "id = ?", playlistID).Error
+	if err != nil {
+		return nil, err
+	}
+	return &playlist, nil
+}
+
+// UpdatePlaylist saves the name and description of an existing playlist.
+func (pdb *playlistDB) UpdatePlaylist(ctx context.Context, playlist *models.Playlist) error {
+	return DB.WithContext(ctx).Model(playlist).
+		Select("name", "description", "updated_at").
+		Updates(playlist).Error
+}
+
+// DeletePlaylist removes a playlist and all of its items.
+func (pdb *playlistDB) DeletePlaylist(ctx context.Context, playlistID uuid.UUID) error {
+	return DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("playlist_id = ?", playlistID).Delete(&models.PlaylistItem{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.Playlist{}, "id = ?", playlistID).Error
+	})
+}
+
+// AddSong appends a song at the end of a playlist.
+func (pdb *playlistDB) AddSong(ctx context.Context, playlistID, songID uuid.UUID) (*models.PlaylistItem, error) {
+	item := &models.PlaylistItem{PlaylistID: playlistID, SongID: songID}
+
+	err := DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Lock the playlist row so concurrent appends don't get the same position
+		if err := tx.Exec("SELECT id FROM playlists WHERE id = ? FOR UPDATE", playlistID).Error; err != nil {
+			return err
+		}
+
+		// Append after the highest position: cascade deletes of songs can
+		// leave gaps, so the item count is not a free position
+		if err := tx.Model(&models.PlaylistItem{}).Where("playlist_id = ?", playlistID).
+			Select("COALESCE(MAX(position) + 1, 0)").Scan(&item.Position).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Create(item).Error; err != nil {
+			return err
+		}
+		return touchPlaylist(tx, playlistID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+// RemoveItem deletes an item from a playlist and closes the gap it leaves.
+func (pdb *playlistDB) RemoveItem(ctx context.Context, playlistID, itemID uuid.UUID) error {
+	return DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var item models.PlaylistItem
+		if err := tx.Where("id = ? AND playlist_id = ?", itemID, playlistID).First(&item).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&item).Error; err != nil {
+			return err
+		}
+		// Positions are unique and checked row by row, so the following items
+		// are moved to negative positions first and then back one place down
+		if err := tx.Model(&models.PlaylistItem{}).
+			Where("playlist_id = ? AND position > ?", playlistID, item.Position).
+			UpdateColumn("position", gorm.Expr("-position")).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.PlaylistItem{}).
+			Where("playlist_id = ? AND position < 0", playlistID).
+			UpdateColumn("position", gorm.Expr("-position - 1")).Error; err != nil {
+			return err
+		}
+		return touchPlaylist(tx, playlistID)
+	})
+}
+
+// ReorderItems rewrites item positions following the order of itemIDs.
+// itemIDs must contain every item of the playlist exactly once.
+func (pdb *playlistDB) ReorderItems(ctx context.Context, playlistID uuid.UUID, itemIDs []uuid.UUID) error {
+	return DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var existing []uuid.UUID
+		if err := tx.Model(&models.PlaylistItem{}).Where("playlist_id = ?", playlistID).Pluck("id", &existing).Error; err != nil {
+			return err
+		}
+		if len(existing) != len(itemIDs) {
+			return fmt.Errorf("expected %d item IDs, got %d", len(existing), len(itemIDs))
+		}
+		known := make(map[uuid.UUID]bool, len(existing))
+		for _, id := range existing {
+			known[id] = true
+		}
+		for _, id := range itemIDs {
+			if !known[id] {
+				return fmt.Errorf("item %s does not belong to playlist or is repeated", id.String())
+			}
+			delete(known, id)
+		}
+
+		// Free the positions first so the unique index doesn't reject the
+		// intermediate states
+		if err := tx.Model(&models.PlaylistItem{}).Where("playlist_id = ?", playlistID).
+			UpdateColumn("position", gorm.Expr("-1 - position")).Error; err != nil {
+			return err
+		}
+		for position, id := range itemIDs {
+			if err := tx.Model(&models.PlaylistItem{}).Where("id = ?", id).UpdateColumn("position", position).Error; err != nil {
+				return err
+			}
+		}
+		return touchPlaylist(tx, playlistID)
+	})
+}
+
+// touchPlaylist bumps updated_at on a playlist after its items change.
+func touchPlaylist(tx *gorm.DB, playlistID uuid.UUID) error {
+	return tx.Model(&models.Playlist{}).Where("id = ?", playlistID).Update("updated_at", gorm.Expr("NOW()")).Error
+}
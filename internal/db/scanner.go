@@ -0,0 +1,522 @@
+package db
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/demispreviotto/cajitamusical/cajitamusical-backend/internal/audio"
+	"github.com/demispreviotto/cajitamusical/cajitamusical-backend/internal/dto/song"
+	"github.com/demispreviotto/cajitamusical/cajitamusical-backend/internal/models"
+	"github.com/dhowden/tag"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// scanBatchSize is how many new or changed songs the writer stores per transaction.
+const scanBatchSize = 200
+
+// supportedAudioExtensions are the file extensions the scanner reads.
+var supportedAudioExtensions = map[string]bool{".mp3": true, ".flac": true, ".m4a": true}
+
+// libraryScanner synchronizes the songs table with the music directory. A
+// walker goroutine lists the audio files, a pool of workers reads their tags
+// and durations, and a single writer links them to the catalog and stores them
+// in batches. Only the writer touches the database, and each worker keeps at
+// most one file open.
+type libraryScanner struct {
+	musicDir    string
+	workers     int
+	hashContent bool
+	progress    func(song.ScanProgress) // Optional; called from the goroutine running the scan
+
+	existing       map[string]models.Song // Songs in the DB by FilePath; read-only once the scan starts
+	artWrittenDirs sync.Map               // Directories whose thumb.jpg was already written in this scan
+	filesSeen      atomic.Int64           // Audio files sent by the walker
+}
+
+// scanFile is an audio file found by the walker.
+type scanFile struct {
+	path    string // Path on disk
+	relPath string // Path relative to the music directory, with forward slashes
+	ext     string
+	size    int64
+	modTime time.Time
+}
+
+// scanOutcome is what a worker reports for a file. A file with neither song
+// nor unchanged set could not be read.
+type scanOutcome struct {
+	relPath   string
+	song      *models.Song
+	unchanged bool
+	errors    []string
+}
+
+// newLibraryScanner creates a scanner for musicDir. The number of workers is
+// read from SCAN_WORKERS and defaults to the number of CPUs.
+func newLibraryScanner(musicDir string) *libraryScanner {
+	workers := runtime.NumCPU()
+	if n, err := strconv.Atoi(os.Getenv("SCAN_WORKERS")); err == nil && n > 0 {
+		workers = n
+	}
+	return &libraryScanner{
+		musicDir:    musicDir,
+		workers:     workers,
+		hashContent: os.Getenv("SCAN_CONTENT_HASH") == "true",
+	}
+}
+
+// run performs a full scan. Songs whose files are gone are removed, unless the
+// scan is cancelled, in which case the batches already stored are kept and the
+// partial result is returned along with the context error.
+func (s *libraryScanner) run(ctx context.Context) (*song.MusicScanResult, error) {
+	if _, err := os.Stat(s.musicDir); err != nil {
+		return nil, fmt.Errorf("music directory is not accessible: %w", err)
+	}
+
+	var existingSongs []models.Song
+	if err := DB.WithContext(ctx).Find(&existingSongs).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch existing songs from DB: %w", err)
+	}
+	s.existing = make(map[string]models.Song, len(existingSongs))
+	for _, existingSong := range existingSongs {
+		s.existing[existingSong.FilePath] = existingSong
+	}
+
+	files := make(chan scanFile, s.workers*4)
+	outcomes := make(chan scanOutcome, s.workers*4)
+
+	go s.walk(ctx, files, outcomes)
+
+	var wg sync.WaitGroup
+	for i := 0; i < s.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for file := range files {
+				outcomes <- s.process(file)
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(outcomes)
+	}()
+
+	result := &song.MusicScanResult{}
+	writer := newScanWriter(ctx, s.existing, result)
+	seen := make(map[string]bool, len(s.existing))
+	processed := 0
+	s.report(song.ScanPhaseScanning, processed, result)
+	for outcome := range outcomes {
+		result.Errors = append(result.Errors, outcome.errors...)
+		switch {
+		case outcome.unchanged:
+			seen[outcome.relPath] = true
+			result.Unchanged++
+		case outcome.song != nil:
+			seen[outcome.relPath] = true
+			writer.add(outcome.song)
+		}
+		if outcome.relPath != "" {
+			processed++
+		}
+		s.report(song.ScanPhaseScanning, processed, result)
+	}
+	writer.flush()
+
+	if err := ctx.Err(); err != nil {
+		return result, fmt.Errorf("music scan cancelled: %w", err)
+	}
+
+	s.report(song.ScanPhaseCleanup, processed, result)
+
+	var removedIDs []uuid.UUID
+	for filePath, existingSong := range s.existing {
+		if !seen[filePath] {
+			removedIDs = append(removedIDs, existingSong.ID)
+		}
+	}
+	writer.remove(removedIDs)
+
+	if err := pruneCatalog(DB.WithContext(ctx)); err != nil {
+		result.Errors = append(result.Errors, scanError("Error removing empty albums/artists: %v", err))
+	}
+	s.report(song.ScanPhaseDone, processed, result)
+	return result, nil
+}
+
+// report sends the current progress to the progress callback, if any.
+func (s *libraryScanner) report(phase string, processed int, result *song.MusicScanResult) {
+	if s.progress == nil {
+		return
+	}
+	s.progress(song.ScanProgress{
+		Phase:          phase,
+		FilesSeen:      int(s.filesSeen.Load()),
+		FilesProcessed: processed,
+		Errors:         len(result.Errors),
+	})
+}
+
+// walk sends every supported audio file under the music directory to files,
+// and closes it when done or when ctx is cancelled. Access errors are reported
+// through outcomes.
+func (s *libraryScanner) walk(ctx context.Context, files chan<- scanFile, outcomes chan<- scanOutcome) {
+	defer close(files)
+
+	err := filepath.WalkDir(s.musicDir, func(path string, d fs.DirEntry, err error) error {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		if err != nil {
+			outcomes <- scanOutcome{errors: []string{scanError("Error accessing path %s: %v", path, err)}}
+			return nil
+		}
+		if d.IsDir() {
+			return nil
+		}
+
+		if !IsSongFile(path) {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			outcomes <- scanOutcome{errors: []string{scanError("Error accessing path %s: %v", path, err)}}
+			return nil
+		}
+
+		file := newScanFile(s.musicDir, path, info)
+		s.filesSeen.Add(1)
+		select {
+		case files <- file:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
+	if err != nil && ctx.Err() == nil {
+		outcomes <- scanOutcome{errors: []string{scanError("Error during file system walk: %v", err)}}
+	}
+}
+
+// IsSongFile reports whether path has a supported audio extension. macOS
+// resource fork files ("._" prefix) are excluded.
+func IsSongFile(path string) bool {
+	name := filepath.Base(path)
+	if strings.HasPrefix(name, "._") {
+		return false
+	}
+	return supportedAudioExtensions[strings.ToLower(filepath.Ext(name))]
+}
+
+// SongFilePath returns the FilePath stored for the file at path: relative to
+// musicDir, with forward slashes.
+func SongFilePath(musicDir, path string) string {
+	normalizedMusicDir := musicDir
+	if !strings.HasSuffix(normalizedMusicDir, string(os.PathSeparator)) {
+		normalizedMusicDir += string(os.PathSeparator)
+	}
+	return filepath.ToSlash(strings.TrimPrefix(path, normalizedMusicDir))
+}
+
+func newScanFile(musicDir, path string, info fs.FileInfo) scanFile {
+	return scanFile{
+		path:    path,
+		relPath: SongFilePath(musicDir, path),
+		ext:     strings.ToLower(filepath.Ext(path)),
+		size:    info.Size(),
+		modTime: info.ModTime().UTC().Truncate(time.Microsecond), // Precisión de PostgreSQL
+	}
+}
+
+// SameSongFile reports whether the file described by info still matches the
+// size and modification time stored on existing.
+func SameSongFile(existing *models.Song, info fs.FileInfo) bool {
+	return existing.FileSize == info.Size() && existing.FileModTime.Equal(info.ModTime().UTC().Truncate(time.Microsecond))
+}
+
+// LoadSongFile reads the audio file at path, inside musicDir, the way a library
+// scan does: tags, duration, fingerprint and album art. It is meant for
+// single-file updates; the returned song is not linked to the catalog yet.
+func LoadSongFile(musicDir, path string) (*models.Song, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	scanner := newLibraryScanner(musicDir)
+	outcome := scanner.process(newScanFile(musicDir, path, info))
+	if outcome.song == nil {
+		return nil, fmt.Errorf("failed to read song file %s: %s", path, strings.Join(outcome.errors, "; "))
+	}
+	return outcome.song, nil
+}
+
+// process reads one file. Files whose size, modification time (and partial
+// hash, when enabled) match the stored song are reported as unchanged without
+// parsing their tags or extracting their album art.
+func (s *libraryScanner) process(file scanFile) scanOutcome {
+	outcome := scanOutcome{relPath: file.relPath}
+
+	var contentHash string
+	if s.hashContent {
+		var err error
+		if contentHash, err = partialContentHash(file.path, file.size); err != nil {
+			outcome.errors = append(outcome.errors, scanError("Error hashing file %s: %v", file.path, err))
+			return outcome
+		}
+	}
+
+	if existingSong, found := s.existing[file.relPath]; found &&
+		existingSong.FileSize == file.size && existingSong.FileModTime.Equal(file.modTime) && existingSong.ContentHash == contentHash {
+		outcome.unchanged = true
+		return outcome
+	}
+
+	newSong, pic, errs := readSongFile(file)
+	outcome.errors = append(outcome.errors, errs...)
+	if newSong == nil {
+		return outcome
+	}
+	newSong.ContentHash = contentHash
+
+	// All tracks of a directory share its thumb.jpg, so it is written once per scan
+	artTargetDir := filepath.Dir(file.path)
+	if pic != nil {
+		if _, written := s.artWrittenDirs.LoadOrStore(artTargetDir, true); !written {
+			if err := writeAlbumArt(pic.Data, artTargetDir); err != nil {
+				s.artWrittenDirs.Delete(artTargetDir)
+				outcome.errors = append(outcome.errors, scanError("Error saving album art for %s (Song: %s): %v", newSong.FilePath, newSong.Title, err))
+			}
+		}
+	}
+
+	outcome.song = newSong
+	return outcome
+}
+
+// readSongFile builds a song from the tags and duration of file. It returns a
+// nil song when the file cannot be read; the returned errors may also report
+// non-fatal problems, such as an unknown duration.
+func readSongFile(file scanFile) (*models.Song, *tag.Picture, []string) {
+	f, err := os.Open(file.path)
+	if err != nil {
+		return nil, nil, []string{scanError("Error opening file %s: %v", file.path, err)}
+	}
+	defer f.Close()
+
+	t, err := tag.ReadFrom(f)
+	if err != nil {
+		return nil, nil, []string{scanError("Error reading tags from %s: %v", file.path, err)}
+	}
+
+	var errs []string
+	duration, err := audio.Duration(f, file.ext)
+	if err != nil {
+		errs = append(errs, scanError("Error reading duration of %s: %v", file.path, err))
+	}
+
+	trackNum, _ := t.Track()
+	discNum, _ := t.Disc()
+	newSong := &models.Song{
+		Title:           t.Title(),
+		Artist:          t.Artist(),
+		Album:           t.Album(),
+		Genre:           t.Genre(),
+		Year:            t.Year(),
+		TrackNumber:     trackNum,
+		AlbumArtist:     t.AlbumArtist(),
+		DiscNumber:      discNum,
+		DurationSeconds: audio.Seconds(duration),
+		FilePath:        file.relPath,
+		Filename:        filepath.Base(file.path),
+		FileSize:        file.size,
+		FileModTime:     file.modTime,
+	}
+	return newSong, t.Picture(), errs
+}
+
+// scanWriter links scanned songs to the catalog and stores them in batches.
+// It is only used from the goroutine collecting the scan outcomes.
+type scanWriter struct {
+	ctx      context.Context
+	catalog  *catalogResolver
+	existing map[string]models.Song
+	result   *song.MusicScanResult
+	pending  []*models.Song
+}
+
+func newScanWriter(ctx context.Context, existing map[string]models.Song, result *song.MusicScanResult) *scanWriter {
+	return &scanWriter{
+		ctx:      ctx,
+		catalog:  newCatalogResolver(),
+		existing: existing,
+		result:   result,
+		pending:  make([]*models.Song, 0, scanBatchSize),
+	}
+}
+
+// add queues a new or changed song, flushing the batch when it is full.
+func (w *scanWriter) add(newSong *models.Song) {
+	if err := w.catalog.link(DB.WithContext(w.ctx), newSong); err != nil {
+		w.result.Errors = append(w.result.Errors, scanError("Error linking artist/album for %s: %v", newSong.FilePath, err))
+	}
+
+	if existingSong, found := w.existing[newSong.FilePath]; found {
+		newSong.ID = existingSong.ID
+		newSong.CreatedAt = existingSong.CreatedAt
+		if existingSong.Equals(newSong) {
+			w.result.Unchanged++
+			return
+		}
+	}
+
+	w.pending = append(w.pending, newSong)
+	if len(w.pending) >= scanBatchSize {
+		w.flush()
+	}
+}
+
+// flush stores the queued songs in one transaction. If it fails, the songs
+// are stored one by one so that a single bad row does not lose the batch.
+// Once the scan is cancelled, queued songs are dropped.
+func (w *scanWriter) flush() {
+	defer func() { w.pending = w.pending[:0] }()
+	if len(w.pending) == 0 || w.ctx.Err() != nil {
+		return
+	}
+
+	var creates, updates []*models.Song
+	for _, pendingSong := range w.pending {
+		if pendingSong.ID == uuid.Nil {
+			creates = append(creates, pendingSong)
+		} else {
+			updates = append(updates, pendingSong)
+		}
+	}
+
+	err := DB.WithContext(w.ctx).Transaction(func(tx *gorm.DB) error {
+		if len(creates) > 0 {
+			if err := tx.Create(&creates).Error; err != nil {
+				return err
+			}
+		}
+		for _, updatedSong := range updates {
+			if err := tx.Save(updatedSong).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err == nil {
+		w.result.Added += len(creates)
+		w.result.Updated += len(updates)
+		return
+	}
+
+	log.Printf("Error storing batch of %d songs, retrying one by one: %v", len(w.pending), err)
+	for _, newSong := range creates {
+		newSong.ID = uuid.Nil // May have been assigned by the failed batch insert
+		if err := DB.WithContext(w.ctx).Create(newSong).Error; err != nil {
+			w.result.Errors = append(w.result.Errors, scanError("Error adding new song %s to DB: %v", newSong.Title, err))
+		} else {
+			w.result.Added++
+		}
+	}
+	for _, updatedSong := range updates {
+		if err := DB.WithContext(w.ctx).Save(updatedSong).Error; err != nil {
+			w.result.Errors = append(w.result.Errors, scanError("Error updating song %s (ID: %s) in DB: %v", updatedSong.Title, updatedSong.ID.String(), err))
+		} else {
+			w.result.Updated++
+		}
+	}
+}
+
+// remove deletes the songs whose files no longer exist.
+func (w *scanWriter) remove(songIDs []uuid.UUID) {
+	for start := 0; start < len(songIDs); start += maxInClauseIDs {
+		end := min(start+maxInClauseIDs, len(songIDs))
+		res := DB.WithContext(w.ctx).Delete(&models.Song{}, songIDs[start:end])
+		if res.Error != nil {
+			w.result.Errors = append(w.result.Errors, scanError("Error deleting %d removed songs from DB: %v", end-start, res.Error))
+			continue
+		}
+		w.result.Removed += int(res.RowsAffected)
+	}
+}
+
+// scanError logs a scan error and returns it for MusicScanResult.Errors.
+func scanError(format string, args ...any) string {
+	msg := fmt.Sprintf(format, args...)
+	log.Print(msg)
+	return msg
+}
+
+// writeAlbumArt decodes the embedded picture data and saves it as thumb.jpg in dir.
+func writeAlbumArt(data []byte, dir string) error {
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to decode album art: %w", err)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create album art directory %s: %w", dir, err)
+	}
+
+	artFilePath := filepath.Join(dir, "thumb.jpg")
+	outFile, err := os.Create(artFilePath)
+	if err != nil {
+		return fmt.Errorf("failed to create album art file %s: %w", artFilePath, err)
+	}
+	if err := jpeg.Encode(outFile, img, &jpeg.Options{Quality: 90}); err != nil {
+		outFile.Close()
+		return fmt.Errorf("failed to encode album art to JPEG for %s: %w", artFilePath, err)
+	}
+	return outFile.Close()
+}
+
+// partialHashChunk is how much of the start and the end of a file partialContentHash reads.
+const partialHashChunk = 64 * 1024
+
+// partialContentHash returns a hex SHA-256 of the size plus the first and last
+// partialHashChunk bytes of a file. It catches rewritten audio or tags (which
+// live at either end) without reading whole files.
+func partialContentHash(path string, size int64) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	fmt.Fprintf(h, "%d:", size)
+	if _, err := io.CopyN(h, f, partialHashChunk); err != nil && err != io.EOF {
+		return "", err
+	}
+	if size > 2*partialHashChunk {
+		if _, err := f.Seek(-partialHashChunk, io.SeekEnd); err != nil {
+			return "", err
+		}
+	}
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
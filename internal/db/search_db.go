@@ -0,0 +1,133 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"unicode"
+
+	"github.com/demispreviotto/cajitamusical/cajitamusical-backend/internal/models"
+)
+
+// searchSetupStatements prepare the songs table for accent-insensitive full-text
+// search. They are idempotent and run at startup after the GORM auto-migrations.
+var searchSetupStatements = []string{
+	`CREATE EXTENSION IF NOT EXISTS unaccent`,
+	// unaccent() is only STABLE; an IMMUTABLE wrapper is required to use it in a generated column/index.
+	`CREATE OR REPLACE FUNCTION immutable_unaccent(text) RETURNS text
+		AS $$ SELECT public.unaccent('public.unaccent', $1) $$
+		LANGUAGE sql IMMUTABLE PARALLEL SAFE STRICT`,
+	`ALTER TABLE songs ADD COLUMN IF NOT EXISTS search_vector tsvector GENERATED ALWAYS AS (
+		setweight(to_tsvector('simple', immutable_unaccent(coalesce(title, ''))), 'A') ||
+		setweight(to_tsvector('simple', immutable_unaccent(coalesce(artist, ''))), 'B') ||
+		setweight(to_tsvector('simple', immutable_unaccent(coalesce(album, ''))), 'B') ||
+		setweight(to_tsvector('simple', immutable_unaccent(coalesce(genre, ''))), 'C')
+	) STORED`,
+	`CREATE INDEX IF NOT EXISTS idx_songs_search_vector ON songs USING GIN (search_vector)`,
+}
+
+// SetupSearch creates the extension, function, column and index used by SearchDBer.
+func SetupSearch(ctx context.Context) error {
+	for _, stmt := range searchSetupStatements {
+		if err := DB.WithContext(ctx).Exec(stmt).Error; err != nil {
+			return fmt.Errorf("failed to set up full-text search: %w", err)
+		}
+	}
+	log.Println("Full-text search setup completed.")
+	return nil
+}
+
+// SearchDBer defines the interface for full-text search over the library.
+// query is a tsquery built with BuildSearchQuery.
+type SearchDBer interface {
+	SearchSongs(ctx context.Context, query string, limit int) ([]models.Song, error)
+	SearchAlbums(ctx context.Context, query string, limit int) ([]AlbumSearchResult, error)
+	SearchArtists(ctx context.Context, query string, limit int) ([]ArtistSearchResult, error)
+}
+
+// AlbumSearchResult is an album (grouped from songs) matching a search.
+type AlbumSearchResult struct {
+	Album      string
+	Artist     string
+	Year       int
+	TrackCount int
+	SamplePath string // FilePath of one of its songs, used to derive the album art
+	Rank       float64
+}
+
+// ArtistSearchResult is an artist (grouped from songs) matching a search.
+type ArtistSearchResult struct {
+	Artist     string
+	TrackCount int
+	AlbumCount int
+	Rank       float64
+}
+
+// searchDB is the concrete implementation of SearchDBer.
+type searchDB struct{}
+
+// NewSearchDB creates a new instance of SearchDB.
+func NewSearchDB() SearchDBer {
+	return &searchDB{}
+}
+
+// tsQueryExpr turns the query parameter into an accent-insensitive tsquery.
+const tsQueryExpr = "to_tsquery('simple', immutable_unaccent(?))"
+
+// SearchSongs retrieves the songs whose title, artist, album or genre match, best ranked first.
+func (sdb *searchDB) SearchSongs(ctx context.Context, query string, limit int) ([]models.Song, error) {
+	var songs []models.Song
+	err := DB.WithContext(ctx).
+		Select("songs.*, ts_rank(songs.search_vector, "+tsQueryExpr+") AS rank", query).
+		Where("songs.search_vector @@ "+tsQueryExpr, query).
+		Order("rank DESC, songs.title ASC").
+		Limit(limit).
+		Find(&songs).Error
+	return songs, err
+}
+
+// SearchAlbums retrieves the albums whose title (plus artist) match, best ranked first.
+func (sdb *searchDB) SearchAlbums(ctx context.Context, query string, limit int) ([]AlbumSearchResult, error) {
+	var albums []AlbumSearchResult
+	err := DB.WithContext(ctx).Model(&models.Song{}).
+		Select(`album, artist, COALESCE(MAX(year), 0) AS year, COUNT(*) AS track_count, MIN(file_path) AS sample_path,
+			MAX(ts_rank(to_tsvector('simple', immutable_unaccent(album || ' ' || artist)), `+tsQueryExpr+`)) AS rank`, query).
+		Where("search_vector @@ "+tsQueryExpr, query).
+		Where("album <> ''").
+		Where("to_tsvector('simple', immutable_unaccent(album || ' ' || artist)) @@ "+tsQueryExpr, query).
+		Group("album, artist").
+		Order("rank DESC, album ASC").
+		Limit(limit).
+		Scan(&albums).Error
+	return albums, err
+}
+
+// SearchArtists retrieves the artists whose name match, best ranked first.
+func (sdb *searchDB) SearchArtists(ctx context.Context, query string, limit int) ([]ArtistSearchResult, error) {
+	var artists []ArtistSearchResult
+	err := DB.WithContext(ctx).Model(&models.Song{}).
+		Select(`artist, COUNT(*) AS track_count, COUNT(DISTINCT NULLIF(album, '')) AS album_count,
+			MAX(ts_rank(to_tsvector('simple', immutable_unaccent(artist)), `+tsQueryExpr+`)) AS rank`, query).
+		Where("search_vector @@ "+tsQueryExpr, query).
+		Where("to_tsvector('simple', immutable_unaccent(artist)) @@ "+tsQueryExpr, query).
+		Group("artist").
+		Order("rank DESC, artist ASC").
+		Limit(limit).
+		Scan(&artists).Error
+	return artists, err
+}
+
+// BuildSearchQuery converts free text typed by a user into a tsquery string
+// where every word must match as a prefix ("canci rock" -> "canci:* & rock:*").
+// Only letters and digits are kept, so the result is always valid tsquery
+// syntax. It returns "" when the text has no searchable words.
+func BuildSearchQuery(text string) string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for i, word := range words {
+		words[i] = word + ":*"
+	}
+	return strings.Join(words, " & ")
+}
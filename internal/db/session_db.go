@@ -6,6 +6,7 @@ import (
 
 	"github.com/demispreviotto/cajitamusical/cajitamusical-backend/internal/models"
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
 // SessionDBer define la interfaz para las operaciones de la base de datos de sesiones.
@@ -14,6 +15,11 @@ type SessionDBer interface {
 	GetSessionByID(ctx context.Context, sessionID uuid.UUID) (*models.Session, error)
 	DeleteSession(ctx context.Context, sessionID uuid.UUID) error
 	DeleteExpiredSessions(ctx context.Context) error
+	GetSessionsByUser(ctx context.Context, userID uuid.UUID) ([]models.Session, error)
+	DeleteSessionsByUser(ctx context.Context, userID uuid.UUID) error
+	DeleteOtherSessions(ctx context.Context, userID, keepSessionID uuid.UUID) error
+	DeleteUserSession(ctx context.Context, userID, sessionID uuid.UUID) error
+	TouchSession(ctx context.Context, sessionID uuid.UUID, lastSeenAt, expiresAt time.Time) error
 	// Agrega otros métodos de DB de sesión aquí
 }
 
@@ -46,3 +52,47 @@ func (sdb *sessionDB) DeleteSession(ctx context.Context, sessionID uuid.UUID) er
 func (sdb *sessionDB) DeleteExpiredSessions(ctx context.Context) error {
 	return DB.WithContext(ctx).Where("expires_at <= ?", time.Now()).Delete(&models.Session{}).Error
 }
+
+// GetSessionsByUser devuelve las sesiones vigentes de un usuario, las más recientes primero.
+func (sdb *sessionDB) GetSessionsByUser(ctx context.Context, userID uuid.UUID) ([]models.Session, error) {
+	var sessions []models.Session
+	err := DB.WithContext(ctx).
+		Where("user_id = ? AND expires_at > ?", userID, time.Now()).
+		Order("created_at DESC").
+		Find(&sessions).Error
+	return sessions, err
+}
+
+// DeleteSessionsByUser elimina todas las sesiones de un usuario.
+func (sdb *sessionDB) DeleteSessionsByUser(ctx context.Context, userID uuid.UUID) error {
+	return DB.WithContext(ctx).Where("user_id = ?", userID).Delete(&models.Session{}).Error
+}
+
+// DeleteOtherSessions elimina las sesiones de un usuario salvo keepSessionID.
+func (sdb *sessionDB) DeleteOtherSessions(ctx context.Context, userID, keepSessionID uuid.UUID) error {
+	return DB.WithContext(ctx).
+		Where("user_id = ? AND session_id <> ?", userID, keepSessionID).
+		Delete(&models.Session{}).Error
+}
+
+// DeleteUserSession elimina una sesión solo si pertenece al usuario. Devuelve
+// gorm.ErrRecordNotFound si no existe o es de otro usuario.
+func (sdb *sessionDB) DeleteUserSession(ctx context.Context, userID, sessionID uuid.UUID) error {
+	res := DB.WithContext(ctx).
+		Where("session_id = ? AND user_id = ?", sessionID, userID).
+		Delete(&models.Session{})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// TouchSession guarda la última actividad de una sesión y su nueva expiración.
+func (sdb *sessionDB) TouchSession(ctx context.Context, sessionID uuid.UUID, lastSeenAt, expiresAt time.Time) error {
+	return DB.WithContext(ctx).Model(&models.Session{}).
+		Where("session_id = ?", sessionID).
+		Updates(map[string]any{"last_seen_at": lastSeenAt, "expires_at": expiresAt}).Error
+}
@@ -1,30 +1,30 @@
 package db
 
 import (
-	"bytes"
 	"context"
+	"errors"
 	"fmt"
-	"image"
-	"image/jpeg"
-	"log"
 	"os"
-	"path/filepath"
-	"strings"
 
 	"github.com/demispreviotto/cajitamusical/cajitamusical-backend/internal/dto/song"
 	"github.com/demispreviotto/cajitamusical/cajitamusical-backend/internal/models"
-	"github.com/dhowden/tag"
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
+// ErrScanInProgress is returned by TryLockScan when a library scan is already running.
+var ErrScanInProgress = errors.New("a library scan is already running")
+
 // SongDBer defines the interface for song database operations.
 type SongDBer interface {
-	GetSongLibrary(ctx context.Context) ([]models.Song, error)
+	GetSongLibrary(ctx context.Context, query song.ListSongsQuery) ([]models.Song, int64, error)
 	CreateSong(ctx context.Context, song *models.Song) error
 	UpdateSong(ctx context.Context, song *models.Song) error
 	GetSongByFilePath(ctx context.Context, filePath string) (*models.Song, error)
 	DeleteSong(ctx context.Context, songID uuid.UUID) error
-	ScanAndStoreSongs(ctx context.Context) (*song.MusicScanResult, error)
+	ScanAndStoreSongs(ctx context.Context, progress func(song.ScanProgress)) (*song.MusicScanResult, error)
+	TryLockScan(ctx context.Context) (unlock func(), err error)
+	TryShareScanLock(ctx context.Context) (unlock func(), err error)
 	GetSongByID(ctx context.Context, songID uuid.UUID) (*models.Song, error)
 }
 
@@ -36,21 +36,86 @@ func NewSongDB() SongDBer {
 	return &songDB{}
 }
 
-// GetSongLibrary retrieves all songs from the database.
-func (sdb *songDB) GetSongLibrary(ctx context.Context) ([]models.Song, error) {
+// songSortColumns maps the sort fields accepted by the API to columns. The
+// extra columns keep the order stable (and albums in track order).
+var songSortColumns = map[string][]string{
+	"title":            {"title", "artist"},
+	"artist":           {"artist", "album", "track_number"},
+	"album":            {"album", "track_number"},
+	"genre":            {"genre", "artist", "album", "track_number"},
+	"year":             {"year", "album", "track_number"},
+	"track_number":     {"track_number"},
+	"duration_seconds": {"duration_seconds"},
+	"created_at":       {"created_at"},
+}
+
+// GetSongLibrary retrieves a page of songs matching the filters of query, in the
+// requested order, along with the total number of matching songs.
+// query.Limit must be set; an unknown query.Sort falls back to title.
+func (sdb *songDB) GetSongLibrary(ctx context.Context, query song.ListSongsQuery) ([]models.Song, int64, error) {
+	tx := DB.WithContext(ctx).Model(&models.Song{})
+	if query.Artist != "" {
+		tx = tx.Where("LOWER(artist) = LOWER(?)", query.Artist)
+	}
+	if query.Album != "" {
+		tx = tx.Where("LOWER(album) = LOWER(?)", query.Album)
+	}
+	if query.Genre != "" {
+		tx = tx.Where("LOWER(genre) = LOWER(?)", query.Genre)
+	}
+	if query.YearFrom > 0 {
+		tx = tx.Where("year >= ?", query.YearFrom)
+	}
+	if query.YearTo > 0 {
+		tx = tx.Where("year <= ?", query.YearTo)
+	}
+
+	// New session so the filtered query can be reused for both Count and Find
+	tx = tx.Session(&gorm.Session{})
+
+	var total int64
+	if err := tx.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	columns, ok := songSortColumns[query.Sort]
+	if !ok {
+		columns = songSortColumns["title"]
+	}
+	direction := "ASC"
+	if query.Order == "desc" {
+		direction = "DESC"
+	}
+	for _, column := range columns {
+		tx = tx.Order(column + " " + direction)
+	}
+
 	var songs []models.Song
-	err := DB.WithContext(ctx).Find(&songs).Error
-	return songs, err
+	err := tx.Order("id ASC").Limit(query.Limit).Offset(query.Offset).Find(&songs).Error
+	return songs, total, err
 }
 
-// CreateSong adds a new song to the database.
+// CreateSong adds a new song to the database, linked to its artist and album.
 func (sdb *songDB) CreateSong(ctx context.Context, song *models.Song) error {
-	return DB.WithContext(ctx).Create(song).Error
+	return DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := newCatalogResolver().link(tx, song); err != nil {
+			return err
+		}
+		return tx.Create(song).Error
+	})
 }
 
-// UpdateSong updates an existing song in the database.
+// UpdateSong updates an existing song in the database and re-links its artist and album.
 func (sdb *songDB) UpdateSong(ctx context.Context, song *models.Song) error {
-	return DB.WithContext(ctx).Save(song).Error
+	return DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := newCatalogResolver().link(tx, song); err != nil {
+			return err
+		}
+		if err := tx.Save(song).Error; err != nil {
+			return err
+		}
+		return pruneCatalogUnlessScanning(tx)
+	})
 }
 
 // GetSongByFilePath retrieves a song by its file path from the database.
@@ -60,9 +125,15 @@ func (sdb *songDB) GetSongByFilePath(ctx context.Context, filePath string) (*mod
 	return &song, err
 }
 
-// DeleteSong removes a song from the database by its ID.
+// DeleteSong removes a song from the database by its ID, along with its album
+// and artist if nothing else references them.
 func (sdb *songDB) DeleteSong(ctx context.Context, songID uuid.UUID) error {
-	return DB.WithContext(ctx).Delete(&models.Song{}, songID).Error
+	return DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&models.Song{}, songID).Error; err != nil {
+			return err
+		}
+		return pruneCatalogUnlessScanning(tx)
+	})
 }
 
 // GetSongByID retrieves a song by its ID from the database.
@@ -75,150 +146,36 @@ func (sdb *songDB) GetSongByID(ctx context.Context, songID uuid.UUID) (*models.S
 }
 
 // ScanAndStoreSongs escanea el directorio de música y actualiza la base de datos.
-func (sdb *songDB) ScanAndStoreSongs(ctx context.Context) (*song.MusicScanResult, error) {
+// Los archivos se leen en paralelo (SCAN_WORKERS) y se guardan por lotes; ver libraryScanner.
+// progress, si no es nil, recibe el avance del escaneo desde una sola goroutine.
+// No toma el lock de escaneo: quien lo llame debe usar TryLockScan.
+func (sdb *songDB) ScanAndStoreSongs(ctx context.Context, progress func(song.ScanProgress)) (*song.MusicScanResult, error) {
 	musicDir := os.Getenv("MUSIC_DIRECTORY")
-	result := &song.MusicScanResult{}
-
 	if musicDir == "" {
 		return nil, fmt.Errorf("MUSIC_DIRECTORY environment variable not set")
 	}
+	scanner := newLibraryScanner(musicDir)
+	scanner.progress = progress
+	return scanner.run(ctx)
+}
 
-	var existingSongs []models.Song
-	if err := DB.WithContext(ctx).Find(&existingSongs).Error; err != nil {
-		return nil, fmt.Errorf("failed to fetch existing songs from DB: %w", err)
-	}
-	existingSongMap := make(map[string]models.Song)
-	for _, s := range existingSongs {
-		existingSongMap[s.FilePath] = s
-	}
-
-	newlyScannedPaths := make(map[string]bool)
-
-	err := filepath.Walk(musicDir, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			log.Printf("Error accessing path %s: %v", path, err)
-			result.Errors = append(result.Errors, fmt.Sprintf("Error accessing path %s: %v", path, err))
-			return nil
-		}
-		if info.IsDir() {
-			return nil
-		}
-
-		// Skip macOS resource fork files (._ prefixed files)
-		if strings.HasPrefix(info.Name(), "._") {
-			log.Printf("Skipping macOS resource fork file: %s", path)
-			return nil
-		}
-
-		ext := strings.ToLower(filepath.Ext(path))
-		if ext != ".mp3" && ext != ".flac" && ext != ".m4a" {
-			return nil
-		}
-
-		f, err := os.Open(path)
-		if err != nil {
-			log.Printf("Error opening file %s: %v", path, err)
-			result.Errors = append(result.Errors, fmt.Sprintf("Error opening file %s: %v", path, err))
-			return nil
-		}
-		defer f.Close()
-
-		t, err := tag.ReadFrom(f)
-		if err != nil {
-			log.Printf("Error reading tags from %s: %v", path, err)
-			result.Errors = append(result.Errors, fmt.Sprintf("Error reading tags from %s: %v", path, err))
-			return nil
-		}
-
-		normalizedMusicDir := musicDir
-		if !strings.HasSuffix(normalizedMusicDir, string(os.PathSeparator)) {
-			normalizedMusicDir += string(os.PathSeparator)
-		}
-		relativeFilePath := strings.TrimPrefix(path, normalizedMusicDir)
-		relativeFilePath = filepath.ToSlash(relativeFilePath)
-
-		newlyScannedPaths[relativeFilePath] = true
-
-		trackNum, _ := t.Track()
-		duration := 0 // Placeholder for duration, as before. You might want to get this from tag.
-
-		newSong := models.Song{
-			Title:           t.Title(),
-			Artist:          t.Artist(),
-			Album:           t.Album(),
-			Genre:           t.Genre(),
-			Year:            t.Year(),
-			TrackNumber:     trackNum,
-			DurationSeconds: duration,
-			FilePath:        relativeFilePath,
-			Filename:        filepath.Base(path),
-		}
-
-		if pic := t.Picture(); pic != nil {
-			img, _, err := image.Decode(bytes.NewReader(pic.Data))
-			if err != nil {
-				log.Printf("Error decoding album art for %s (Song: %s): %v", newSong.FilePath, newSong.Title, err)
-				result.Errors = append(result.Errors, fmt.Sprintf("Error decoding album art for %s: %v", newSong.FilePath, err))
-			} else {
-				artTargetDir := filepath.Dir(path)                      // Get the directory of the current music file
-				artFilePath := filepath.Join(artTargetDir, "thumb.jpg") // Full path for thumb.jpg
-
-				if err := os.MkdirAll(artTargetDir, 0755); err != nil {
-					log.Printf("Error creating album art directory %s: %v", artTargetDir, err)
-					result.Errors = append(result.Errors, fmt.Sprintf("Error creating album art directory %s: %v", artTargetDir, err))
-				} else {
-					outFile, err := os.Create(artFilePath)
-					if err != nil {
-						log.Printf("Error creating album art file %s: %v", artFilePath, err)
-						result.Errors = append(result.Errors, fmt.Sprintf("Error creating album art file %s: %v", artFilePath, err))
-					} else {
-						defer outFile.Close()
-						if err := jpeg.Encode(outFile, img, &jpeg.Options{Quality: 90}); err != nil {
-							log.Printf("Error encoding album art to JPEG for %s: %v", artFilePath, err)
-							result.Errors = append(result.Errors, fmt.Sprintf("Error encoding album art to JPEG for %s: %v", artFilePath, err))
-						}
-						// AlbumArtPath is NOT set on newSong here, as it's no longer a field.
-					}
-				}
-			}
-		}
-
-		if existingSong, found := existingSongMap[newSong.FilePath]; found {
-			newSong.ID = existingSong.ID
-			if existingSong.Equals(&newSong) {
-			} else {
-				if err := DB.WithContext(ctx).Save(&newSong).Error; err != nil {
-					log.Printf("Error updating song %s (ID: %s) in DB: %v", newSong.Title, newSong.ID.String(), err)
-					result.Errors = append(result.Errors, fmt.Sprintf("Error updating song %s (ID: %s) in DB: %v", newSong.Title, newSong.ID.String(), err))
-				} else {
-					result.Updated++
-				}
-			}
-			delete(existingSongMap, newSong.FilePath)
-		} else {
-			if err := DB.WithContext(ctx).Create(&newSong).Error; err != nil {
-				log.Printf("Error adding new song %s to DB: %v", newSong.Title, err)
-				result.Errors = append(result.Errors, fmt.Sprintf("Error adding new song %s to DB: %v", newSong.Title, err))
-			} else {
-				result.Added++
-			}
-		}
-
-		return nil
-	})
-
-	if err != nil {
-		return nil, fmt.Errorf("error during file system walk: %w", err)
+// TryLockScan takes the library scan lock, shared by all the instances using
+// the database. It returns ErrScanInProgress if it is already held.
+func (sdb *songDB) TryLockScan(ctx context.Context) (func(), error) {
+	unlock, err := tryAdvisoryLock(ctx, libraryScanLockKey)
+	if errors.Is(err, ErrLockHeld) {
+		return nil, ErrScanInProgress
 	}
+	return unlock, err
+}
 
-	for _, songToRemove := range existingSongMap {
-		if err := DB.WithContext(ctx).Delete(&songToRemove).Error; err != nil {
-			log.Printf("Error deleting song %s (ID: %s) from DB: %v", songToRemove.Title, songToRemove.ID.String(), err)
-			result.Errors = append(result.Errors, fmt.Sprintf("Error deleting song %s (ID: %s) from DB: %v", songToRemove.Title, songToRemove.ID.String(), err))
-		} else {
-			result.Removed++
-		}
+// TryShareScanLock takes the library scan lock in shared mode, to change songs
+// while keeping scans from starting. It returns ErrScanInProgress if a scan
+// holds the lock.
+func (sdb *songDB) TryShareScanLock(ctx context.Context) (func(), error) {
+	unlock, err := tryAdvisoryLockShared(ctx, libraryScanLockKey)
+	if errors.Is(err, ErrLockHeld) {
+		return nil, ErrScanInProgress
 	}
-
-	return result, nil
+	return unlock, err
 }
@@ -0,0 +1,211 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/demispreviotto/cajitamusical/cajitamusical-backend/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// TwoFactorDBer defines the interface for TOTP authenticators, recovery codes
+// and pending two-step logins.
+type TwoFactorDBer interface {
+	GetTwoFactor(ctx context.Context, userID uuid.UUID) (*models.TwoFactor, error)
+	StartTwoFactorEnrollment(ctx context.Context, userID uuid.UUID, secret string) error
+	ConfirmTwoFactor(ctx context.Context, userID uuid.UUID, step int64, recoveryCodeHashes []string) error
+	DeleteTwoFactor(ctx context.Context, userID uuid.UUID) error
+	UseTOTPStep(ctx context.Context, userID uuid.UUID, step int64) error
+
+	ReplaceRecoveryCodes(ctx context.Context, userID uuid.UUID, codeHashes []string) error
+	CountRecoveryCodes(ctx context.Context, userID uuid.UUID) (int64, error)
+	UseRecoveryCode(ctx context.Context, userID uuid.UUID, codeHash string) error
+
+	CreatePendingLogin(ctx context.Context, pending *models.PendingLogin) error
+	GetPendingLogin(ctx context.Context, tokenHash string, now time.Time) (*models.PendingLogin, error)
+	RecordPendingLoginFailure(ctx context.Context, tokenHash string, maxAttempts int) error
+	ConsumePendingLogin(ctx context.Context, tokenHash string, now time.Time) (*models.PendingLogin, error)
+	DeleteExpiredPendingLogins(ctx context.Context, before time.Time) error
+}
+
+// twoFactorDB is the concrete implementation of TwoFactorDBer.
+type twoFactorDB struct{}
+
+// NewTwoFactorDB creates a new instance of TwoFactorDB.
+func NewTwoFactorDB() TwoFactorDBer {
+	return &twoFactorDB{}
+}
+
+// GetTwoFactor returns the authenticator of the user, confirmed or not.
+func (tdb *twoFactorDB) GetTwoFactor(ctx context.Context, userID uuid.UUID) (*models.TwoFactor, error) {
+	var twoFactor models.TwoFactor
+	if err := DB.WithContext(ctx).Where("user_id = ?", userID).First(&twoFactor).Error; err != nil {
+		return nil, err
+	}
+	return &twoFactor, nil
+}
+
+// StartTwoFactorEnrollment stores a new unconfirmed secret for the user,
+// replacing a previous unconfirmed one. It returns gorm.ErrDuplicatedKey if
+// the user already has a confirmed authenticator, which is never replaced.
+func (tdb *twoFactorDB) StartTwoFactorEnrollment(ctx context.Context, userID uuid.UUID, secret string) error {
+	res := DB.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"secret", "updated_at"}),
+		Where:     clause.Where{Exprs: []clause.Expression{clause.Expr{SQL: "two_factors.confirmed_at IS NULL"}}},
+	}).Create(&models.TwoFactor{UserID: userID, Secret: secret})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrDuplicatedKey
+	}
+	return nil
+}
+
+// ConfirmTwoFactor enables the pending authenticator of the user, recording
+// the step of the code that confirmed it, and stores its recovery codes. It
+// returns gorm.ErrRecordNotFound if there is no enrollment to confirm.
+func (tdb *twoFactorDB) ConfirmTwoFactor(ctx context.Context, userID uuid.UUID, step int64, recoveryCodeHashes []string) error {
+	return DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&models.TwoFactor{}).
+			Where("user_id = ? AND confirmed_at IS NULL", userID).
+			Updates(map[string]any{"confirmed_at": time.Now(), "last_used_step": step})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return replaceRecoveryCodes(tx, userID, recoveryCodeHashes)
+	})
+}
+
+// DeleteTwoFactor removes the authenticator of the user with its recovery
+// codes and pending logins.
+func (tdb *twoFactorDB) DeleteTwoFactor(ctx context.Context, userID uuid.UUID) error {
+	return DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&models.PendingLogin{}).Error; err != nil {
+			return err
+		}
+		res := tx.Where("user_id = ?", userID).Delete(&models.TwoFactor{})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return nil
+	})
+}
+
+// UseTOTPStep records that the code of step was accepted. It returns
+// gorm.ErrRecordNotFound if that step or a later one was already used, so a
+// code cannot be replayed while it is still valid.
+func (tdb *twoFactorDB) UseTOTPStep(ctx context.Context, userID uuid.UUID, step int64) error {
+	res := DB.WithContext(ctx).Model(&models.TwoFactor{}).
+		Where("user_id = ? AND confirmed_at IS NOT NULL AND last_used_step < ?", userID, step).
+		Update("last_used_step", step)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// ReplaceRecoveryCodes discards the recovery codes of the user and stores new ones.
+func (tdb *twoFactorDB) ReplaceRecoveryCodes(ctx context.Context, userID uuid.UUID, codeHashes []string) error {
+	return DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return replaceRecoveryCodes(tx, userID, codeHashes)
+	})
+}
+
+func replaceRecoveryCodes(tx *gorm.DB, userID uuid.UUID, codeHashes []string) error {
+	if err := tx.Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error; err != nil {
+		return err
+	}
+	codes := make([]models.RecoveryCode, 0, len(codeHashes))
+	for _, codeHash := range codeHashes {
+		codes = append(codes, models.RecoveryCode{UserID: userID, CodeHash: codeHash})
+	}
+	if len(codes) == 0 {
+		return nil
+	}
+	return tx.Create(&codes).Error
+}
+
+// CountRecoveryCodes returns how many unused recovery codes the user has left.
+func (tdb *twoFactorDB) CountRecoveryCodes(ctx context.Context, userID uuid.UUID) (int64, error) {
+	var count int64
+	err := DB.WithContext(ctx).Model(&models.RecoveryCode{}).Where("user_id = ?", userID).Count(&count).Error
+	return count, err
+}
+
+// UseRecoveryCode deletes an unused recovery code of the user, or returns
+// gorm.ErrRecordNotFound.
+func (tdb *twoFactorDB) UseRecoveryCode(ctx context.Context, userID uuid.UUID, codeHash string) error {
+	res := DB.WithContext(ctx).Where("user_id = ? AND code_hash = ?", userID, codeHash).Delete(&models.RecoveryCode{})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// CreatePendingLogin stores the first step of a two-step login.
+func (tdb *twoFactorDB) CreatePendingLogin(ctx context.Context, pending *models.PendingLogin) error {
+	return DB.WithContext(ctx).Create(pending).Error
+}
+
+// GetPendingLogin returns an unexpired pending login.
+func (tdb *twoFactorDB) GetPendingLogin(ctx context.Context, tokenHash string, now time.Time) (*models.PendingLogin, error) {
+	var pending models.PendingLogin
+	if err := DB.WithContext(ctx).Where("token_hash = ? AND expires_at > ?", tokenHash, now).First(&pending).Error; err != nil {
+		return nil, err
+	}
+	return &pending, nil
+}
+
+// RecordPendingLoginFailure counts a wrong code for a pending login and
+// deletes it once it reaches maxAttempts, so the password must be given again.
+func (tdb *twoFactorDB) RecordPendingLoginFailure(ctx context.Context, tokenHash string, maxAttempts int) error {
+	return DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.PendingLogin{}).
+			Where("token_hash = ?", tokenHash).
+			Update("attempts", gorm.Expr("attempts + 1")).Error; err != nil {
+			return err
+		}
+		return tx.Where("token_hash = ? AND attempts >= ?", tokenHash, maxAttempts).Delete(&models.PendingLogin{}).Error
+	})
+}
+
+// ConsumePendingLogin deletes an unexpired pending login and returns it, or
+// returns gorm.ErrRecordNotFound.
+func (tdb *twoFactorDB) ConsumePendingLogin(ctx context.Context, tokenHash string, now time.Time) (*models.PendingLogin, error) {
+	var pending []models.PendingLogin
+	res := DB.WithContext(ctx).
+		Clauses(clause.Returning{}).
+		Where("token_hash = ? AND expires_at > ?", tokenHash, now).
+		Delete(&pending)
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	if len(pending) == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &pending[0], nil
+}
+
+// DeleteExpiredPendingLogins removes the pending logins that expired before before.
+func (tdb *twoFactorDB) DeleteExpiredPendingLogins(ctx context.Context, before time.Time) error {
+	return DB.WithContext(ctx).Where("expires_at < ?", before).Delete(&models.PendingLogin{}).Error
+}
@@ -2,20 +2,53 @@ package db
 
 import (
 	"context"
+	"errors"
+	"time"
 
 	"github.com/demispreviotto/cajitamusical/cajitamusical-backend/internal/models"
 	"github.com/google/uuid" // Importar uuid
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // UserDBer define la interfaz para las operaciones de la base de datos de usuarios.
 type UserDBer interface {
-	CreateUser(ctx context.Context, user *models.User, hashedPassword string) error
+	CreateUser(ctx context.Context, user *models.User, hashedPassword string, opts CreateUserOptions) error
 	GetUserByUsername(ctx context.Context, username string) (*models.User, string, error)
 	GetUserByID(ctx context.Context, userID uuid.UUID) (*models.User, error) // Usar uuid.UUID
+	UpdateUserAccess(ctx context.Context, userID uuid.UUID, role string, permissions []string) error
+	ListUsers(ctx context.Context, limit, offset int) ([]models.User, int64, error)
+	SetUserDisabled(ctx context.Context, userID uuid.UUID, disabledAt *time.Time) error
+	SetPasswordResetRequired(ctx context.Context, userID uuid.UUID, required bool) error
+	DeleteUser(ctx context.Context, userID uuid.UUID) error
+	UpdateUserProfile(ctx context.Context, user *models.User) error
+	IsEmailTaken(ctx context.Context, email string, exceptUserID uuid.UUID) (bool, error)
+	GetPasswordHash(ctx context.Context, userID uuid.UUID) (string, error)
+	UpdatePassword(ctx context.Context, userID uuid.UUID, hashedPassword string, keepSessionID uuid.UUID) error
+	RecordLogin(ctx context.Context, userID uuid.UUID, at time.Time, success bool) error
+	GetUserByEmail(ctx context.Context, email string) (*models.User, error)
 	// Agrega otros métodos de DB de usuario aquí
 }
 
+// ErrLastAdmin lo devuelven UpdateUserAccess, SetUserDisabled y DeleteUser si
+// el cambio dejaría la aplicación sin ningún administrador activo.
+var ErrLastAdmin = errors.New("cannot remove, disable or delete the last admin")
+
+// Errores de CreateUser según el modo de registro.
+var (
+	ErrRegistrationClosed = errors.New("registration is closed")
+	ErrInviteRequired     = errors.New("an invite code is required")
+	ErrInviteUnavailable  = errors.New("invite code is invalid, expired or used up")
+)
+
+// CreateUserOptions aplica el modo de registro dentro de la transacción de
+// CreateUser. El primer usuario siempre puede registrarse (y es admin).
+type CreateUserOptions struct {
+	InviteCodeHash string // Invitación a canjear; su rol sustituye a user.Role
+	Closed         bool   // Solo el primer usuario puede registrarse
+	RequireInvite  bool   // Hace falta una invitación válida
+}
+
 // userDB es la implementación concreta de UserDBer.
 type userDB struct{}
 
@@ -25,7 +58,7 @@ func NewUserDB() UserDBer {
 }
 
 // Implementación de CreateUser
-func (udb *userDB) CreateUser(ctx context.Context, user *models.User, hashedPassword string) error {
+func (udb *userDB) CreateUser(ctx context.Context, user *models.User, hashedPassword string, opts CreateUserOptions) error {
 	// Asegúrate de que user.ID se genere aquí si GORM no lo hace automáticamente
 	// Por ejemplo: if user.ID == uuid.Nil { user.ID = uuid.New() }
 	auth := models.Authentication{
@@ -35,6 +68,32 @@ func (udb *userDB) CreateUser(ctx context.Context, user *models.User, hashedPass
 
 	// Inicia una transacción
 	return DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// El primer usuario registrado es el administrador. El lock evita que dos
+		// registros simultáneos sobre una base vacía se conviertan ambos en admin.
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", userBootstrapLockKey).Error; err != nil {
+			return err
+		}
+		var userCount int64
+		if err := tx.Model(&models.User{}).Count(&userCount).Error; err != nil {
+			return err
+		}
+		if userCount == 0 {
+			user.Role = models.RoleAdmin
+		} else {
+			switch {
+			case opts.Closed:
+				return ErrRegistrationClosed
+			case opts.InviteCodeHash != "":
+				role, err := redeemInvite(tx, opts.InviteCodeHash)
+				if err != nil {
+					return err
+				}
+				user.Role = role
+			case opts.RequireInvite:
+				return ErrInviteRequired
+			}
+		}
+
 		if err := tx.Create(user).Error; err != nil {
 			return err
 		}
@@ -44,6 +103,22 @@ func (udb *userDB) CreateUser(ctx context.Context, user *models.User, hashedPass
 	})
 }
 
+// redeemInvite gasta un uso de la invitación si sigue siendo válida y devuelve su rol.
+func redeemInvite(tx *gorm.DB, codeHash string) (string, error) {
+	var invites []models.Invite
+	res := tx.Model(&invites).
+		Clauses(clause.Returning{Columns: []clause.Column{{Name: "role"}}}).
+		Where("code_hash = ? AND (max_uses = 0 OR uses < max_uses) AND (expires_at IS NULL OR expires_at > ?)", codeHash, time.Now()).
+		Update("uses", gorm.Expr("uses + 1"))
+	if res.Error != nil {
+		return "", res.Error
+	}
+	if len(invites) == 0 {
+		return "", ErrInviteUnavailable
+	}
+	return invites[0].Role, nil
+}
+
 // Implementación de GetUserByUsername
 func (udb *userDB) GetUserByUsername(ctx context.Context, username string) (*models.User, string, error) {
 	var user models.User
@@ -68,3 +143,197 @@ func (udb *userDB) GetUserByID(ctx context.Context, userID uuid.UUID) (*models.U
 	err := DB.WithContext(ctx).Where("id = ?", userID).First(&user).Error
 	return &user, err
 }
+
+// UpdateUserAccess cambia el rol y los permisos explícitos de un usuario.
+// permissions nil vuelve a los permisos por defecto del rol.
+func (udb *userDB) UpdateUserAccess(ctx context.Context, userID uuid.UUID, role string, permissions []string) error {
+	return DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if role != models.RoleAdmin {
+			if err := ensureAnotherAdmin(tx, userID); err != nil {
+				return err
+			}
+		}
+		res := tx.Model(&models.User{ID: userID}).
+			Select("role", "permissions", "updated_at").
+			Updates(&models.User{Role: role, Permissions: permissions})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return nil
+	})
+}
+
+// ensureAnotherAdmin devuelve ErrLastAdmin si userID es el único administrador
+// activo, con el lock de CreateUser tomado hasta el final de la transacción.
+func ensureAnotherAdmin(tx *gorm.DB, userID uuid.UUID) error {
+	if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", userBootstrapLockKey).Error; err != nil {
+		return err
+	}
+	var user models.User
+	if err := tx.Select("role", "disabled_at").Where("id = ?", userID).Take(&user).Error; err != nil {
+		return err
+	}
+	if !user.IsAdmin() || user.DisabledAt != nil {
+		return nil
+	}
+
+	var otherAdmins int64
+	if err := tx.Model(&models.User{}).
+		Where("role = ? AND disabled_at IS NULL AND id <> ?", models.RoleAdmin, userID).
+		Count(&otherAdmins).Error; err != nil {
+		return err
+	}
+	if otherAdmins == 0 {
+		return ErrLastAdmin
+	}
+	return nil
+}
+
+// ListUsers devuelve una página de usuarios ordenados por nombre de usuario,
+// con sus datos de autenticación, y el total de usuarios.
+func (udb *userDB) ListUsers(ctx context.Context, limit, offset int) ([]models.User, int64, error) {
+	var total int64
+	if err := DB.WithContext(ctx).Model(&models.User{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var users []models.User
+	err := DB.WithContext(ctx).
+		Preload("Authentication").
+		Order("username ASC").
+		Limit(limit).
+		Offset(offset).
+		Find(&users).Error
+	return users, total, err
+}
+
+// SetUserDisabled desactiva (disabledAt no nil) o reactiva una cuenta.
+func (udb *userDB) SetUserDisabled(ctx context.Context, userID uuid.UUID, disabledAt *time.Time) error {
+	if disabledAt == nil {
+		return udb.updateUserColumn(ctx, userID, "disabled_at", disabledAt)
+	}
+	return DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := ensureAnotherAdmin(tx, userID); err != nil {
+			return err
+		}
+		return tx.Model(&models.User{ID: userID}).Update("disabled_at", disabledAt).Error
+	})
+}
+
+// SetPasswordResetRequired marca si el usuario debe cambiar su contraseña.
+func (udb *userDB) SetPasswordResetRequired(ctx context.Context, userID uuid.UUID, required bool) error {
+	return udb.updateUserColumn(ctx, userID, "password_reset_required", required)
+}
+
+func (udb *userDB) updateUserColumn(ctx context.Context, userID uuid.UUID, column string, value any) error {
+	res := DB.WithContext(ctx).Model(&models.User{ID: userID}).Update(column, value)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// DeleteUser elimina un usuario con sus sesiones y credenciales. Sus datos
+// personales (playlists, favoritos, historial) se borran en cascada.
+func (udb *userDB) DeleteUser(ctx context.Context, userID uuid.UUID) error {
+	return DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := ensureAnotherAdmin(tx, userID); err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&models.Session{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&models.Authentication{}).Error; err != nil {
+			return err
+		}
+		res := tx.Delete(&models.User{}, "id = ?", userID)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return nil
+	})
+}
+
+// UpdateUserProfile guarda el nombre y el email del usuario, y si el email
+// está verificado.
+func (udb *userDB) UpdateUserProfile(ctx context.Context, user *models.User) error {
+	res := DB.WithContext(ctx).Model(&models.User{ID: user.ID}).
+		Select("name", "email", "email_verified_at", "updated_at").
+		Updates(&models.User{Name: user.Name, Email: user.Email, EmailVerifiedAt: user.EmailVerifiedAt})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// IsEmailTaken indica si otro usuario distinto de exceptUserID ya usa el email.
+func (udb *userDB) IsEmailTaken(ctx context.Context, email string, exceptUserID uuid.UUID) (bool, error) {
+	var count int64
+	err := DB.WithContext(ctx).Model(&models.User{}).
+		Where("LOWER(email) = LOWER(?) AND id <> ?", email, exceptUserID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// GetPasswordHash devuelve el hash de la contraseña del usuario.
+func (udb *userDB) GetPasswordHash(ctx context.Context, userID uuid.UUID) (string, error) {
+	var auth models.Authentication
+	err := DB.WithContext(ctx).Where("user_id = ?", userID).First(&auth).Error
+	return auth.PasswordHash, err
+}
+
+// UpdatePassword guarda el nuevo hash de la contraseña, quita la obligación de
+// cambiarla y revoca los tokens de API y las sesiones salvo keepSessionID
+// (uuid.Nil: todas), en una sola transacción.
+func (udb *userDB) UpdatePassword(ctx context.Context, userID uuid.UUID, hashedPassword string, keepSessionID uuid.UUID) error {
+	return DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return updatePassword(tx, userID, hashedPassword, keepSessionID)
+	})
+}
+
+func updatePassword(tx *gorm.DB, userID uuid.UUID, hashedPassword string, keepSessionID uuid.UUID) error {
+	res := tx.Model(&models.Authentication{}).Where("user_id = ?", userID).Update("password_hash", hashedPassword)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	if err := tx.Model(&models.User{ID: userID}).Update("password_reset_required", false).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("user_id = ? AND session_id <> ?", userID, keepSessionID).Delete(&models.Session{}).Error; err != nil {
+		return err
+	}
+	return tx.Where("user_id = ?", userID).Delete(&models.APIToken{}).Error
+}
+
+// RecordLogin guarda la fecha del último inicio de sesión correcto o fallido.
+func (udb *userDB) RecordLogin(ctx context.Context, userID uuid.UUID, at time.Time, success bool) error {
+	column := "last_failed_login"
+	if success {
+		column = "last_login"
+	}
+	return DB.WithContext(ctx).Model(&models.Authentication{}).Where("user_id = ?", userID).Update(column, at).Error
+}
+
+// GetUserByEmail obtiene un usuario por su email, sin distinguir mayúsculas.
+func (udb *userDB) GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
+	var user models.User
+	if err := DB.WithContext(ctx).Where("LOWER(email) = LOWER(?)", email).First(&user).Error; err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
@@ -4,4 +4,24 @@ package auth
 type LoginUserInput struct {
 	Username string `json:"username" binding:"required"`
 	Password string `json:"password" binding:"required"`
+
+	RememberMe bool `json:"remember_me"` // Keep the session cookie after the browser is closed
+}
+
+// TwoFactorLoginInput defines the request body for the second step of a login
+// with two-factor authentication.
+type TwoFactorLoginInput struct {
+	PendingToken string `json:"pending_token" binding:"required"` // Returned by the first step
+	Code         string `json:"code" binding:"required"`          // Authenticator code or recovery code
+}
+
+// TwoFactorCodeInput defines the request body to confirm two-factor
+// enrollment or to regenerate the recovery codes.
+type TwoFactorCodeInput struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// DisableTwoFactorInput defines the request body to turn off two-factor authentication.
+type DisableTwoFactorInput struct {
+	Password string `json:"password" binding:"required"` // Confirms the change
 }
@@ -1,11 +1,56 @@
 package auth
 
 import (
+	"time"
+
 	"github.com/demispreviotto/cajitamusical/cajitamusical-backend/internal/dto/user"
+	"github.com/google/uuid"
 )
 
-// LoginResponse defines the response for a successful login.
+// LoginResponse defines the response for a successful login. When the user
+// has two-factor authentication, the first step returns no user: it returns
+// the pending token to send with a code to /api/login/2fa instead.
 type LoginResponse struct {
-	Message string        `json:"message"`
-	User    user.UserInfo `json:"user"`
+	Message string         `json:"message"`
+	User    *user.UserInfo `json:"user,omitempty"`
+
+	TwoFactorRequired bool       `json:"two_factor_required,omitempty"`
+	PendingToken      string     `json:"pending_token,omitempty"`
+	PendingExpiresAt  *time.Time `json:"pending_expires_at,omitempty"`
+}
+
+// SessionResponse defines the response structure for a login session.
+type SessionResponse struct {
+	ID        uuid.UUID `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+	UserAgent string    `json:"user_agent"`
+	IPAddress string    `json:"ip_address,omitempty"`
+
+	Browser    string     `json:"browser,omitempty"` // Parsed from the user agent
+	OS         string     `json:"os,omitempty"`
+	Device     string     `json:"device"` // desktop, mobile, tablet or other
+	LastSeenAt *time.Time `json:"last_seen_at,omitempty"`
+	Current    bool       `json:"current"` // The session making the request
+}
+
+// TwoFactorStatusResponse describes the two-factor authentication of the user.
+type TwoFactorStatusResponse struct {
+	Enabled                bool       `json:"enabled"`
+	Pending                bool       `json:"pending"` // Enrollment started but not confirmed
+	ConfirmedAt            *time.Time `json:"confirmed_at,omitempty"`
+	RecoveryCodesRemaining int64      `json:"recovery_codes_remaining"`
+}
+
+// TwoFactorSetupResponse defines the response to starting enrollment. The
+// provisioning URI is meant to be shown as a QR code; the secret is for
+// entering it by hand.
+type TwoFactorSetupResponse struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"`
+}
+
+// RecoveryCodesResponse lists new recovery codes. They cannot be shown again.
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
 }
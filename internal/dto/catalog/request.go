@@ -0,0 +1,14 @@
+package catalog
+
+// ListArtistsQuery defines the query parameters for listing artists.
+type ListArtistsQuery struct {
+	Limit  int `form:"limit" binding:"omitempty,min=1,max=500"`
+	Offset int `form:"offset" binding:"omitempty,min=0"`
+}
+
+// ListAlbumsQuery defines the query parameters for listing albums.
+type ListAlbumsQuery struct {
+	Limit    int    `form:"limit" binding:"omitempty,min=1,max=500"`
+	Offset   int    `form:"offset" binding:"omitempty,min=0"`
+	ArtistID string `form:"artist_id" binding:"omitempty,uuid"` // Only albums of this artist
+}
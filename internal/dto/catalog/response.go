@@ -0,0 +1,56 @@
+package catalog
+
+import (
+	"github.com/demispreviotto/cajitamusical/cajitamusical-backend/internal/dto/song"
+	"github.com/google/uuid"
+)
+
+// ArtistResponse defines the response structure for an artist.
+type ArtistResponse struct {
+	ID                   uuid.UUID `json:"id"`
+	Name                 string    `json:"name"`
+	AlbumCount           int       `json:"album_count"`
+	TrackCount           int       `json:"track_count"`
+	TotalDurationSeconds int       `json:"total_duration_seconds"`
+}
+
+// ArtistDetailResponse defines the response structure for a single artist with its albums.
+type ArtistDetailResponse struct {
+	ArtistResponse
+	Albums []AlbumResponse `json:"albums"`
+}
+
+// AlbumResponse defines the response structure for an album.
+type AlbumResponse struct {
+	ID                   uuid.UUID `json:"id"`
+	Title                string    `json:"title"`
+	ArtistID             uuid.UUID `json:"artist_id"`
+	Artist               string    `json:"artist"`
+	Year                 int       `json:"year,omitempty"`
+	TrackCount           int       `json:"track_count"`
+	TotalDurationSeconds int       `json:"total_duration_seconds"`
+	AlbumArtURL          string    `json:"album_art_url"`
+}
+
+// AlbumDetailResponse defines the response structure for a single album with
+// its tracks, sorted by disc and track number.
+type AlbumDetailResponse struct {
+	AlbumResponse
+	Tracks []song.SongResponse `json:"tracks"`
+}
+
+// ListArtistsResponse defines a page of artists.
+type ListArtistsResponse struct {
+	Artists []ArtistResponse `json:"artists"`
+	Total   int              `json:"total"`
+	Limit   int              `json:"limit"`
+	Offset  int              `json:"offset"`
+}
+
+// ListAlbumsResponse defines a page of albums.
+type ListAlbumsResponse struct {
+	Albums []AlbumResponse `json:"albums"`
+	Total  int             `json:"total"`
+	Limit  int             `json:"limit"`
+	Offset int             `json:"offset"`
+}
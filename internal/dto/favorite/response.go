@@ -0,0 +1,31 @@
+package favorite
+
+import (
+	"time"
+
+	"github.com/demispreviotto/cajitamusical/cajitamusical-backend/internal/dto/song"
+	"github.com/google/uuid"
+)
+
+// FavoriteAlbumResponse defines the response structure for a starred album.
+type FavoriteAlbumResponse struct {
+	ID        uuid.UUID `json:"id"`
+	Title     string    `json:"title"`
+	ArtistID  uuid.UUID `json:"artist_id"`
+	Artist    string    `json:"artist"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// FavoriteArtistResponse defines the response structure for a starred artist.
+type FavoriteArtistResponse struct {
+	ID        uuid.UUID `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// FavoritesResponse groups everything a user has starred.
+type FavoritesResponse struct {
+	Songs   []song.SongResponse      `json:"songs"`
+	Albums  []FavoriteAlbumResponse  `json:"albums"`
+	Artists []FavoriteArtistResponse `json:"artists"`
+}
@@ -0,0 +1,27 @@
+package history
+
+import "github.com/google/uuid"
+
+// Playback events accepted by the play-event endpoint.
+const (
+	EventStart     = "start"
+	EventProgress  = "progress"
+	EventCompleted = "completed"
+	EventSkipped   = "skipped"
+)
+
+// PlayEventInput defines the request body for reporting a playback event.
+// PlayID is returned by the "start" event and is required for "progress";
+// "completed" and "skipped" without a PlayID record a finished play directly.
+type PlayEventInput struct {
+	SongID          uuid.UUID  `json:"song_id" binding:"required"`
+	Event           string     `json:"event" binding:"required,oneof=start progress completed skipped"`
+	PlayID          *uuid.UUID `json:"play_id"`
+	PositionSeconds int        `json:"position_seconds" binding:"min=0"`
+}
+
+// ListHistoryQuery defines the query parameters for listing play history.
+type ListHistoryQuery struct {
+	Limit  int `form:"limit" binding:"omitempty,min=1,max=200"`
+	Offset int `form:"offset" binding:"omitempty,min=0"`
+}
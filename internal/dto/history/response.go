@@ -0,0 +1,33 @@
+package history
+
+import (
+	"time"
+
+	"github.com/demispreviotto/cajitamusical/cajitamusical-backend/internal/dto/song"
+	"github.com/google/uuid"
+)
+
+// PlayEventResponse defines the response for a recorded playback event.
+type PlayEventResponse struct {
+	PlayID          uuid.UUID `json:"play_id"`
+	Status          string    `json:"status"`
+	PositionSeconds int       `json:"position_seconds"`
+}
+
+// HistoryEntryResponse defines a single play in the history of a user.
+type HistoryEntryResponse struct {
+	PlayID          uuid.UUID         `json:"play_id"`
+	Status          string            `json:"status"`
+	PositionSeconds int               `json:"position_seconds"`
+	StartedAt       time.Time         `json:"started_at"`
+	EndedAt         *time.Time        `json:"ended_at,omitempty"`
+	Song            song.SongResponse `json:"song"`
+}
+
+// ListHistoryResponse defines a page of play history.
+type ListHistoryResponse struct {
+	Entries []HistoryEntryResponse `json:"entries"`
+	Total   int                    `json:"total"`
+	Limit   int                    `json:"limit"`
+	Offset  int                    `json:"offset"`
+}
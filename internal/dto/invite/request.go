@@ -0,0 +1,8 @@
+package invite
+
+// CreateInviteInput defines the request body to create an invite.
+type CreateInviteInput struct {
+	Role          string `json:"role" binding:"omitempty,oneof=admin user guest"`   // Omitted: user
+	MaxUses       *int   `json:"max_uses" binding:"omitempty,min=0,max=10000"`      // Omitted: single use; 0: unlimited
+	ExpiresInDays *int   `json:"expires_in_days" binding:"omitempty,min=1,max=365"` // Omitted or null: never expires
+}
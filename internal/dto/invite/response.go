@@ -0,0 +1,33 @@
+package invite
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// InviteResponse defines the response structure for an invite. The code is
+// never included.
+type InviteResponse struct {
+	ID        uuid.UUID  `json:"id"`
+	Hint      string     `json:"hint"` // Start of the code
+	Role      string     `json:"role"`
+	MaxUses   int        `json:"max_uses"` // 0: unlimited
+	Uses      int        `json:"uses"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	CreatedBy *uuid.UUID `json:"created_by,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	Usable    bool       `json:"usable"` // Not expired nor used up
+}
+
+// CreateInviteResponse defines the response to a new invite. Code is what
+// people enter when registering; it cannot be shown again.
+type CreateInviteResponse struct {
+	InviteResponse
+	Code string `json:"code"`
+}
+
+// RegistrationResponse tells clients how registration works on this instance.
+type RegistrationResponse struct {
+	Mode string `json:"mode"` // open, invite-only or closed
+}
@@ -0,0 +1,35 @@
+package passkey
+
+import (
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/google/uuid"
+)
+
+// BeginRegistrationInput defines the request body to start registering a
+// passkey. It confirms that the account owner is asking for it.
+type BeginRegistrationInput struct {
+	Password string `json:"password" binding:"required"`
+	Code     string `json:"code"` // Authenticator or recovery code, with two-factor authentication
+}
+
+// FinishRegistrationInput defines the request body to register a passkey
+// with the credential created by the browser.
+type FinishRegistrationInput struct {
+	ChallengeID uuid.UUID                           `json:"challenge_id" binding:"required"`
+	Name        string                              `json:"name" binding:"max=100"` // Omitted: "Passkey"
+	Credential  protocol.CredentialCreationResponse `json:"credential"`
+}
+
+// BeginLoginInput defines the request body to start a passkey login.
+type BeginLoginInput struct {
+	Username string `json:"username"` // Omitted: any passkey stored on the device
+}
+
+// FinishLoginInput defines the request body to log in with the assertion
+// signed by the browser.
+type FinishLoginInput struct {
+	ChallengeID uuid.UUID                            `json:"challenge_id" binding:"required"`
+	Credential  protocol.CredentialAssertionResponse `json:"credential"`
+
+	RememberMe bool `json:"remember_me"` // Keep the session cookie after the browser is closed
+}
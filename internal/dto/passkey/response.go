@@ -0,0 +1,31 @@
+package passkey
+
+import (
+	"time"
+
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/google/uuid"
+)
+
+// RegistrationOptionsResponse defines the response to starting a passkey
+// registration. PublicKey is passed as is to navigator.credentials.create().
+type RegistrationOptionsResponse struct {
+	ChallengeID uuid.UUID                                   `json:"challenge_id"`
+	PublicKey   protocol.PublicKeyCredentialCreationOptions `json:"publicKey"`
+}
+
+// LoginOptionsResponse defines the response to starting a passkey login.
+// PublicKey is passed as is to navigator.credentials.get().
+type LoginOptionsResponse struct {
+	ChallengeID uuid.UUID                                  `json:"challenge_id"`
+	PublicKey   protocol.PublicKeyCredentialRequestOptions `json:"publicKey"`
+}
+
+// PasskeyResponse defines the response structure for a passkey.
+type PasskeyResponse struct {
+	ID             uuid.UUID  `json:"id"`
+	Name           string     `json:"name"`
+	BackupEligible bool       `json:"backup_eligible"` // Synced passkey, e.g. in a password manager
+	LastUsedAt     *time.Time `json:"last_used_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
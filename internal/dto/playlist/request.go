@@ -0,0 +1,26 @@
+package playlist
+
+import "github.com/google/uuid"
+
+// CreatePlaylistInput defines the request body for creating a playlist.
+type CreatePlaylistInput struct {
+	Name        string `json:"name" binding:"required,max=255"`
+	Description string `json:"description"`
+}
+
+// UpdatePlaylistInput defines the request body for renaming a playlist.
+type UpdatePlaylistInput struct {
+	Name        *string `json:"name" binding:"omitempty,min=1,max=255"` // Use pointers for optional updates
+	Description *string `json:"description"`
+}
+
+// AddSongInput defines the request body for appending a song to a playlist.
+type AddSongInput struct {
+	SongID uuid.UUID `json:"song_id" binding:"required"`
+}
+
+// ReorderPlaylistInput defines the new order of a playlist. It must list
+// every item ID of the playlist exactly once.
+type ReorderPlaylistInput struct {
+	ItemIDs []uuid.UUID `json:"item_ids" binding:"required"`
+}
@@ -0,0 +1,34 @@
+package playlist
+
+import (
+	"time"
+
+	"github.com/demispreviotto/cajitamusical/cajitamusical-backend/internal/dto/song"
+	"github.com/google/uuid"
+)
+
+// PlaylistResponse defines the response structure for a single playlist.
+type PlaylistResponse struct {
+	ID                   uuid.UUID               `json:"id"`
+	Name                 string                  `json:"name"`
+	Description          string                  `json:"description"`
+	TrackCount           int                     `json:"track_count"`
+	TotalDurationSeconds int                     `json:"total_duration_seconds"`
+	CreatedAt            time.Time               `json:"created_at"`
+	UpdatedAt            time.Time               `json:"updated_at"`
+	Tracks               []PlaylistTrackResponse `json:"tracks,omitempty"` // Only filled when fetching a single playlist
+}
+
+// PlaylistTrackResponse is a playlist entry. ItemID identifies the entry itself
+// (for removal and reordering), while Song is playable as-is.
+type PlaylistTrackResponse struct {
+	ItemID   uuid.UUID         `json:"item_id"`
+	Position int               `json:"position"`
+	AddedAt  time.Time         `json:"added_at"`
+	Song     song.SongResponse `json:"song"`
+}
+
+// ListPlaylistsResponse defines the response structure for a list of playlists.
+type ListPlaylistsResponse struct {
+	Playlists []PlaylistResponse `json:"playlists"`
+}
@@ -0,0 +1,7 @@
+package search
+
+// SearchQuery defines the query parameters of the search endpoint.
+type SearchQuery struct {
+	Q     string `form:"q" binding:"required"`
+	Limit int    `form:"limit" binding:"omitempty,min=1,max=100"` // Maximum results per group
+}
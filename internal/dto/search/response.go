@@ -0,0 +1,27 @@
+package search
+
+import "github.com/demispreviotto/cajitamusical/cajitamusical-backend/internal/dto/song"
+
+// AlbumResult defines an album matching a search.
+type AlbumResult struct {
+	Album       string `json:"album"`
+	Artist      string `json:"artist"`
+	Year        int    `json:"year,omitempty"`
+	TrackCount  int    `json:"track_count"`
+	AlbumArtURL string `json:"album_art_url"`
+}
+
+// ArtistResult defines an artist matching a search.
+type ArtistResult struct {
+	Artist     string `json:"artist"`
+	TrackCount int    `json:"track_count"`
+	AlbumCount int    `json:"album_count"`
+}
+
+// SearchResponse groups search results by kind, each group ordered by relevance.
+type SearchResponse struct {
+	Query   string              `json:"query"`
+	Songs   []song.SongResponse `json:"songs"`
+	Albums  []AlbumResult       `json:"albums"`
+	Artists []ArtistResult      `json:"artists"`
+}
@@ -14,3 +14,17 @@ type UpdateSongInput struct {
 	Artist *string `json:"artist"`
 	Album  *string `json:"album"`
 }
+
+// ListSongsQuery defines the query parameters for listing the library.
+// Zero values mean "not set"; the service applies the defaults.
+type ListSongsQuery struct {
+	Limit    int    `form:"limit" binding:"omitempty,min=1,max=500"`
+	Offset   int    `form:"offset" binding:"omitempty,min=0"`
+	Sort     string `form:"sort" binding:"omitempty,oneof=title artist album genre year track_number duration_seconds created_at"`
+	Order    string `form:"order" binding:"omitempty,oneof=asc desc"`
+	Artist   string `form:"artist"` // Exact match, case-insensitive
+	Album    string `form:"album"`  // Exact match, case-insensitive
+	Genre    string `form:"genre"`  // Exact match, case-insensitive
+	YearFrom int    `form:"year_from" binding:"omitempty,min=0"`
+	YearTo   int    `form:"year_to" binding:"omitempty,min=0"`
+}
@@ -22,18 +22,64 @@ type SongResponse struct {
 	UpdatedAt       time.Time `json:"updated_at"`
 	AlbumArtURL     string    `json:"album_art_url"`
 	// AlbumArtURL     string    `json:"album_art_url,omitempty"`
+	AlbumArtist string     `json:"album_artist,omitempty"`
+	DiscNumber  int        `json:"disc_number,omitempty"`
+	ArtistID    *uuid.UUID `json:"artist_id,omitempty"`
+	AlbumID     *uuid.UUID `json:"album_id,omitempty"`
+
+	Favorite     bool       `json:"favorite"`                 // Whether the requesting user starred this song
+	PlayCount    int        `json:"play_count"`               // Completed plays by the requesting user
+	LastPlayedAt *time.Time `json:"last_played_at,omitempty"` // Last time the requesting user played it
 }
 
 // ListSongsResponse defines the response structure for a list of songs.
 type ListSongsResponse struct {
-	Songs []SongResponse `json:"songs"`
-	Total int            `json:"total"` // Useful for pagination
+	Songs  []SongResponse `json:"songs"`
+	Total  int            `json:"total"` // Useful for pagination
+	Limit  int            `json:"limit"`
+	Offset int            `json:"offset"`
 }
 
 // MusicScanResult defines the result of a music scan operation.
 type MusicScanResult struct {
-	Added   int
-	Updated int
-	Removed int
-	Errors  []string
+	Added     int
+	Updated   int
+	Removed   int
+	Unchanged int // Files skipped because their size, modification time (and hash) did not change
+	Errors    []string
+}
+
+// Phases of a library scan.
+const (
+	ScanPhaseQueued   = "queued"
+	ScanPhaseScanning = "scanning" // Walking the directory and reading changed files
+	ScanPhaseCleanup  = "cleanup"  // Removing songs whose files are gone
+	ScanPhaseDone     = "done"
+)
+
+// ScanProgress reports how far a library scan has got.
+type ScanProgress struct {
+	Phase          string `json:"phase"`
+	FilesSeen      int    `json:"files_seen"`      // Audio files found so far
+	FilesProcessed int    `json:"files_processed"` // Files checked, read and stored (or skipped as unchanged)
+	Errors         int    `json:"errors"`
+}
+
+// Statuses of a scan job.
+const (
+	ScanStatusRunning   = "running"
+	ScanStatusCompleted = "completed"
+	ScanStatusFailed    = "failed"
+	ScanStatusCancelled = "cancelled"
+)
+
+// ScanJobResponse defines the response structure for an asynchronous library scan.
+type ScanJobResponse struct {
+	ID         uuid.UUID        `json:"id"`
+	Status     string           `json:"status"`
+	Progress   ScanProgress     `json:"progress"`
+	StartedAt  time.Time        `json:"started_at"`
+	FinishedAt *time.Time       `json:"finished_at,omitempty"`
+	Result     *MusicScanResult `json:"result,omitempty"` // Set once the scan finishes, also when cancelled
+	Error      string           `json:"error,omitempty"`
 }
@@ -0,0 +1,19 @@
+package task
+
+import "time"
+
+// TaskStatusResponse defines the response structure for a scheduled background task.
+type TaskStatusResponse struct {
+	Name               string     `json:"name"`
+	Schedule           string     `json:"schedule"`
+	Running            bool       `json:"running"`
+	LastRunAt          *time.Time `json:"last_run_at,omitempty"`
+	LastDurationMillis int64      `json:"last_duration_ms,omitempty"`
+	LastError          string     `json:"last_error,omitempty"`
+	NextRunAt          *time.Time `json:"next_run_at,omitempty"`
+}
+
+// ListTasksResponse defines the response structure for the scheduled tasks.
+type ListTasksResponse struct {
+	Tasks []TaskStatusResponse `json:"tasks"`
+}
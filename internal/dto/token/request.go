@@ -0,0 +1,8 @@
+package token
+
+// CreateAPITokenInput defines the request body to create a personal API token.
+type CreateAPITokenInput struct {
+	Name          string   `json:"name" binding:"required,max=100"`
+	Scopes        []string `json:"scopes" binding:"required,min=1"`
+	ExpiresInDays *int     `json:"expires_in_days" binding:"omitempty,min=1,max=3650"` // Omitted or null: never expires
+}
@@ -0,0 +1,27 @@
+package token
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// APITokenResponse defines the response structure for a personal API token.
+// The secret is never included.
+type APITokenResponse struct {
+	ID         uuid.UUID  `json:"id"`
+	Name       string     `json:"name"`
+	Hint       string     `json:"hint"` // Start of the secret
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	Expired    bool       `json:"expired"`
+}
+
+// CreateAPITokenResponse defines the response to a new token. Token is the
+// secret to send as "Authorization: Bearer <token>"; it cannot be shown again.
+type CreateAPITokenResponse struct {
+	APITokenResponse
+	Token string `json:"token"`
+}
@@ -6,4 +6,53 @@ type RegisterUserInput struct {
 	Email    string `json:"email" binding:"required,email"`
 	Name     string `json:"name" binding:"required"`
 	Password string `json:"password" binding:"required,min=6"`
+
+	InviteCode string `json:"invite_code"` // Required in invite-only mode; also sets the role
+}
+
+// UpdateAccessInput defines the request body to change the role and permissions of a user.
+type UpdateAccessInput struct {
+	Role        string   `json:"role" binding:"required,oneof=admin user guest"`
+	Permissions []string `json:"permissions"` // Omitted or null: the defaults of the role
+}
+
+// ListUsersQuery defines the query parameters for listing users.
+type ListUsersQuery struct {
+	Limit  int `form:"limit" binding:"omitempty,min=1,max=200"`
+	Offset int `form:"offset" binding:"omitempty,min=0"`
+}
+
+// UpdateProfileInput defines the request body to update the authenticated user's profile.
+// Omitted fields are left unchanged. Changing the email requires CurrentPassword.
+type UpdateProfileInput struct {
+	Name            *string `json:"name" binding:"omitempty,min=1,max=255"`
+	Email           *string `json:"email" binding:"omitempty,email,max=255"`
+	CurrentPassword string  `json:"current_password"`
+}
+
+// ChangePasswordInput defines the request body to change the authenticated user's password.
+type ChangePasswordInput struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	NewPassword     string `json:"new_password" binding:"required,min=6"`
+}
+
+// DeleteAccountInput defines the request body to delete the authenticated user's account.
+type DeleteAccountInput struct {
+	Password string `json:"password" binding:"required"` // Confirms the deletion
+}
+
+// ForgotPasswordInput defines the request body to ask for a password reset link.
+type ForgotPasswordInput struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+// ResetPasswordInput defines the request body to set a new password with a reset link.
+type ResetPasswordInput struct {
+	Token       string `json:"token" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required,min=6"`
+}
+
+// ResendVerificationInput defines the request body to ask for a new email verification link.
+type ResendVerificationInput struct {
+	Email string `json:"email" binding:"required,email"`
 }
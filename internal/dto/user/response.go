@@ -1,6 +1,10 @@
 package user
 
-import "github.com/google/uuid" // Import uuid for the ID field
+import (
+	"time"
+
+	"github.com/google/uuid" // Import uuid for the ID field
+)
 
 // UserInfo defines the user information to be exposed in responses.
 type UserInfo struct {
@@ -8,6 +12,11 @@ type UserInfo struct {
 	Username string    `json:"username"`
 	Email    string    `json:"email"`
 	Name     string    `json:"name"`
+
+	Role                  string   `json:"role"`
+	Permissions           []string `json:"permissions"` // Effective permissions
+	PasswordResetRequired bool     `json:"password_reset_required"`
+	EmailVerified         bool     `json:"email_verified"`
 }
 
 // // LoginResponse defines the response for a successful login.
@@ -22,4 +31,21 @@ type UserResponse struct {
 	Username string    `json:"username"`
 	Email    string    `json:"email"`
 	Name     string    `json:"name"`
+	Role     string    `json:"role"`
+}
+
+// AdminUserResponse defines the user information shown to admins.
+type AdminUserResponse struct {
+	UserInfo
+	CreatedAt  time.Time  `json:"created_at"`
+	LastLogin  *time.Time `json:"last_login,omitempty"`
+	DisabledAt *time.Time `json:"disabled_at,omitempty"`
+}
+
+// ListUsersResponse defines a page of users.
+type ListUsersResponse struct {
+	Users  []AdminUserResponse `json:"users"`
+	Total  int                 `json:"total"`
+	Limit  int                 `json:"limit"`
+	Offset int                 `json:"offset"`
 }
@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/demispreviotto/cajitamusical/cajitamusical-backend/internal/dto/token"
+	"github.com/demispreviotto/cajitamusical/cajitamusical-backend/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+// apiTokenHandler handles the personal API tokens of the authenticated user.
+type apiTokenHandler struct {
+	apiTokenService services.APITokenServicer
+}
+
+// NewapiTokenHandler creates a new instance of apiTokenHandler.
+func NewapiTokenHandler(apiTokenService services.APITokenServicer) *apiTokenHandler {
+	return &apiTokenHandler{apiTokenService: apiTokenService}
+}
+
+// CreateToken creates a token and returns its secret, shown only this once.
+func (h *apiTokenHandler) CreateToken(c *gin.Context) {
+	userModel, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	var input token.CreateAPITokenInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	response, err := h.apiTokenService.CreateToken(c.Request.Context(), userModel, input)
+	if err != nil {
+		respondAPITokenError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, response)
+}
+
+// ListTokens lists the tokens of the authenticated user.
+func (h *apiTokenHandler) ListTokens(c *gin.Context) {
+	userModel, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	tokens, err := h.apiTokenService.ListTokens(c.Request.Context(), userModel.ID)
+	if err != nil {
+		respondAPITokenError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, tokens)
+}
+
+// RevokeToken deletes a token of the authenticated user.
+func (h *apiTokenHandler) RevokeToken(c *gin.Context) {
+	userModel, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	tokenID, ok := uuidParam(c, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.apiTokenService.RevokeToken(c.Request.Context(), userModel.ID, tokenID); err != nil {
+		respondAPITokenError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// respondAPITokenError maps API token service errors to HTTP responses.
+func respondAPITokenError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidScope):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrAPITokenNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		log.Printf("Handler: API token operation failed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -2,11 +2,15 @@ package handlers
 
 import (
 	"context"
+	"errors"
 	"log"
-	"net/http" // Necesario para net.ParseIP
-	"time"     // Necesario para time.Now()
+	"math"
+	"net/http"
+	"strconv"
 
 	"github.com/demispreviotto/cajitamusical/cajitamusical-backend/internal/dto/auth"
+	"github.com/demispreviotto/cajitamusical/cajitamusical-backend/internal/dto/passkey"
+	"github.com/demispreviotto/cajitamusical/cajitamusical-backend/internal/middleware"
 	"github.com/demispreviotto/cajitamusical/cajitamusical-backend/internal/services" // Importar el paquete services
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -14,12 +18,13 @@ import (
 
 // authHandler es un handler para las operaciones de autenticación.
 type authHandler struct {
-	authService services.AuthServicer // Dependencia del servicio de autenticación
+	authService  services.AuthServicer   // Dependencia del servicio de autenticación
+	cookiePolicy middleware.CookiePolicy // Atributos de la cookie de sesión
 }
 
 // NewauthHandler crea una nueva instancia de authHandler.
-func NewauthHandler(authService services.AuthServicer) *authHandler {
-	return &authHandler{authService: authService}
+func NewauthHandler(authService services.AuthServicer, cookiePolicy middleware.CookiePolicy) *authHandler {
+	return &authHandler{authService: authService, cookiePolicy: cookiePolicy}
 }
 
 // LoginUser maneja el inicio de sesión del usuario.
@@ -34,24 +39,106 @@ func (h *authHandler) LoginUser(c *gin.Context) {
 	userAgent := c.Request.UserAgent()
 	clientIP := c.ClientIP()
 
-	loginResponse, session, err := h.authService.Login(context.Background(), loginRequest.Username, loginRequest.Password, userAgent, clientIP)
+	loginResponse, session, err := h.authService.Login(context.Background(), loginRequest, userAgent, clientIP)
 	if err != nil {
 		log.Printf("Handler: Login attempt failed for %s: %v", loginRequest.Username, err)
-		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()}) // Retorna el error del servicio
+		respondLoginError(c, err)
 		return
 	}
+	if session == nil {
+		// Two-factor authentication: the session is created by CompleteTwoFactorLogin
+		c.JSON(http.StatusOK, loginResponse)
+		return
+	}
+
+	// Set the session cookie: persistent until the session expires with
+	// "remember me", otherwise dropped when the browser closes
+	h.cookiePolicy.Set(c, session.SessionID.String(), session.ExpiresAt, session.Persistent)
+
+	c.JSON(http.StatusOK, loginResponse)
+}
+
+// CompleteTwoFactorLogin maneja el segundo paso del inicio de sesión con doble
+// factor: el token pendiente del primer paso y un código.
+func (h *authHandler) CompleteTwoFactorLogin(c *gin.Context) {
+	var input auth.TwoFactorLoginInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	loginResponse, session, err := h.authService.CompleteTwoFactorLogin(c.Request.Context(), input, c.Request.UserAgent(), c.ClientIP())
+	if err != nil {
+		log.Printf("Handler: Two-factor login attempt failed: %v", err)
+		respondLoginError(c, err)
+		return
+	}
+
+	h.cookiePolicy.Set(c, session.SessionID.String(), session.ExpiresAt, session.Persistent)
+	c.JSON(http.StatusOK, loginResponse)
+}
 
-	// Set the session cookie
-	// Calcular el tiempo de vida de la cookie basado en la expiración de la sesión
-	maxAge := int(time.Until(session.ExpiresAt).Seconds())
-	c.SetCookie("session_id", session.SessionID.String(), maxAge, "/", "localhost", false, true)
+// BeginPasskeyLogin devuelve las opciones para navigator.credentials.get().
+func (h *authHandler) BeginPasskeyLogin(c *gin.Context) {
+	var input passkey.BeginLoginInput
+	// El cuerpo es opcional: sin nombre de usuario vale cualquier passkey
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
 
+	options, err := h.authService.BeginPasskeyLogin(c.Request.Context(), input, c.ClientIP())
+	if err != nil {
+		if errors.Is(err, services.ErrLoginThrottled) {
+			respondLoginError(c, err)
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, options)
+}
+
+// FinishPasskeyLogin inicia sesión con la firma de la passkey y pone la
+// misma cookie de sesión que LoginUser.
+func (h *authHandler) FinishPasskeyLogin(c *gin.Context) {
+	var input passkey.FinishLoginInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	loginResponse, session, err := h.authService.FinishPasskeyLogin(c.Request.Context(), input, c.Request.UserAgent(), c.ClientIP())
+	if err != nil {
+		log.Printf("Handler: Passkey login attempt failed: %v", err)
+		respondLoginError(c, err)
+		return
+	}
+
+	h.cookiePolicy.Set(c, session.SessionID.String(), session.ExpiresAt, session.Persistent)
 	c.JSON(http.StatusOK, loginResponse)
 }
 
+// respondLoginError traduce los errores del inicio de sesión a respuestas HTTP.
+func respondLoginError(c *gin.Context, err error) {
+	var throttled *services.LoginThrottledError
+	if errors.As(err, &throttled) {
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(throttled.RetryAfter.Seconds()))))
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, services.ErrAccountDisabled) || errors.Is(err, services.ErrEmailNotVerified) {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()}) // Retorna el error del servicio
+}
+
 // LogoutUser maneja el cierre de sesión del usuario.
 func (h *authHandler) LogoutUser(c *gin.Context) {
-	sessionIDStr, err := c.Cookie("session_id")
+	sessionIDStr, err := h.cookiePolicy.Read(c)
 	if err != nil {
 		c.JSON(http.StatusOK, gin.H{"message": "Already logged out or session expired."})
 		return
@@ -70,7 +157,7 @@ func (h *authHandler) LogoutUser(c *gin.Context) {
 		return
 	}
 
-	c.SetCookie("session_id", "", -1, "/", "localhost", false, true) // Invalida la cookie
+	h.cookiePolicy.Clear(c) // Invalida la cookie
 	c.JSON(http.StatusOK, gin.H{"message": "Logout successful"})
 }
 
@@ -87,3 +174,70 @@ func (h *authHandler) CleanupExpiredSessions(c *gin.Context) {
 	log.Println("Handler: Manual expired session cleanup completed.")
 	c.JSON(http.StatusOK, gin.H{"message": "Expired sessions cleanup initiated successfully"})
 }
+
+// ListSessions devuelve las sesiones vigentes del usuario autenticado.
+func (h *authHandler) ListSessions(c *gin.Context) {
+	userModel, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	session, ok := currentSession(c)
+	if !ok {
+		return
+	}
+
+	sessions, err := h.authService.ListSessions(c.Request.Context(), userModel.ID, session.SessionID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, sessions)
+}
+
+// RevokeSession cierra una de las sesiones del usuario autenticado. Cerrar la
+// actual equivale a cerrar sesión.
+func (h *authHandler) RevokeSession(c *gin.Context) {
+	userModel, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	session, ok := currentSession(c)
+	if !ok {
+		return
+	}
+	sessionID, ok := uuidParam(c, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.authService.RevokeSession(c.Request.Context(), userModel.ID, sessionID); err != nil {
+		if errors.Is(err, services.ErrSessionNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if sessionID == session.SessionID {
+		h.cookiePolicy.Clear(c)
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// RevokeOtherSessions cierra todas las sesiones del usuario autenticado salvo la actual.
+func (h *authHandler) RevokeOtherSessions(c *gin.Context) {
+	userModel, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	session, ok := currentSession(c)
+	if !ok {
+		return
+	}
+
+	if err := h.authService.RevokeOtherSessions(c.Request.Context(), userModel.ID, session.SessionID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/demispreviotto/cajitamusical/cajitamusical-backend/internal/dto/catalog"
+	"github.com/demispreviotto/cajitamusical/cajitamusical-backend/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+// catalogHandler is a handler for browsing artists and albums.
+type catalogHandler struct {
+	catalogService services.CatalogServicer
+}
+
+// NewcatalogHandler creates a new instance of catalogHandler.
+func NewcatalogHandler(catalogService services.CatalogServicer) *catalogHandler {
+	return &catalogHandler{catalogService: catalogService}
+}
+
+// ListArtists returns a page of artists.
+func (h *catalogHandler) ListArtists(c *gin.Context) {
+	var query catalog.ListArtistsQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	response, err := h.catalogService.ListArtists(c.Request.Context(), query)
+	if err != nil {
+		log.Printf("Handler: Failed to list artists: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve artists"})
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// GetArtist returns an artist with its albums.
+func (h *catalogHandler) GetArtist(c *gin.Context) {
+	artistID, ok := uuidParam(c, "id")
+	if !ok {
+		return
+	}
+
+	response, err := h.catalogService.GetArtist(c.Request.Context(), artistID)
+	if err != nil {
+		respondCatalogError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// ListAlbums returns a page of albums.
+func (h *catalogHandler) ListAlbums(c *gin.Context) {
+	var query catalog.ListAlbumsQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	response, err := h.catalogService.ListAlbums(c.Request.Context(), query)
+	if err != nil {
+		respondCatalogError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// GetAlbum returns an album with its tracks.
+func (h *catalogHandler) GetAlbum(c *gin.Context) {
+	userModel, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	albumID, ok := uuidParam(c, "id")
+	if !ok {
+		return
+	}
+
+	response, err := h.catalogService.GetAlbum(c.Request.Context(), userModel.ID, albumID)
+	if err != nil {
+		respondCatalogError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// respondCatalogError maps catalog service errors to HTTP responses.
+func respondCatalogError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrArtistNotFound), errors.Is(err, services.ErrAlbumNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		log.Printf("Handler: Catalog operation failed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/demispreviotto/cajitamusical/cajitamusical-backend/internal/middleware"
+	"github.com/demispreviotto/cajitamusical/cajitamusical-backend/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// currentUser returns the user stored in the context by AuthMiddleware.
+// If it is missing, it writes the error response and returns false.
+func currentUser(c *gin.Context) (*models.User, bool) {
+	userFromContext, exists := c.Get(middleware.UserContextKey)
+	if !exists {
+		log.Printf("Handler: User info not found in context for %s", c.FullPath())
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized: User info not available"})
+		return nil, false
+	}
+
+	userModel, ok := userFromContext.(*models.User)
+	if !ok {
+		log.Printf("Handler: User info in context is of incorrect type for %s: got %T", c.FullPath(), userFromContext)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve user info (type mismatch)"})
+		return nil, false
+	}
+	return userModel, true
+}
+
+// currentSession returns the session set by AuthMiddleware. If it is missing,
+// it writes an error response and returns false.
+func currentSession(c *gin.Context) (*models.Session, bool) {
+	sessionFromContext, exists := c.Get(middleware.SessionContextKey)
+	session, ok := sessionFromContext.(*models.Session)
+	if !exists || !ok {
+		log.Printf("Handler: Session not found in context for %s", c.FullPath())
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized: Session not available"})
+		return nil, false
+	}
+	return session, true
+}
+
+// uuidParam parses a UUID path parameter. If it is invalid, it writes a 400
+// response and returns false.
+func uuidParam(c *gin.Context, name string) (uuid.UUID, bool) {
+	id, err := uuid.Parse(c.Param(name))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + name + " format"})
+		return uuid.Nil, false
+	}
+	return id, true
+}
@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/demispreviotto/cajitamusical/cajitamusical-backend/internal/dto/user"
+	"github.com/demispreviotto/cajitamusical/cajitamusical-backend/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+// emailVerificationHandler handles the verification of email addresses.
+type emailVerificationHandler struct {
+	emailVerificationService services.EmailVerificationServicer
+}
+
+// NewemailVerificationHandler creates a new instance of emailVerificationHandler.
+func NewemailVerificationHandler(emailVerificationService services.EmailVerificationServicer) *emailVerificationHandler {
+	return &emailVerificationHandler{emailVerificationService: emailVerificationService}
+}
+
+// VerifyEmail verifies the email with the token of the link sent by email.
+func (h *emailVerificationHandler) VerifyEmail(c *gin.Context) {
+	token := c.Query("token")
+	if token == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "token is required"})
+		return
+	}
+
+	if err := h.emailVerificationService.VerifyEmail(c.Request.Context(), token); err != nil {
+		if errors.Is(err, services.ErrInvalidVerificationToken) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		log.Printf("Handler: Email verification failed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Email verified"})
+}
+
+// ResendVerification sends a new verification link. The response is the same
+// whether the email is registered or not.
+func (h *emailVerificationHandler) ResendVerification(c *gin.Context) {
+	var input user.ResendVerificationInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.emailVerificationService.ResendVerification(c.Request.Context(), input); err != nil {
+		log.Printf("Handler: Verification resend failed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{"message": "If the email is registered and not verified yet, a verification link has been sent"})
+}
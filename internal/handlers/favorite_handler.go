@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/demispreviotto/cajitamusical/cajitamusical-backend/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+// favoriteHandler is a handler for per-user favorites.
+type favoriteHandler struct {
+	favoriteService services.FavoriteServicer
+}
+
+// NewfavoriteHandler creates a new instance of favoriteHandler.
+func NewfavoriteHandler(favoriteService services.FavoriteServicer) *favoriteHandler {
+	return &favoriteHandler{favoriteService: favoriteService}
+}
+
+// ListFavorites returns the songs, albums and artists starred by the authenticated user.
+func (h *favoriteHandler) ListFavorites(c *gin.Context) {
+	userModel, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	response, err := h.favoriteService.ListFavorites(c.Request.Context(), userModel.ID)
+	if err != nil {
+		log.Printf("Handler: Failed to list favorites for user %s: %v", userModel.ID.String(), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve favorites"})
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// StarSong marks a song as favorite.
+func (h *favoriteHandler) StarSong(c *gin.Context) {
+	userModel, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	songID, ok := uuidParam(c, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.favoriteService.StarSong(c.Request.Context(), userModel.ID, songID); err != nil {
+		respondFavoriteError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Song added to favorites"})
+}
+
+// UnstarSong removes a song from the favorites.
+func (h *favoriteHandler) UnstarSong(c *gin.Context) {
+	userModel, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	songID, ok := uuidParam(c, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.favoriteService.UnstarSong(c.Request.Context(), userModel.ID, songID); err != nil {
+		respondFavoriteError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Song removed from favorites"})
+}
+
+// StarAlbum marks an album as favorite.
+func (h *favoriteHandler) StarAlbum(c *gin.Context) {
+	userModel, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	albumID, ok := uuidParam(c, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.favoriteService.StarAlbum(c.Request.Context(), userModel.ID, albumID); err != nil {
+		respondFavoriteError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Album added to favorites"})
+}
+
+// UnstarAlbum removes an album from the favorites.
+func (h *favoriteHandler) UnstarAlbum(c *gin.Context) {
+	userModel, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	albumID, ok := uuidParam(c, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.favoriteService.UnstarAlbum(c.Request.Context(), userModel.ID, albumID); err != nil {
+		respondFavoriteError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Album removed from favorites"})
+}
+
+// StarArtist marks an artist as favorite.
+func (h *favoriteHandler) StarArtist(c *gin.Context) {
+	userModel, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	artistID, ok := uuidParam(c, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.favoriteService.StarArtist(c.Request.Context(), userModel.ID, artistID); err != nil {
+		respondFavoriteError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Artist added to favorites"})
+}
+
+// UnstarArtist removes an artist from the favorites.
+func (h *favoriteHandler) UnstarArtist(c *gin.Context) {
+	userModel, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	artistID, ok := uuidParam(c, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.favoriteService.UnstarArtist(c.Request.Context(), userModel.ID, artistID); err != nil {
+		respondFavoriteError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Artist removed from favorites"})
+}
+
+// respondFavoriteError maps favorite service errors to HTTP responses.
+func respondFavoriteError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrSongNotFound), errors.Is(err, services.ErrAlbumNotFound), errors.Is(err, services.ErrArtistNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		log.Printf("Handler: Favorite operation failed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
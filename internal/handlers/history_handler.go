@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/demispreviotto/cajitamusical/cajitamusical-backend/internal/dto/history"
+	"github.com/demispreviotto/cajitamusical/cajitamusical-backend/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+// historyHandler is a handler for play history operations.
+type historyHandler struct {
+	historyService services.HistoryServicer
+}
+
+// NewhistoryHandler creates a new instance of historyHandler.
+func NewhistoryHandler(historyService services.HistoryServicer) *historyHandler {
+	return &historyHandler{historyService: historyService}
+}
+
+// RecordPlayEvent stores a playback event (start, progress, completed, skipped).
+func (h *historyHandler) RecordPlayEvent(c *gin.Context) {
+	userModel, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	var input history.PlayEventInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	response, err := h.historyService.RecordEvent(c.Request.Context(), userModel.ID, input)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrSongNotFound), errors.Is(err, services.ErrPlayNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrPlayIDRequired):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrPlayAlreadyFinished):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			log.Printf("Handler: Failed to record play event for user %s: %v", userModel.ID.String(), err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// GetHistory returns the play history of the authenticated user.
+func (h *historyHandler) GetHistory(c *gin.Context) {
+	userModel, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	var query history.ListHistoryQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	response, err := h.historyService.GetHistory(c.Request.Context(), userModel.ID, query)
+	if err != nil {
+		log.Printf("Handler: Failed to get play history for user %s: %v", userModel.ID.String(), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve play history"})
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
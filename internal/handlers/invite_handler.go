@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/demispreviotto/cajitamusical/cajitamusical-backend/internal/dto/invite"
+	"github.com/demispreviotto/cajitamusical/cajitamusical-backend/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+// inviteHandler handles the invites admins create for registration.
+type inviteHandler struct {
+	inviteService services.InviteServicer
+}
+
+// NewinviteHandler creates a new instance of inviteHandler.
+func NewinviteHandler(inviteService services.InviteServicer) *inviteHandler {
+	return &inviteHandler{inviteService: inviteService}
+}
+
+// CreateInvite creates an invite and returns its code, shown only this once.
+func (h *inviteHandler) CreateInvite(c *gin.Context) {
+	userModel, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	var input invite.CreateInviteInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	response, err := h.inviteService.CreateInvite(c.Request.Context(), userModel.ID, input)
+	if err != nil {
+		respondInviteError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, response)
+}
+
+// ListInvites lists every invite.
+func (h *inviteHandler) ListInvites(c *gin.Context) {
+	invites, err := h.inviteService.ListInvites(c.Request.Context())
+	if err != nil {
+		respondInviteError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, invites)
+}
+
+// RevokeInvite deletes an invite.
+func (h *inviteHandler) RevokeInvite(c *gin.Context) {
+	inviteID, ok := uuidParam(c, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.inviteService.RevokeInvite(c.Request.Context(), inviteID); err != nil {
+		respondInviteError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// respondInviteError maps invite service errors to HTTP responses.
+func respondInviteError(c *gin.Context, err error) {
+	if errors.Is(err, services.ErrInviteNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	log.Printf("Handler: Invite operation failed: %v", err)
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/demispreviotto/cajitamusical/cajitamusical-backend/internal/dto/passkey"
+	"github.com/demispreviotto/cajitamusical/cajitamusical-backend/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+// passkeyHandler handles the passkeys of the authenticated user.
+type passkeyHandler struct {
+	passkeyService services.PasskeyServicer
+}
+
+// NewpasskeyHandler creates a new instance of passkeyHandler.
+func NewpasskeyHandler(passkeyService services.PasskeyServicer) *passkeyHandler {
+	return &passkeyHandler{passkeyService: passkeyService}
+}
+
+// BeginRegistration checks the password (and two-factor code) of the user and
+// returns the options for navigator.credentials.create().
+func (h *passkeyHandler) BeginRegistration(c *gin.Context) {
+	userModel, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	var input passkey.BeginRegistrationInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	options, err := h.passkeyService.BeginRegistration(c.Request.Context(), userModel, input)
+	if err != nil {
+		respondPasskeyError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, options)
+}
+
+// FinishRegistration stores the passkey created by the browser.
+func (h *passkeyHandler) FinishRegistration(c *gin.Context) {
+	userModel, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	var input passkey.FinishRegistrationInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	response, err := h.passkeyService.FinishRegistration(c.Request.Context(), userModel.ID, input)
+	if err != nil {
+		respondPasskeyError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, response)
+}
+
+// ListPasskeys lists the passkeys of the authenticated user.
+func (h *passkeyHandler) ListPasskeys(c *gin.Context) {
+	userModel, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	passkeys, err := h.passkeyService.ListPasskeys(c.Request.Context(), userModel.ID)
+	if err != nil {
+		respondPasskeyError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, passkeys)
+}
+
+// DeletePasskey removes a passkey of the authenticated user.
+func (h *passkeyHandler) DeletePasskey(c *gin.Context) {
+	userModel, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	passkeyID, ok := uuidParam(c, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.passkeyService.DeletePasskey(c.Request.Context(), userModel.ID, passkeyID); err != nil {
+		respondPasskeyError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// respondPasskeyError maps passkey service errors to HTTP responses.
+func respondPasskeyError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidPasskey):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrPasskeyExists):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrPasskeyNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrInvalidTwoFactorCode):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrInvalidPassword):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	default:
+		log.Printf("Handler: Passkey operation failed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
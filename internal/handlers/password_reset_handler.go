@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/demispreviotto/cajitamusical/cajitamusical-backend/internal/dto/user"
+	"github.com/demispreviotto/cajitamusical/cajitamusical-backend/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+// passwordResetHandler handles the forgotten password flow.
+type passwordResetHandler struct {
+	passwordResetService services.PasswordResetServicer
+}
+
+// NewpasswordResetHandler creates a new instance of passwordResetHandler.
+func NewpasswordResetHandler(passwordResetService services.PasswordResetServicer) *passwordResetHandler {
+	return &passwordResetHandler{passwordResetService: passwordResetService}
+}
+
+// ForgotPassword sends a reset link to the given email. The response is the
+// same whether the email is registered or not.
+func (h *passwordResetHandler) ForgotPassword(c *gin.Context) {
+	var input user.ForgotPasswordInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.passwordResetService.RequestReset(c.Request.Context(), input); err != nil {
+		log.Printf("Handler: Password reset request failed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{"message": "If the email is registered, a reset link has been sent"})
+}
+
+// ResetPassword sets a new password with the token of a reset link.
+func (h *passwordResetHandler) ResetPassword(c *gin.Context) {
+	var input user.ResetPasswordInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.passwordResetService.ResetPassword(c.Request.Context(), input); err != nil {
+		if errors.Is(err, services.ErrInvalidResetToken) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		log.Printf("Handler: Password reset failed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Password reset successful"})
+}
@@ -0,0 +1,209 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/demispreviotto/cajitamusical/cajitamusical-backend/internal/dto/playlist"
+	"github.com/demispreviotto/cajitamusical/cajitamusical-backend/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+// playlistHandler is a handler for playlist operations.
+type playlistHandler struct {
+	playlistService services.PlaylistServicer
+}
+
+// NewplaylistHandler creates a new instance of playlistHandler.
+func NewplaylistHandler(playlistService services.PlaylistServicer) *playlistHandler {
+	return &playlistHandler{playlistService: playlistService}
+}
+
+// ListPlaylists returns the playlists of the authenticated user.
+func (h *playlistHandler) ListPlaylists(c *gin.Context) {
+	userModel, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	response, err := h.playlistService.ListPlaylists(c.Request.Context(), userModel.ID)
+	if err != nil {
+		log.Printf("Handler: Failed to list playlists for user %s: %v", userModel.ID.String(), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve playlists"})
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// CreatePlaylist creates a new empty playlist.
+func (h *playlistHandler) CreatePlaylist(c *gin.Context) {
+	userModel, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	var input playlist.CreatePlaylistInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	response, err := h.playlistService.CreatePlaylist(c.Request.Context(), userModel.ID, input)
+	if err != nil {
+		respondPlaylistError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, response)
+}
+
+// GetPlaylist returns a playlist with its tracks.
+func (h *playlistHandler) GetPlaylist(c *gin.Context) {
+	userModel, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	playlistID, ok := uuidParam(c, "id")
+	if !ok {
+		return
+	}
+
+	response, err := h.playlistService.GetPlaylist(c.Request.Context(), userModel.ID, playlistID)
+	if err != nil {
+		respondPlaylistError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// UpdatePlaylist renames a playlist or changes its description.
+func (h *playlistHandler) UpdatePlaylist(c *gin.Context) {
+	userModel, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	playlistID, ok := uuidParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var input playlist.UpdatePlaylistInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	response, err := h.playlistService.UpdatePlaylist(c.Request.Context(), userModel.ID, playlistID, input)
+	if err != nil {
+		respondPlaylistError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// DeletePlaylist removes a playlist.
+func (h *playlistHandler) DeletePlaylist(c *gin.Context) {
+	userModel, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	playlistID, ok := uuidParam(c, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.playlistService.DeletePlaylist(c.Request.Context(), userModel.ID, playlistID); err != nil {
+		respondPlaylistError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Playlist deleted successfully"})
+}
+
+// AddSong appends a song to the end of a playlist.
+func (h *playlistHandler) AddSong(c *gin.Context) {
+	userModel, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	playlistID, ok := uuidParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var input playlist.AddSongInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	response, err := h.playlistService.AddSong(c.Request.Context(), userModel.ID, playlistID, input)
+	if err != nil {
+		respondPlaylistError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// RemoveItem removes an entry from a playlist.
+func (h *playlistHandler) RemoveItem(c *gin.Context) {
+	userModel, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	playlistID, ok := uuidParam(c, "id")
+	if !ok {
+		return
+	}
+	itemID, ok := uuidParam(c, "itemID")
+	if !ok {
+		return
+	}
+
+	response, err := h.playlistService.RemoveItem(c.Request.Context(), userModel.ID, playlistID, itemID)
+	if err != nil {
+		respondPlaylistError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// ReorderPlaylist changes the order of the entries of a playlist.
+func (h *playlistHandler) ReorderPlaylist(c *gin.Context) {
+	userModel, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	playlistID, ok := uuidParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var input playlist.ReorderPlaylistInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	response, err := h.playlistService.ReorderPlaylist(c.Request.Context(), userModel.ID, playlistID, input)
+	if err != nil {
+		respondPlaylistError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// respondPlaylistError maps playlist service errors to HTTP responses.
+func respondPlaylistError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrPlaylistNotFound),
+		errors.Is(err, services.ErrPlaylistItemNotFound),
+		errors.Is(err, services.ErrSongNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrInvalidPlaylistName),
+		errors.Is(err, services.ErrInvalidPlaylistOrder):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		log.Printf("Handler: Playlist operation failed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/demispreviotto/cajitamusical/cajitamusical-backend/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+// scanHandler is a handler for background library scans.
+type scanHandler struct {
+	scanService services.ScanServicer
+}
+
+// NewscanHandler creates a new instance of scanHandler.
+func NewscanHandler(scanService services.ScanServicer) *scanHandler {
+	return &scanHandler{scanService: scanService}
+}
+
+// StartScan starts a scan of the music directory and returns its job right away.
+func (h *scanHandler) StartScan(c *gin.Context) {
+	job, err := h.scanService.StartScan(c.Request.Context())
+	if err != nil {
+		if errors.Is(err, services.ErrScanInProgress) {
+			response := gin.H{"error": err.Error()}
+			if job != nil {
+				response["job"] = job
+			}
+			c.JSON(http.StatusConflict, response)
+			return
+		}
+		if errors.Is(err, services.ErrScanStopped) {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+			return
+		}
+		log.Printf("Handler: Failed to start music scan: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{
+		"message": "Music library scan started",
+		"job":     job,
+	})
+}
+
+// GetScan returns the progress of a scan job.
+func (h *scanHandler) GetScan(c *gin.Context) {
+	jobID, ok := uuidParam(c, "id")
+	if !ok {
+		return
+	}
+
+	job, err := h.scanService.GetScan(jobID)
+	if err != nil {
+		respondScanError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, job)
+}
+
+// CancelScan stops a running scan job.
+func (h *scanHandler) CancelScan(c *gin.Context) {
+	jobID, ok := uuidParam(c, "id")
+	if !ok {
+		return
+	}
+
+	job, err := h.scanService.CancelScan(jobID)
+	if err != nil {
+		respondScanError(c, err)
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{
+		"message": "Music library scan cancellation requested",
+		"job":     job,
+	})
+}
+
+// respondScanError maps scan service errors to HTTP responses.
+func respondScanError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrScanNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrScanFinished):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		log.Printf("Handler: Scan operation failed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
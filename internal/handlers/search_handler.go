@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/demispreviotto/cajitamusical/cajitamusical-backend/internal/dto/search"
+	"github.com/demispreviotto/cajitamusical/cajitamusical-backend/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+// searchHandler is a handler for library search.
+type searchHandler struct {
+	searchService services.SearchServicer
+}
+
+// NewsearchHandler creates a new instance of searchHandler.
+func NewsearchHandler(searchService services.SearchServicer) *searchHandler {
+	return &searchHandler{searchService: searchService}
+}
+
+// Search searches songs, albums and artists (GET /api/search?q=...&limit=...).
+func (h *searchHandler) Search(c *gin.Context) {
+	userModel, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	var query search.SearchQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	response, err := h.searchService.Search(c.Request.Context(), userModel.ID, query)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidSearchQuery) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		log.Printf("Handler: Search failed for %q: %v", query.Q, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Search failed"})
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
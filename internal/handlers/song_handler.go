@@ -1,12 +1,14 @@
 package handlers
 
 import (
+	"errors"
 	"log"
 	"net/http"
 	"os"
 	"path/filepath"
 	"strings"
 
+	"github.com/demispreviotto/cajitamusical/cajitamusical-backend/internal/dto/song"
 	"github.com/demispreviotto/cajitamusical/cajitamusical-backend/internal/services"
 	"github.com/gin-gonic/gin"
 )
@@ -21,15 +23,30 @@ func NewsongHandler(songService services.SongServicer) *songHandler {
 	return &songHandler{songService: songService}
 }
 
-// GetLibrary retrieves the song library.
+// GetLibrary retrieves a page of the song library, with optional sorting and filters.
 func (h *songHandler) GetLibrary(c *gin.Context) {
-	songs, err := h.songService.GetLibrary(c.Request.Context()) // Use request context
+	userModel, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	var query song.ListSongsQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	response, err := h.songService.GetLibrary(c.Request.Context(), userModel.ID, query) // Use request context
 	if err != nil {
+		if errors.Is(err, services.ErrInvalidYearRange) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		log.Printf("Handler: Failed to fetch song library: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"songs": songs})
+	c.JSON(http.StatusOK, response)
 }
 
 // ServeAudio serves an audio file by song ID.
@@ -39,21 +56,52 @@ func (h *songHandler) ServeAudio(c *gin.Context) {
 	filePath, err := h.songService.GetSongFilePath(songID)
 	if err != nil {
 		log.Printf("Handler: Error serving audio for song ID %s: %v", songID, err)
-		if strings.Contains(err.Error(), "required") || strings.Contains(err.Error(), "invalid") {
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		} else if strings.Contains(err.Error(), "not found") {
-			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
-		} else if strings.Contains(err.Error(), "path traversal") {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid file path"}) // Prevent leaking internal paths
-		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve audio file"})
-		}
+		respondSongFileError(c, err)
 		return
 	}
 
 	c.File(filePath)
 }
 
+// DownloadSong serves an audio file by song ID as an attachment.
+func (h *songHandler) DownloadSong(c *gin.Context) {
+	songID := c.Param("songID")
+
+	filePath, err := h.songService.GetSongFilePath(songID)
+	if err != nil {
+		log.Printf("Handler: Error downloading song ID %s: %v", songID, err)
+		respondSongFileError(c, err)
+		return
+	}
+
+	c.FileAttachment(filePath, filepath.Base(filePath))
+}
+
+// respondSongFileError maps GetSongFilePath errors to HTTP responses.
+func respondSongFileError(c *gin.Context, err error) {
+	if strings.Contains(err.Error(), "required") || strings.Contains(err.Error(), "invalid") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	} else if strings.Contains(err.Error(), "not found") {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	} else if strings.Contains(err.Error(), "path traversal") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid file path"}) // Prevent leaking internal paths
+	} else {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve audio file"})
+	}
+}
+
+// albumArtExtensions are the image files ServeAlbumArt may return: the
+// thumb.jpg written by the scanner and cover images kept next to the tracks.
+// Anything else under MUSIC_DIRECTORY, the audio files in particular, is only
+// served by the routes that check the stream and download permissions.
+var albumArtExtensions = map[string]bool{
+	".jpg":  true,
+	".jpeg": true,
+	".png":  true,
+	".webp": true,
+	".gif":  true,
+}
+
 // ServeAlbumArt serves an album art image.
 func (h *songHandler) ServeAlbumArt(c *gin.Context) {
 	// The filename parameter will now be the full relative path, e.g., "Artist/Album/thumb.jpg"
@@ -84,34 +132,26 @@ func (h *songHandler) ServeAlbumArt(c *gin.Context) {
 		return
 	}
 
-	if !strings.HasPrefix(absRequestedPath, absMusicDir) {
+	// The separator keeps a sibling directory such as /music2 out of /music
+	if !strings.HasPrefix(absRequestedPath, absMusicDir+string(os.PathSeparator)) {
 		log.Printf("Attempted path traversal in album art request: %s", imageRelativePath)
 		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
 		return
 	}
 
-	if _, err := os.Stat(fullExpectedPath); os.IsNotExist(err) {
+	if !albumArtExtensions[strings.ToLower(filepath.Ext(absRequestedPath))] {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Album art not found"})
+		return
+	}
+
+	if info, err := os.Stat(absRequestedPath); os.IsNotExist(err) || (err == nil && !info.Mode().IsRegular()) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Album art not found"})
 		return
 	} else if err != nil {
-		log.Printf("Error accessing album art file %s: %v", fullExpectedPath, err)
+		log.Printf("Error accessing album art file %s: %v", absRequestedPath, err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read album art"})
 		return
 	}
 
-	c.File(fullExpectedPath)
-}
-
-// ScanMusicLibrary triggers a manual scan of the music directory.
-func (h *songHandler) ScanMusicLibrary(c *gin.Context) {
-	result, err := h.songService.ScanMusicLibrary(c.Request.Context())
-	if err != nil {
-		log.Printf("Handler: Failed to trigger music scan: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
-	}
-	c.JSON(http.StatusOK, gin.H{
-		"message": "Music library scan initiated successfully",
-		"result":  result,
-	})
+	c.File(absRequestedPath)
 }
@@ -0,0 +1,23 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/demispreviotto/cajitamusical/cajitamusical-backend/internal/scheduler"
+	"github.com/gin-gonic/gin"
+)
+
+// taskHandler is a handler for the scheduled background tasks.
+type taskHandler struct {
+	scheduler *scheduler.Scheduler
+}
+
+// NewtaskHandler creates a new instance of taskHandler.
+func NewtaskHandler(scheduler *scheduler.Scheduler) *taskHandler {
+	return &taskHandler{scheduler: scheduler}
+}
+
+// ListTasks returns the schedule, last run and next run of every task.
+func (h *taskHandler) ListTasks(c *gin.Context) {
+	c.JSON(http.StatusOK, h.scheduler.Status())
+}
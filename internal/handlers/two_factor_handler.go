@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/demispreviotto/cajitamusical/cajitamusical-backend/internal/dto/auth"
+	"github.com/demispreviotto/cajitamusical/cajitamusical-backend/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+// twoFactorHandler handles TOTP two-factor authentication settings.
+type twoFactorHandler struct {
+	twoFactorService services.TwoFactorServicer
+}
+
+// NewtwoFactorHandler creates a new instance of twoFactorHandler.
+func NewtwoFactorHandler(twoFactorService services.TwoFactorServicer) *twoFactorHandler {
+	return &twoFactorHandler{twoFactorService: twoFactorService}
+}
+
+// GetStatus reports whether the authenticated user has two-factor authentication.
+func (h *twoFactorHandler) GetStatus(c *gin.Context) {
+	userModel, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	status, err := h.twoFactorService.GetStatus(c.Request.Context(), userModel.ID)
+	if err != nil {
+		respondTwoFactorError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, status)
+}
+
+// BeginEnrollment returns a new secret and its provisioning URI.
+func (h *twoFactorHandler) BeginEnrollment(c *gin.Context) {
+	userModel, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	setup, err := h.twoFactorService.BeginEnrollment(c.Request.Context(), userModel)
+	if err != nil {
+		respondTwoFactorError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, setup)
+}
+
+// ConfirmEnrollment enables two-factor authentication with a code from the
+// authenticator app and returns the recovery codes, shown only this once.
+func (h *twoFactorHandler) ConfirmEnrollment(c *gin.Context) {
+	userModel, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	var input auth.TwoFactorCodeInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	codes, err := h.twoFactorService.ConfirmEnrollment(c.Request.Context(), userModel.ID, input)
+	if err != nil {
+		respondTwoFactorError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, codes)
+}
+
+// RegenerateRecoveryCodes replaces the recovery codes of the authenticated user.
+func (h *twoFactorHandler) RegenerateRecoveryCodes(c *gin.Context) {
+	userModel, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	var input auth.TwoFactorCodeInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	codes, err := h.twoFactorService.RegenerateRecoveryCodes(c.Request.Context(), userModel.ID, input)
+	if err != nil {
+		respondTwoFactorError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, codes)
+}
+
+// Disable turns off two-factor authentication for the authenticated user.
+func (h *twoFactorHandler) Disable(c *gin.Context) {
+	userModel, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	var input auth.DisableTwoFactorInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.twoFactorService.Disable(c.Request.Context(), userModel.ID, input); err != nil {
+		respondTwoFactorError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// ResetUserTwoFactor turns off two-factor authentication for another user,
+// who lost both the authenticator and the recovery codes (admins only).
+func (h *twoFactorHandler) ResetUserTwoFactor(c *gin.Context) {
+	userID, ok := uuidParam(c, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.twoFactorService.Reset(c.Request.Context(), userID); err != nil {
+		respondTwoFactorError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// respondTwoFactorError maps two-factor service errors to HTTP responses.
+func respondTwoFactorError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrTwoFactorEnabled):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrTwoFactorNotEnabled), errors.Is(err, services.ErrNoTwoFactorPending):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrInvalidTwoFactorCode):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrInvalidPassword):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrUserNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		log.Printf("Handler: Two-factor operation failed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
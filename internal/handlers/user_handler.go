@@ -2,24 +2,26 @@ package handlers
 
 import (
 	"context"
+	"errors"
 	"log"
 	"net/http"
 
+	"github.com/demispreviotto/cajitamusical/cajitamusical-backend/internal/dto/invite"
 	"github.com/demispreviotto/cajitamusical/cajitamusical-backend/internal/dto/user"
 	"github.com/demispreviotto/cajitamusical/cajitamusical-backend/internal/middleware"
-	"github.com/demispreviotto/cajitamusical/cajitamusical-backend/internal/models"
 	"github.com/demispreviotto/cajitamusical/cajitamusical-backend/internal/services"
 	"github.com/gin-gonic/gin"
 )
 
 // userHandler es un handler para las operaciones de usuario.
 type userHandler struct {
-	userService services.UserServicer // Dependencia del servicio de usuario
+	userService  services.UserServicer   // Dependencia del servicio de usuario
+	cookiePolicy middleware.CookiePolicy // Para borrar la cookie de sesión al eliminar la cuenta
 }
 
 // NewuserHandler crea una nueva instancia de userHandler.
-func NewuserHandler(userService services.UserServicer) *userHandler {
-	return &userHandler{userService: userService}
+func NewuserHandler(userService services.UserServicer, cookiePolicy middleware.CookiePolicy) *userHandler {
+	return &userHandler{userService: userService, cookiePolicy: cookiePolicy}
 }
 
 // RegisterUser maneja el registro de nuevos usuarios.
@@ -34,38 +36,232 @@ func (h *userHandler) RegisterUser(c *gin.Context) {
 	userResponse, err := h.userService.RegisterUser(context.Background(), userInput)
 	if err != nil {
 		log.Printf("Handler: User registration failed for %s: %v", userInput.Username, err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondUserError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusCreated, userResponse)
 }
 
+// GetRegistrationMode indica a los clientes si el registro está abierto, solo
+// con invitación o cerrado.
+func (h *userHandler) GetRegistrationMode(c *gin.Context) {
+	c.JSON(http.StatusOK, invite.RegistrationResponse{Mode: h.userService.RegistrationMode()})
+}
+
 // GetAuthenticatedUser recupera la información del usuario autenticado.
 func (h *userHandler) GetAuthenticatedUser(c *gin.Context) {
-	userFromContext, exists := c.Get(middleware.UserContextKey)
+	userModel, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, services.MapUserToInfo(userModel))
+}
+
+// UpdateProfile actualiza el nombre y/o el email del usuario autenticado.
+func (h *userHandler) UpdateProfile(c *gin.Context) {
+	userModel, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	var input user.UpdateProfileInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userInfo, err := h.userService.UpdateProfile(c.Request.Context(), userModel.ID, input)
+	if err != nil {
+		respondUserError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, userInfo)
+}
+
+// ChangePassword cambia la contraseña del usuario autenticado, cierra sus otras
+// sesiones y revoca sus tokens de API.
+func (h *userHandler) ChangePassword(c *gin.Context) {
+	userModel, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	session, ok := currentSession(c)
+	if !ok {
+		return
+	}
+
+	var input user.ChangePasswordInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.userService.ChangePassword(c.Request.Context(), userModel.ID, session.SessionID, input); err != nil {
+		respondUserError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// DeleteAccount elimina la cuenta del usuario autenticado.
+func (h *userHandler) DeleteAccount(c *gin.Context) {
+	userModel, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	var input user.DeleteAccountInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.userService.DeleteAccount(c.Request.Context(), userModel.ID, input); err != nil {
+		respondUserError(c, err)
+		return
+	}
+	h.cookiePolicy.Clear(c)
+	c.Status(http.StatusNoContent)
+}
+
+// UpdateUserAccess cambia el rol y los permisos de un usuario (solo administradores).
+func (h *userHandler) UpdateUserAccess(c *gin.Context) {
+	userID, ok := uuidParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var input user.UpdateAccessInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userInfo, err := h.userService.UpdateUserAccess(c.Request.Context(), userID, input)
+	if err != nil {
+		respondUserError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, userInfo)
+}
+
+// ListUsers devuelve una página de usuarios (solo administradores).
+func (h *userHandler) ListUsers(c *gin.Context) {
+	var query user.ListUsersQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
-	if !exists {
-		log.Println("Handler: User info not found in context for /me endpoint (AuthMiddleware might not have run or set it)")
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized: User info not available"})
+	response, err := h.userService.ListUsers(c.Request.Context(), query)
+	if err != nil {
+		respondUserError(c, err)
 		return
 	}
+	c.JSON(http.StatusOK, response)
+}
 
-	userModel, ok := userFromContext.(*models.User)
+// GetUserSessions devuelve las sesiones vigentes de un usuario (solo administradores).
+func (h *userHandler) GetUserSessions(c *gin.Context) {
+	userID, ok := uuidParam(c, "id")
 	if !ok {
-		log.Printf("Handler: User info in context is of incorrect type for /me endpoint: got %T", userFromContext)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve user info (type mismatch)"})
 		return
 	}
 
-	safeUser := user.UserInfo{
-		ID:       userModel.ID,
-		Username: userModel.Username,
-		Email:    userModel.Email,
-		Name:     userModel.Name,
+	sessions, err := h.userService.GetUserSessions(c.Request.Context(), userID)
+	if err != nil {
+		respondUserError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, sessions)
+}
+
+// DisableUser desactiva una cuenta y cierra sus sesiones (solo administradores).
+func (h *userHandler) DisableUser(c *gin.Context) {
+	h.setUserDisabled(c, true)
+}
+
+// EnableUser reactiva una cuenta desactivada (solo administradores).
+func (h *userHandler) EnableUser(c *gin.Context) {
+	h.setUserDisabled(c, false)
+}
+
+func (h *userHandler) setUserDisabled(c *gin.Context, disabled bool) {
+	actor, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	userID, ok := uuidParam(c, "id")
+	if !ok {
+		return
 	}
 
-	c.JSON(http.StatusOK, safeUser)
+	if err := h.userService.SetUserDisabled(c.Request.Context(), actor.ID, userID, disabled); err != nil {
+		respondUserError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// ForcePasswordReset obliga a un usuario a cambiar su contraseña (solo administradores).
+func (h *userHandler) ForcePasswordReset(c *gin.Context) {
+	userID, ok := uuidParam(c, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.userService.ForcePasswordReset(c.Request.Context(), userID); err != nil {
+		respondUserError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// DeleteUser elimina una cuenta con sus sesiones y datos personales (solo administradores).
+func (h *userHandler) DeleteUser(c *gin.Context) {
+	actor, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	userID, ok := uuidParam(c, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.userService.DeleteUser(c.Request.Context(), actor.ID, userID); err != nil {
+		respondUserError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// respondUserError traduce los errores del servicio de usuarios a respuestas HTTP.
+func respondUserError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrUserNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrInvalidPermission):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrRegistrationClosed), errors.Is(err, services.ErrInviteRequired):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrInvalidInvite):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrInvalidPassword):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrPasswordRequired):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrEmailTaken):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrCannotModifySelf):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrLastAdmin):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		log.Printf("Handler: User operation failed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
 }
 
 // GetLibrary and ServeAudio would go here too if they are user-specific
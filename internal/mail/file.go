@@ -0,0 +1,34 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// fileMailer writes each message to its own .eml file, so development setups
+// and tests can read what would have been sent.
+type fileMailer struct {
+	dir string
+	seq atomic.Uint64 // Keeps file names unique within the same nanosecond
+}
+
+// NewFileMailer returns a Mailer writing messages to dir, creating it if needed.
+func NewFileMailer(dir string) (Mailer, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create mail directory: %w", err)
+	}
+	return &fileMailer{dir: dir}, nil
+}
+
+func (m *fileMailer) Send(ctx context.Context, msg Message) error {
+	name := fmt.Sprintf("%s-%d.eml", time.Now().UTC().Format("20060102T150405.000000000"), m.seq.Add(1))
+	content := string(formatMessage("", msg))
+	// Unix line endings are easier to read in a file
+	content = strings.ReplaceAll(content, "\r\n", "\n")
+	return os.WriteFile(filepath.Join(m.dir, name), []byte(content), 0o644)
+}
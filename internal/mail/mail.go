@@ -0,0 +1,78 @@
+// Package mail sends the emails of the application (password resets, address
+// verification...) through a Mailer chosen by configuration.
+package mail
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+)
+
+// Message is a plain text email.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer sends emails.
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// FromEnv builds the Mailer selected by MAIL_DRIVER:
+//
+//   - "smtp": SMTP_HOST, SMTP_PORT (587), SMTP_USERNAME, SMTP_PASSWORD and MAIL_FROM.
+//   - "file": writes each message to a file in MAIL_DIRECTORY.
+//   - "log": writes the messages to the log, for development.
+//
+// There is no default, so a deployment cannot end up logging the reset and
+// verification links by mistake.
+func FromEnv() (Mailer, error) {
+	switch driver := os.Getenv("MAIL_DRIVER"); driver {
+	case "":
+		return nil, fmt.Errorf("MAIL_DRIVER must be set to smtp, file or log (development only)")
+	case "log":
+		return NewLogMailer(), nil
+	case "file":
+		dir := os.Getenv("MAIL_DIRECTORY")
+		if dir == "" {
+			return nil, fmt.Errorf("MAIL_DIRECTORY must be set with MAIL_DRIVER=file")
+		}
+		return NewFileMailer(dir)
+	case "smtp":
+		port := 587
+		if portStr := os.Getenv("SMTP_PORT"); portStr != "" {
+			p, err := strconv.Atoi(portStr)
+			if err != nil {
+				return nil, fmt.Errorf("invalid SMTP_PORT %q: %w", portStr, err)
+			}
+			port = p
+		}
+		return NewSMTPMailer(SMTPConfig{
+			Host:     os.Getenv("SMTP_HOST"),
+			Port:     port,
+			Username: os.Getenv("SMTP_USERNAME"),
+			Password: os.Getenv("SMTP_PASSWORD"),
+			From:     os.Getenv("MAIL_FROM"),
+		})
+	default:
+		return nil, fmt.Errorf("unknown MAIL_DRIVER %q", driver)
+	}
+}
+
+// logMailer writes messages to the standard logger.
+type logMailer struct{}
+
+// NewLogMailer returns a Mailer that only logs the messages. Links in them
+// (reset, verification) can be copied from the log during development.
+func NewLogMailer() Mailer {
+	return logMailer{}
+}
+
+func (logMailer) Send(ctx context.Context, msg Message) error {
+	log.Printf("Mail: To: %s\nSubject: %s\n\n%s", msg.To, msg.Subject, msg.Body)
+	return nil
+}
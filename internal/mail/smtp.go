@@ -0,0 +1,77 @@
+package mail
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SMTPConfig holds the settings of an SMTP server.
+type SMTPConfig struct {
+	Host     string
+	Port     int
+	Username string // Empty for servers without authentication
+	Password string
+	From     string
+}
+
+// smtpMailer sends messages through an SMTP server, using STARTTLS when the
+// server offers it.
+type smtpMailer struct {
+	config SMTPConfig
+}
+
+// NewSMTPMailer returns a Mailer sending through the server of config.
+func NewSMTPMailer(config SMTPConfig) (Mailer, error) {
+	if config.Host == "" || config.From == "" {
+		return nil, errors.New("SMTP_HOST and MAIL_FROM must be set with MAIL_DRIVER=smtp")
+	}
+	return &smtpMailer{config: config}, nil
+}
+
+func (m *smtpMailer) Send(ctx context.Context, msg Message) error {
+	var auth smtp.Auth
+	if m.config.Username != "" {
+		auth = smtp.PlainAuth("", m.config.Username, m.config.Password, m.config.Host)
+	}
+	addr := net.JoinHostPort(m.config.Host, strconv.Itoa(m.config.Port))
+
+	// smtp.SendMail has no context; run it aside so a slow server does not
+	// outlive the caller's deadline
+	done := make(chan error, 1)
+	go func() {
+		done <- smtp.SendMail(addr, auth, m.config.From, []string{msg.To}, formatMessage(m.config.From, msg))
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			return fmt.Errorf("failed to send mail to %s: %w", msg.To, err)
+		}
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// formatMessage builds an RFC 5322 message with a UTF-8 plain text body.
+func formatMessage(from string, msg Message) []byte {
+	var buf bytes.Buffer
+	if from != "" {
+		fmt.Fprintf(&buf, "From: %s\r\n", from)
+	}
+	fmt.Fprintf(&buf, "To: %s\r\n", msg.To)
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	buf.WriteString("Content-Transfer-Encoding: 8bit\r\n\r\n")
+	buf.WriteString(strings.ReplaceAll(strings.ReplaceAll(msg.Body, "\r\n", "\n"), "\n", "\r\n"))
+	return buf.Bytes()
+}
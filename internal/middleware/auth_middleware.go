@@ -3,8 +3,11 @@ package middleware
 import (
 	"log"
 	"net/http"
+	"strings"
+	"time"
 
 	"github.com/demispreviotto/cajitamusical/cajitamusical-backend/internal/db" // Import the db package for interfaces
+	"github.com/demispreviotto/cajitamusical/cajitamusical-backend/internal/models"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 )
@@ -12,26 +15,55 @@ import (
 // UserContextKey is the key used to store user information in Gin's context.
 const UserContextKey = "user"
 
+// SessionContextKey is the key used to store the current *models.Session in Gin's context.
+const SessionContextKey = "session"
+
+// APITokenContextKey is the key used to store the *models.APIToken of requests
+// authenticated with "Authorization: Bearer" instead of a session cookie.
+const APITokenContextKey = "api_token"
+
+// passwordResetRoutes are the only routes available to users who must change
+// their password.
+var passwordResetRoutes = map[string]bool{
+	http.MethodGet + " /api/me":           true,
+	http.MethodPost + " /api/me/password": true,
+	http.MethodPost + " /api/logout":      true,
+}
+
 // AuthMiddleware struct holds the database dependencies for the middleware.
 type AuthMiddleware struct {
-	sessionDB db.SessionDBer // Dependency on the SessionDBer interface
-	userDB    db.UserDBer    // Dependency on the UserDBer interface
+	sessionDB     db.SessionDBer  // Dependency on the SessionDBer interface
+	userDB        db.UserDBer     // Dependency on the UserDBer interface
+	apiTokenDB    db.APITokenDBer // Dependency on the APITokenDBer interface
+	sessionPolicy models.SessionPolicy
+	cookiePolicy  CookiePolicy
 }
 
 // NewAuthMiddleware creates a new instance of AuthMiddleware.
-// It takes concrete implementations of SessionDBer and UserDBer.
-func NewAuthMiddleware(sessionDB db.SessionDBer, userDB db.UserDBer) *AuthMiddleware {
+// It takes concrete implementations of SessionDBer, UserDBer and APITokenDBer,
+// and the policies used to renew sessions as they are used and to write the cookie.
+func NewAuthMiddleware(sessionDB db.SessionDBer, userDB db.UserDBer, apiTokenDB db.APITokenDBer, sessionPolicy models.SessionPolicy, cookiePolicy CookiePolicy) *AuthMiddleware {
 	return &AuthMiddleware{
-		sessionDB: sessionDB,
-		userDB:    userDB,
+		sessionDB:     sessionDB,
+		userDB:        userDB,
+		apiTokenDB:    apiTokenDB,
+		sessionPolicy: sessionPolicy,
+		cookiePolicy:  cookiePolicy,
 	}
 }
 
 // Handler is the actual Gin middleware function.
 // It's a method of AuthMiddleware so it can access its dependencies.
+// Requests with an Authorization header are authenticated with an API token,
+// the rest with the session cookie.
 func (m *AuthMiddleware) Handler() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		sessionIDStr, err := c.Cookie("session_id")
+		if header := c.GetHeader("Authorization"); header != "" {
+			m.authenticateToken(c, header)
+			return
+		}
+
+		sessionIDStr, err := m.cookiePolicy.Read(c)
 		if err != nil || sessionIDStr == "" {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized: No session cookie"})
 			return
@@ -51,7 +83,13 @@ func (m *AuthMiddleware) Handler() gin.HandlerFunc {
 		if err != nil {
 			log.Printf("Session validation failed for ID %s: %v", sessionID.String(), err)
 			// You might want to clear the cookie here if the session is invalid/expired
-			c.SetCookie("session_id", "", -1, "/", "localhost", false, true)
+			m.cookiePolicy.Clear(c)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized: Invalid or expired session"})
+			return
+		}
+		now := time.Now()
+		if m.sessionPolicy.Expired(session, now) {
+			m.cookiePolicy.Clear(c)
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized: Invalid or expired session"})
 			return
 		}
@@ -64,10 +102,114 @@ func (m *AuthMiddleware) Handler() gin.HandlerFunc {
 			return
 		}
 
+		if user.DisabledAt != nil {
+			m.cookiePolicy.Clear(c)
+		}
+		if !checkUserAccess(c, user) {
+			return
+		}
+
+		m.renewSession(c, session, now)
+
 		// Store user data in Gin's context
 		c.Set(UserContextKey, user)
+		c.Set(SessionContextKey, session)
 
 		// Continue with the next handler in the chain
 		c.Next()
 	}
 }
+
+// authenticateToken authenticates the request with the API token of an
+// "Authorization: Bearer" header. Tokens only reach the routes their scopes
+// allow: read for GET requests, write for the rest.
+func (m *AuthMiddleware) authenticateToken(c *gin.Context, header string) {
+	secret, found := strings.CutPrefix(header, "Bearer ")
+	if !found || !strings.HasPrefix(secret, models.APITokenPrefix) {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized: Invalid authorization header"})
+		return
+	}
+
+	reqCtx := c.Request.Context()
+	now := time.Now()
+
+	apiToken, err := m.apiTokenDB.GetAPITokenByHash(reqCtx, models.HashToken(secret))
+	if err != nil || apiToken.Expired(now) {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized: Invalid or expired API token"})
+		return
+	}
+
+	user, err := m.userDB.GetUserByID(reqCtx, apiToken.UserID)
+	if err != nil {
+		log.Printf("Failed to retrieve user %s for API token %s: %v", apiToken.UserID.String(), apiToken.ID.String(), err)
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Internal server error: User data not found"})
+		return
+	}
+	if !checkUserAccess(c, user) {
+		return
+	}
+
+	scope := models.APITokenScopeWrite
+	if c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead {
+		scope = models.APITokenScopeRead
+	}
+	if !apiToken.HasScope(scope) {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Forbidden: API token lacks scope " + scope})
+		return
+	}
+
+	if apiToken.LastUsedAt == nil || now.Sub(*apiToken.LastUsedAt) >= m.sessionPolicy.LastSeenInterval {
+		if err := m.apiTokenDB.TouchAPIToken(reqCtx, apiToken.ID, now); err != nil {
+			log.Printf("Failed to update last use of API token %s: %v", apiToken.ID.String(), err)
+		} else {
+			apiToken.LastUsedAt = &now
+		}
+	}
+
+	c.Set(UserContextKey, user)
+	c.Set(APITokenContextKey, apiToken)
+	c.Next()
+}
+
+// checkUserAccess rejects disabled users, and users who must change their
+// password on every route but the ones needed to do so.
+func checkUserAccess(c *gin.Context, user *models.User) bool {
+	// Accounts disabled by an admin lose access even if a session survived
+	if user.DisabledAt != nil {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Forbidden: Account disabled"})
+		return false
+	}
+
+	// Users whose password was reset by an admin must change it first
+	if user.PasswordResetRequired && !passwordResetRoutes[c.Request.Method+" "+c.FullPath()] {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Forbidden: Password change required"})
+		return false
+	}
+	return true
+}
+
+// renewSession slides the expiry of session when it is close to expiring,
+// refreshing the cookie, and records the activity in LastSeenAt. Both are
+// written at most once per LastSeenInterval unless a renewal is due.
+// Failures are logged: the request itself is still authenticated.
+func (m *AuthMiddleware) renewSession(c *gin.Context, session *models.Session, now time.Time) {
+	renew := m.sessionPolicy.ShouldRenew(session, now)
+	if !renew && !m.sessionPolicy.ShouldTouch(session, now) {
+		return
+	}
+
+	expiresAt := session.ExpiresAt
+	if renew {
+		expiresAt = m.sessionPolicy.ExpiresAt(session.CreatedAt, now)
+	}
+	if err := m.sessionDB.TouchSession(c.Request.Context(), session.SessionID, now, expiresAt); err != nil {
+		log.Printf("Failed to update activity of session %s: %v", session.SessionID.String(), err)
+		return
+	}
+	session.LastSeenAt = &now
+	session.ExpiresAt = expiresAt
+
+	if renew {
+		m.cookiePolicy.Set(c, session.SessionID.String(), expiresAt, session.Persistent)
+	}
+}
@@ -0,0 +1,88 @@
+package middleware
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/demispreviotto/cajitamusical/cajitamusical-backend/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+// RequireAdmin only lets users with the admin role through.
+// It must run after AuthMiddleware.Handler.
+func RequireAdmin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, ok := authenticatedUser(c)
+		if !ok {
+			return
+		}
+		if !user.IsAdmin() {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Forbidden: Admin role required"})
+			return
+		}
+		if !tokenHasScope(c, models.APITokenScopeAdmin) {
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequirePermission only lets users with permission through.
+// It must run after AuthMiddleware.Handler.
+func RequirePermission(permission string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, ok := authenticatedUser(c)
+		if !ok {
+			return
+		}
+		if !user.HasPermission(permission) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Forbidden: Missing permission " + permission})
+			return
+		}
+		if !tokenHasScope(c, permission) {
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireSession rejects requests authenticated with an API token, for
+// account management routes that need the user to be logged in.
+// It must run after AuthMiddleware.Handler.
+func RequireSession() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, usingToken := c.Get(APITokenContextKey); usingToken {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Forbidden: Not available with API tokens"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// tokenHasScope aborts the request when it was authenticated with an API token
+// without scope. Requests authenticated with a session always pass.
+func tokenHasScope(c *gin.Context, scope string) bool {
+	tokenFromContext, usingToken := c.Get(APITokenContextKey)
+	if !usingToken {
+		return true
+	}
+	apiToken, ok := tokenFromContext.(*models.APIToken)
+	if !ok || !apiToken.HasScope(scope) {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Forbidden: API token lacks scope " + scope})
+		return false
+	}
+	return true
+}
+
+// authenticatedUser returns the user stored by AuthMiddleware, aborting the
+// request when it is missing.
+func authenticatedUser(c *gin.Context) (*models.User, bool) {
+	userFromContext, exists := c.Get(UserContextKey)
+	user, ok := userFromContext.(*models.User)
+	if !exists || !ok {
+		log.Printf("Authorization check without authenticated user for %s", c.FullPath())
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized: User info not available"})
+		return nil, false
+	}
+	return user, true
+}
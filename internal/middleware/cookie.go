@@ -0,0 +1,92 @@
+package middleware
+
+import (
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CookiePolicy describes the session cookie. Every place that sets, reads or
+// clears the cookie goes through it so the attributes always match; a
+// browser only clears a cookie whose name, domain and path are the same.
+type CookiePolicy struct {
+	Name     string
+	Path     string
+	Domain   string // Empty for a host-only cookie
+	Secure   bool
+	SameSite http.SameSite
+}
+
+// CookiePolicyFromEnv builds the cookie policy from SESSION_COOKIE_NAME,
+// SESSION_COOKIE_PATH, SESSION_COOKIE_DOMAIN, SESSION_COOKIE_SECURE and
+// SESSION_COOKIE_SAMESITE (lax, strict or none).
+func CookiePolicyFromEnv() CookiePolicy {
+	policy := CookiePolicy{
+		Name:     "session_id",
+		Path:     "/",
+		Domain:   os.Getenv("SESSION_COOKIE_DOMAIN"),
+		Secure:   os.Getenv("SESSION_COOKIE_SECURE") == "true",
+		SameSite: http.SameSiteLaxMode,
+	}
+	if name := os.Getenv("SESSION_COOKIE_NAME"); name != "" {
+		policy.Name = name
+	}
+	if path := os.Getenv("SESSION_COOKIE_PATH"); path != "" {
+		policy.Path = path
+	}
+
+	switch sameSite := strings.ToLower(os.Getenv("SESSION_COOKIE_SAMESITE")); sameSite {
+	case "", "lax":
+	case "strict":
+		policy.SameSite = http.SameSiteStrictMode
+	case "none":
+		policy.SameSite = http.SameSiteNoneMode
+		if !policy.Secure {
+			// Browsers reject SameSite=None cookies without Secure
+			log.Println("SESSION_COOKIE_SAMESITE=none requires a secure cookie, enabling SESSION_COOKIE_SECURE")
+			policy.Secure = true
+		}
+	default:
+		log.Printf("Unknown SESSION_COOKIE_SAMESITE %q, using lax", sameSite)
+	}
+	return policy
+}
+
+// Read returns the value of the session cookie of the request.
+func (p CookiePolicy) Read(c *gin.Context) (string, error) {
+	return c.Cookie(p.Name)
+}
+
+// Set writes the session cookie. A persistent cookie lasts until expiresAt;
+// otherwise it is a browser-session cookie, dropped when the browser closes.
+func (p CookiePolicy) Set(c *gin.Context, value string, expiresAt time.Time, persistent bool) {
+	cookie := p.cookie(value)
+	if persistent {
+		cookie.Expires = expiresAt
+		cookie.MaxAge = int(time.Until(expiresAt).Seconds())
+	}
+	http.SetCookie(c.Writer, cookie)
+}
+
+// Clear removes the session cookie from the browser.
+func (p CookiePolicy) Clear(c *gin.Context) {
+	cookie := p.cookie("")
+	cookie.MaxAge = -1
+	http.SetCookie(c.Writer, cookie)
+}
+
+func (p CookiePolicy) cookie(value string) *http.Cookie {
+	return &http.Cookie{
+		Name:     p.Name,
+		Value:    value,
+		Path:     p.Path,
+		Domain:   p.Domain,
+		Secure:   p.Secure,
+		HttpOnly: true,
+		SameSite: p.SameSite,
+	}
+}
@@ -0,0 +1,110 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestCookiePolicyFromEnv(t *testing.T) {
+	tests := []struct {
+		name string
+		env  map[string]string
+		want CookiePolicy
+	}{
+		{
+			name: "defaults",
+			want: CookiePolicy{Name: "session_id", Path: "/", SameSite: http.SameSiteLaxMode},
+		},
+		{
+			name: "custom attributes",
+			env: map[string]string{
+				"SESSION_COOKIE_NAME":     "sid",
+				"SESSION_COOKIE_PATH":     "/api",
+				"SESSION_COOKIE_DOMAIN":   "example.com",
+				"SESSION_COOKIE_SECURE":   "true",
+				"SESSION_COOKIE_SAMESITE": "Strict",
+			},
+			want: CookiePolicy{Name: "sid", Path: "/api", Domain: "example.com", Secure: true, SameSite: http.SameSiteStrictMode},
+		},
+		{
+			name: "SameSite=None forces Secure",
+			env:  map[string]string{"SESSION_COOKIE_SAMESITE": "none"},
+			want: CookiePolicy{Name: "session_id", Path: "/", Secure: true, SameSite: http.SameSiteNoneMode},
+		},
+		{
+			name: "unknown SameSite falls back to lax",
+			env:  map[string]string{"SESSION_COOKIE_SAMESITE": "sometimes"},
+			want: CookiePolicy{Name: "session_id", Path: "/", SameSite: http.SameSiteLaxMode},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, key := range []string{"SESSION_COOKIE_NAME", "SESSION_COOKIE_PATH", "SESSION_COOKIE_DOMAIN", "SESSION_COOKIE_SECURE", "SESSION_COOKIE_SAMESITE"} {
+				t.Setenv(key, tt.env[key])
+			}
+			if got := CookiePolicyFromEnv(); got != tt.want {
+				t.Errorf("CookiePolicyFromEnv() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCookiePolicySetAndClear(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	policy := CookiePolicy{Name: "sid", Path: "/api", Domain: "example.com", Secure: true, SameSite: http.SameSiteStrictMode}
+	expiresAt := time.Now().Add(time.Hour)
+
+	tests := []struct {
+		name       string
+		write      func(c *gin.Context)
+		wantValue  string
+		wantMaxAge func(maxAge int) bool
+	}{
+		{
+			name:       "browser-session cookie",
+			write:      func(c *gin.Context) { policy.Set(c, "token", expiresAt, false) },
+			wantValue:  "token",
+			wantMaxAge: func(maxAge int) bool { return maxAge == 0 },
+		},
+		{
+			name:       "persistent cookie",
+			write:      func(c *gin.Context) { policy.Set(c, "token", expiresAt, true) },
+			wantValue:  "token",
+			wantMaxAge: func(maxAge int) bool { return maxAge > 3500 && maxAge <= 3600 },
+		},
+		{
+			name:       "clear",
+			write:      policy.Clear,
+			wantValue:  "",
+			wantMaxAge: func(maxAge int) bool { return maxAge < 0 },
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(recorder)
+			tt.write(c)
+
+			cookies := recorder.Result().Cookies()
+			if len(cookies) != 1 {
+				t.Fatalf("got %d cookies, want 1", len(cookies))
+			}
+			cookie := cookies[0]
+			// Every cookie carries the same attributes, or the browser would not replace it
+			if cookie.Name != policy.Name || cookie.Path != policy.Path || cookie.Domain != policy.Domain ||
+				cookie.Secure != policy.Secure || cookie.SameSite != policy.SameSite || !cookie.HttpOnly {
+				t.Errorf("cookie attributes = %+v, want those of %+v and HttpOnly", cookie, policy)
+			}
+			if cookie.Value != tt.wantValue {
+				t.Errorf("Value = %q, want %q", cookie.Value, tt.wantValue)
+			}
+			if !tt.wantMaxAge(cookie.MaxAge) {
+				t.Errorf("MaxAge = %d, unexpected", cookie.MaxAge)
+			}
+		})
+	}
+}
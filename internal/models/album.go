@@ -0,0 +1,20 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Album represents a release by an artist. Albums are created by the library
+// scan and identified by their title and album artist. Aggregates such as the
+// year or track count are computed from their songs.
+type Album struct {
+	ID        uuid.UUID `json:"id" gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	Title     string    `json:"title" gorm:"size:255;not null;uniqueIndex:idx_albums_artist_title"`
+	ArtistID  uuid.UUID `json:"artist_id" gorm:"type:uuid;not null;uniqueIndex:idx_albums_artist_title"`
+	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+
+	Artist Artist `json:"-" gorm:"foreignKey:ArtistID;references:ID;constraint:OnDelete:CASCADE"`
+}
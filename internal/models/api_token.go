@@ -0,0 +1,55 @@
+package models
+
+import (
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Scopes an API token can be limited to. Besides these, a scope can be any
+// permission (stream, download, manage_library), which the token then needs
+// for the routes requiring that permission.
+const (
+	APITokenScopeRead  = "read"  // GET requests
+	APITokenScopeWrite = "write" // Requests changing data
+	APITokenScopeAdmin = "admin" // Admin routes, for admins only
+)
+
+// AllAPITokenScopes lists every scope.
+var AllAPITokenScopes = append([]string{APITokenScopeRead, APITokenScopeWrite, APITokenScopeAdmin}, AllPermissions...)
+
+// APITokenPrefix starts every API token secret, so leaked tokens are easy to
+// recognize.
+const APITokenPrefix = "cm_"
+
+// APIToken is a personal token a user creates for scripts and players. Only
+// the SHA-256 of the secret is stored; the secret is shown once on creation.
+type APIToken struct {
+	ID         uuid.UUID  `json:"id" gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	UserID     uuid.UUID  `json:"user_id" gorm:"type:uuid;not null;index"`
+	Name       string     `json:"name" gorm:"type:varchar(100);not null"`
+	TokenHash  string     `json:"-" gorm:"type:varchar(64);uniqueIndex;not null"`
+	Hint       string     `json:"hint" gorm:"type:varchar(20);not null"` // Start of the secret, to tell tokens apart
+	Scopes     []string   `json:"scopes" gorm:"serializer:json;type:jsonb;not null"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"` // nil: never expires
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at" gorm:"autoCreateTime"`
+
+	User User `json:"-" gorm:"foreignKey:UserID;references:ID;constraint:OnDelete:CASCADE"`
+}
+
+// IsValidAPITokenScope reports whether scope is a known scope.
+func IsValidAPITokenScope(scope string) bool {
+	return slices.Contains(AllAPITokenScopes, scope)
+}
+
+// HasScope reports whether the token was granted scope.
+func (t *APIToken) HasScope(scope string) bool {
+	return slices.Contains(t.Scopes, scope)
+}
+
+// Expired reports whether the token is past its expiry at now.
+func (t *APIToken) Expired(now time.Time) bool {
+	return t.ExpiresAt != nil && !now.Before(*t.ExpiresAt)
+}
@@ -14,5 +14,7 @@ type Authentication struct {
 	CreatedAt    time.Time  `json:"created_at" db:"created_at" gorm:"not null"`
 	UpdatedAt    time.Time  `json:"updated_at" db:"updated_at" gorm:"not null"`
 
+	LastFailedLogin *time.Time `json:"last_failed_login" db:"last_failed_login"`
+
 	User User `gorm:"foreignKey:UserID;references:ID"`
 }
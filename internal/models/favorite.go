@@ -0,0 +1,37 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// FavoriteSong marks a song as liked by a user.
+type FavoriteSong struct {
+	UserID    uuid.UUID `json:"user_id" gorm:"type:uuid;primaryKey"`
+	SongID    uuid.UUID `json:"song_id" gorm:"type:uuid;primaryKey;index"`
+	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
+
+	User User `json:"-" gorm:"foreignKey:UserID;references:ID;constraint:OnDelete:CASCADE"`
+	Song Song `json:"-" gorm:"foreignKey:SongID;references:ID;constraint:OnDelete:CASCADE"`
+}
+
+// FavoriteAlbum marks an album as liked by a user.
+type FavoriteAlbum struct {
+	UserID    uuid.UUID `json:"user_id" gorm:"type:uuid;primaryKey"`
+	AlbumID   uuid.UUID `json:"album_id" gorm:"type:uuid;primaryKey;index"`
+	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
+
+	User  User  `json:"-" gorm:"foreignKey:UserID;references:ID;constraint:OnDelete:CASCADE"`
+	Album Album `json:"-" gorm:"foreignKey:AlbumID;references:ID;constraint:OnDelete:CASCADE"`
+}
+
+// FavoriteArtist marks an artist as liked by a user.
+type FavoriteArtist struct {
+	UserID    uuid.UUID `json:"user_id" gorm:"type:uuid;primaryKey"`
+	ArtistID  uuid.UUID `json:"artist_id" gorm:"type:uuid;primaryKey;index"`
+	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
+
+	User   User   `json:"-" gorm:"foreignKey:UserID;references:ID;constraint:OnDelete:CASCADE"`
+	Artist Artist `json:"-" gorm:"foreignKey:ArtistID;references:ID;constraint:OnDelete:CASCADE"`
+}
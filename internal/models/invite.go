@@ -0,0 +1,38 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Registration modes, set with REGISTRATION_MODE.
+const (
+	RegistrationOpen       = "open"        // Anyone can register
+	RegistrationInviteOnly = "invite-only" // Registering needs an invite code
+	RegistrationClosed     = "closed"      // Only the first user can register
+)
+
+// Invite is a code admins hand out to let people register. Only the hash of
+// the code is stored.
+type Invite struct {
+	ID        uuid.UUID  `json:"id" gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	CodeHash  string     `json:"-" gorm:"type:varchar(64);uniqueIndex;not null"`
+	Hint      string     `json:"hint" gorm:"type:varchar(20);not null"` // Start of the code, to tell invites apart
+	Role      string     `json:"role" gorm:"type:varchar(20);not null;default:user"`
+	MaxUses   int        `json:"max_uses" gorm:"not null"` // 0: unlimited
+	Uses      int        `json:"uses" gorm:"not null;default:0"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"` // nil: never expires
+	CreatedBy *uuid.UUID `json:"created_by,omitempty" gorm:"type:uuid"`
+	CreatedAt time.Time  `json:"created_at" gorm:"autoCreateTime"`
+
+	Creator *User `json:"-" gorm:"foreignKey:CreatedBy;references:ID;constraint:OnDelete:SET NULL"`
+}
+
+// Usable reports whether the invite can still be redeemed at now.
+func (i *Invite) Usable(now time.Time) bool {
+	if i.ExpiresAt != nil && !now.Before(*i.ExpiresAt) {
+		return false
+	}
+	return i.MaxUses == 0 || i.Uses < i.MaxUses
+}
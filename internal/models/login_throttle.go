@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+// LoginThrottle counts recent failed logins for a username or a client IP.
+// Key is "user:<username>" or "ip:<address>", so both share one table.
+type LoginThrottle struct {
+	Key           string    `json:"key" gorm:"type:varchar(300);primaryKey"`
+	Failures      int       `json:"failures" gorm:"not null;default:0"`
+	LastFailureAt time.Time `json:"last_failure_at" gorm:"not null;index"`
+}
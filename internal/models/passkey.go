@@ -0,0 +1,44 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// WebAuthn ceremonies a challenge is issued for.
+const (
+	WebAuthnRegistration = "registration"
+	WebAuthnLogin        = "login"
+)
+
+// Passkey is a WebAuthn credential a user registered to log in without a
+// password. Only its public key is stored.
+type Passkey struct {
+	ID             uuid.UUID  `json:"id" gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	UserID         uuid.UUID  `json:"user_id" gorm:"type:uuid;not null;index"`
+	Name           string     `json:"name" gorm:"type:varchar(100);not null"`
+	CredentialID   []byte     `json:"-" gorm:"type:bytea;uniqueIndex;not null"`
+	PublicKey      []byte     `json:"-" gorm:"type:bytea;not null"` // COSE_Key
+	Algorithm      int64      `json:"algorithm" gorm:"not null"`
+	SignCount      int64      `json:"-" gorm:"not null;default:0"` // Signature counter of the authenticator, to detect clones
+	BackupEligible bool       `json:"backup_eligible" gorm:"not null;default:false"`
+	Transports     []string   `json:"transports" gorm:"serializer:json;type:jsonb"`
+	LastUsedAt     *time.Time `json:"last_used_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at" gorm:"autoCreateTime"`
+
+	User User `json:"-" gorm:"foreignKey:UserID;references:ID;constraint:OnDelete:CASCADE"`
+}
+
+// WebAuthnChallenge is the random challenge of a registration or login
+// ceremony in progress. It is used once: finishing the ceremony deletes it.
+type WebAuthnChallenge struct {
+	ID        uuid.UUID  `json:"id" gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	UserID    *uuid.UUID `json:"user_id,omitempty" gorm:"type:uuid;index"` // nil for a login with any passkey
+	Ceremony  string     `json:"ceremony" gorm:"type:varchar(20);not null"`
+	Challenge []byte     `json:"-" gorm:"type:bytea;not null"`
+	ExpiresAt time.Time  `json:"expires_at" gorm:"not null;index"`
+	CreatedAt time.Time  `json:"created_at" gorm:"autoCreateTime"`
+
+	User *User `json:"-" gorm:"foreignKey:UserID;references:ID;constraint:OnDelete:CASCADE"`
+}
@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Status values of a PlayHistory entry.
+const (
+	PlayStatusStarted   = "started"   // Playback began and has not finished yet
+	PlayStatusCompleted = "completed" // The song was listened to the end
+	PlayStatusSkipped   = "skipped"   // The user moved on before the end
+)
+
+// PlayHistory records a single playback of a song by a user. It is created on
+// the "start" event and updated by later progress/completed/skipped events.
+type PlayHistory struct {
+	ID              uuid.UUID  `json:"id" gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	UserID          uuid.UUID  `json:"user_id" gorm:"type:uuid;not null;index:idx_play_histories_user_song;index:idx_play_histories_user_started"`
+	SongID          uuid.UUID  `json:"song_id" gorm:"type:uuid;not null;index:idx_play_histories_user_song"`
+	Status          string     `json:"status" gorm:"size:20;not null"`
+	PositionSeconds int        `json:"position_seconds" gorm:"not null;default:0"` // Last reported playback position
+	StartedAt       time.Time  `json:"started_at" gorm:"not null;index:idx_play_histories_user_started"`
+	EndedAt         *time.Time `json:"ended_at"`
+	UpdatedAt       time.Time  `json:"updated_at" gorm:"autoUpdateTime"`
+
+	User User `json:"-" gorm:"foreignKey:UserID;references:ID;constraint:OnDelete:CASCADE"`
+	Song Song `json:"song" gorm:"foreignKey:SongID;references:ID;constraint:OnDelete:CASCADE"`
+}
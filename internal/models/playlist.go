@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Playlist represents an ordered, user-owned collection of songs.
+type Playlist struct {
+	ID          uuid.UUID `json:"id" gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	UserID      uuid.UUID `json:"user_id" gorm:"type:uuid;not null;index"`
+	Name        string    `json:"name" gorm:"size:255;not null"`
+	Description string    `json:"description" gorm:"type:text"`
+	CreatedAt   time.Time `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt   time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+
+	User  User           `json:"-" gorm:"foreignKey:UserID;references:ID;constraint:OnDelete:CASCADE"`
+	Items []PlaylistItem `json:"items" gorm:"foreignKey:PlaylistID;references:ID;constraint:OnDelete:CASCADE"`
+}
+
+// PlaylistItem is a single track inside a playlist. The same song may appear
+// more than once, so items have their own ID and an explicit Position.
+type PlaylistItem struct {
+	ID         uuid.UUID `json:"id" gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	PlaylistID uuid.UUID `json:"playlist_id" gorm:"type:uuid;not null;uniqueIndex:idx_playlist_items_position"`
+	SongID     uuid.UUID `json:"song_id" gorm:"type:uuid;not null;index"`
+	Position   int       `json:"position" gorm:"not null;uniqueIndex:idx_playlist_items_position"` // 0-based position inside the playlist
+	AddedAt    time.Time `json:"added_at" gorm:"autoCreateTime"`
+
+	Song Song `json:"song" gorm:"foreignKey:SongID;references:ID;constraint:OnDelete:CASCADE"` // Removing a song from the library drops it from every playlist
+}
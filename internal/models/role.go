@@ -0,0 +1,60 @@
+package models
+
+import "slices"
+
+// Roles a user can have.
+const (
+	RoleAdmin = "admin" // Every permission, plus user and system administration
+	RoleUser  = "user"
+	RoleGuest = "guest"
+)
+
+// Permissions that can be granted to a user.
+const (
+	PermissionStream        = "stream"
+	PermissionDownload      = "download"
+	PermissionManageLibrary = "manage_library" // Start and cancel library scans
+)
+
+// AllPermissions lists every permission.
+var AllPermissions = []string{PermissionStream, PermissionDownload, PermissionManageLibrary}
+
+// rolePermissions are the permissions of users without an explicit permission set.
+var rolePermissions = map[string][]string{
+	RoleAdmin: AllPermissions,
+	RoleUser:  {PermissionStream, PermissionDownload},
+	RoleGuest: {PermissionStream},
+}
+
+// IsValidRole reports whether role is a known role.
+func IsValidRole(role string) bool {
+	_, ok := rolePermissions[role]
+	return ok
+}
+
+// IsValidPermission reports whether permission is a known permission.
+func IsValidPermission(permission string) bool {
+	return slices.Contains(AllPermissions, permission)
+}
+
+// IsAdmin reports whether the user has the admin role.
+func (u *User) IsAdmin() bool {
+	return u.Role == RoleAdmin
+}
+
+// EffectivePermissions returns the explicit permissions of the user, or the
+// defaults of their role when none were set. Admins always have them all.
+func (u *User) EffectivePermissions() []string {
+	if u.IsAdmin() {
+		return AllPermissions
+	}
+	if u.Permissions != nil {
+		return u.Permissions
+	}
+	return rolePermissions[u.Role]
+}
+
+// HasPermission reports whether the user has permission.
+func (u *User) HasPermission(permission string) bool {
+	return slices.Contains(u.EffectivePermissions(), permission)
+}
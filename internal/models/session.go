@@ -15,4 +15,50 @@ type Session struct {
 	ExpiresAt time.Time `json:"expires_at" db:"expires_at" gorm:"not null"`
 	UserAgent string    `json:"user_agent" db:"user_agent"`
 	IPAddress net.IP    `json:"ip_address" db:"ip_address"`
+
+	LastSeenAt *time.Time `json:"last_seen_at,omitempty" db:"last_seen_at"`                 // Last authenticated request; nil for sessions created before it was tracked
+	Persistent bool       `json:"persistent" db:"persistent" gorm:"not null;default:false"` // "Remember me": the cookie outlives the browser session
+}
+
+// SessionPolicy controls how long sessions live. Sessions slide: while in use
+// they are renewed for another Duration, but never past MaxLifetime from
+// their creation.
+type SessionPolicy struct {
+	Duration         time.Duration // Lifetime given at login and on each renewal
+	MaxLifetime      time.Duration // Absolute limit from CreatedAt; 0 means no limit
+	RenewThreshold   time.Duration // Renew once less than this is left; 0 disables sliding
+	LastSeenInterval time.Duration // Minimum time between LastSeenAt updates
+}
+
+// ExpiresAt returns the expiry for a session created at createdAt and renewed at now.
+func (p SessionPolicy) ExpiresAt(createdAt, now time.Time) time.Time {
+	expiresAt := now.Add(p.Duration)
+	if p.MaxLifetime > 0 {
+		if limit := createdAt.Add(p.MaxLifetime); expiresAt.After(limit) {
+			return limit
+		}
+	}
+	return expiresAt
+}
+
+// Expired reports whether the session is past its expiry or its maximum lifetime.
+func (p SessionPolicy) Expired(s *Session, now time.Time) bool {
+	if !now.Before(s.ExpiresAt) {
+		return true
+	}
+	return p.MaxLifetime > 0 && !now.Before(s.CreatedAt.Add(p.MaxLifetime))
+}
+
+// ShouldRenew reports whether the session is close enough to its expiry to be
+// renewed, and renewing would actually extend it.
+func (p SessionPolicy) ShouldRenew(s *Session, now time.Time) bool {
+	if p.RenewThreshold <= 0 || s.ExpiresAt.Sub(now) >= p.RenewThreshold {
+		return false
+	}
+	return p.ExpiresAt(s.CreatedAt, now).After(s.ExpiresAt)
+}
+
+// ShouldTouch reports whether LastSeenAt is old enough to be updated.
+func (p SessionPolicy) ShouldTouch(s *Session, now time.Time) bool {
+	return s.LastSeenAt == nil || now.Sub(*s.LastSeenAt) >= p.LastSeenInterval
 }
@@ -0,0 +1,132 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSessionPolicyExpiresAt(t *testing.T) {
+	created := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		policy SessionPolicy
+		now    time.Time
+		want   time.Time
+	}{
+		{
+			name:   "at login",
+			policy: SessionPolicy{Duration: 24 * time.Hour, MaxLifetime: 720 * time.Hour},
+			now:    created,
+			want:   created.Add(24 * time.Hour),
+		},
+		{
+			name:   "renewed well before the limit",
+			policy: SessionPolicy{Duration: 24 * time.Hour, MaxLifetime: 720 * time.Hour},
+			now:    created.Add(100 * time.Hour),
+			want:   created.Add(124 * time.Hour),
+		},
+		{
+			name:   "capped by the maximum lifetime",
+			policy: SessionPolicy{Duration: 24 * time.Hour, MaxLifetime: 720 * time.Hour},
+			now:    created.Add(710 * time.Hour),
+			want:   created.Add(720 * time.Hour),
+		},
+		{
+			name:   "no maximum lifetime",
+			policy: SessionPolicy{Duration: 24 * time.Hour},
+			now:    created.Add(1000 * time.Hour),
+			want:   created.Add(1024 * time.Hour),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.policy.ExpiresAt(created, tt.now); !got.Equal(tt.want) {
+				t.Errorf("ExpiresAt = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSessionPolicyExpired(t *testing.T) {
+	created := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	policy := SessionPolicy{Duration: 24 * time.Hour, MaxLifetime: 720 * time.Hour}
+
+	tests := []struct {
+		name      string
+		policy    SessionPolicy
+		expiresAt time.Time
+		now       time.Time
+		want      bool
+	}{
+		{"before expiry", policy, created.Add(24 * time.Hour), created.Add(time.Hour), false},
+		{"at expiry", policy, created.Add(24 * time.Hour), created.Add(24 * time.Hour), true},
+		{"after expiry", policy, created.Add(24 * time.Hour), created.Add(25 * time.Hour), true},
+		// A row with a later expiry (e.g. issued under a longer policy) still ends at the maximum lifetime
+		{"past the maximum lifetime", policy, created.Add(1000 * time.Hour), created.Add(720 * time.Hour), true},
+		{"no maximum lifetime", SessionPolicy{Duration: 24 * time.Hour}, created.Add(1000 * time.Hour), created.Add(720 * time.Hour), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			session := &Session{CreatedAt: created, ExpiresAt: tt.expiresAt}
+			if got := tt.policy.Expired(session, tt.now); got != tt.want {
+				t.Errorf("Expired = %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSessionPolicyShouldRenew(t *testing.T) {
+	created := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	policy := SessionPolicy{Duration: 24 * time.Hour, MaxLifetime: 720 * time.Hour, RenewThreshold: 12 * time.Hour}
+
+	tests := []struct {
+		name      string
+		policy    SessionPolicy
+		expiresAt time.Time
+		now       time.Time
+		want      bool
+	}{
+		{"more than the threshold left", policy, created.Add(24 * time.Hour), created.Add(11 * time.Hour), false},
+		{"exactly the threshold left", policy, created.Add(24 * time.Hour), created.Add(12 * time.Hour), false},
+		{"less than the threshold left", policy, created.Add(24 * time.Hour), created.Add(13 * time.Hour), true},
+		{"already at the maximum lifetime", policy, created.Add(720 * time.Hour), created.Add(710 * time.Hour), false},
+		{"sliding disabled", SessionPolicy{Duration: 24 * time.Hour}, created.Add(24 * time.Hour), created.Add(23 * time.Hour), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			session := &Session{CreatedAt: created, ExpiresAt: tt.expiresAt}
+			if got := tt.policy.ShouldRenew(session, tt.now); got != tt.want {
+				t.Errorf("ShouldRenew = %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSessionPolicyShouldTouch(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	policy := SessionPolicy{LastSeenInterval: 5 * time.Minute}
+	at := func(d time.Duration) *time.Time {
+		t := now.Add(-d)
+		return &t
+	}
+
+	tests := []struct {
+		name       string
+		lastSeenAt *time.Time
+		want       bool
+	}{
+		{"never seen", nil, true},
+		{"seen recently", at(time.Minute), false},
+		{"seen exactly an interval ago", at(5 * time.Minute), true},
+		{"seen long ago", at(time.Hour), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			session := &Session{LastSeenAt: tt.lastSeenAt}
+			if got := policy.ShouldTouch(session, now); got != tt.want {
+				t.Errorf("ShouldTouch = %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
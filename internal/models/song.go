@@ -9,17 +9,31 @@ import (
 // Song representa una canción almacenada en la base de datos.
 type Song struct {
 	ID              uuid.UUID `json:"id" gorm:"type:uuid;primaryKey;default:gen_random_uuid()"` // ID único de la canción en la DB, ahora UUID
-	Title           string    `gorm:"size:255;not null" json:"title"`
-	Artist          string    `gorm:"size:255;not null" json:"artist"`
-	Album           string    `gorm:"size:255" json:"album,omitempty"`
+	Title           string    `gorm:"size:255;not null;index" json:"title"`
+	Artist          string    `gorm:"size:255;not null;index;index:idx_songs_artist_lower,expression:lower(artist)" json:"artist"`
+	Album           string    `gorm:"size:255;index;index:idx_songs_album_lower,expression:lower(album)" json:"album,omitempty"`
 	TrackNumber     int       `json:"track_number,omitempty"`
-	Genre           string    `gorm:"size:255" json:"genre,omitempty"`
-	Year            int       `json:"year,omitempty"`
+	Genre           string    `gorm:"size:255;index;index:idx_songs_genre_lower,expression:lower(genre)" json:"genre,omitempty"`
+	Year            int       `gorm:"index" json:"year,omitempty"`
 	DurationSeconds int       `json:"duration_seconds"`                          // Duración en segundos
 	FilePath        string    `gorm:"size:512;unique;not null" json:"file_path"` // FilePath es la ruta del archivo relativa al MUSIC_DIRECTORY. Ej: "Artista/Álbum/Cancion.mp3". Debe ser único para evitar duplicados del mismo archivo.
 	Filename        string    `gorm:"size:255;not null" json:"filename"`         // ej: "Cancion.mp3"
 	CreatedAt       time.Time `gorm:"autoCreateTime" json:"created_at"`
 	UpdatedAt       time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+
+	// Catálogo: disco, artista del álbum y enlaces a Artist/Album
+	AlbumArtist string     `gorm:"size:255" json:"album_artist,omitempty"` // Tag "album artist"; si está vacío el álbum pertenece a Artist
+	DiscNumber  int        `json:"disc_number,omitempty"`
+	ArtistID    *uuid.UUID `gorm:"type:uuid;index" json:"artist_id,omitempty"` // Enlace a Artist, asignado por el escaneo
+	AlbumID     *uuid.UUID `gorm:"type:uuid;index" json:"album_id,omitempty"`  // Enlace a Album, asignado por el escaneo
+
+	ArtistRef *Artist `gorm:"foreignKey:ArtistID;references:ID;constraint:OnDelete:SET NULL" json:"-"`
+	AlbumRef  *Album  `gorm:"foreignKey:AlbumID;references:ID;constraint:OnDelete:SET NULL" json:"-"`
+
+	// Huella del archivo: el escaneo incremental no vuelve a leer archivos que no han cambiado
+	FileSize    int64     `json:"-"`
+	FileModTime time.Time `json:"-"`                // Fecha de modificación, truncada a microsegundos (precisión de PostgreSQL)
+	ContentHash string    `gorm:"size:64" json:"-"` // SHA-256 parcial del contenido; vacío si SCAN_CONTENT_HASH no está activo
 }
 
 func (s *Song) Equals(other *Song) bool {
@@ -29,5 +43,20 @@ func (s *Song) Equals(other *Song) bool {
 		s.TrackNumber == other.TrackNumber &&
 		s.Genre == other.Genre &&
 		s.Year == other.Year &&
-		s.DurationSeconds == other.DurationSeconds
+		s.DurationSeconds == other.DurationSeconds &&
+		s.AlbumArtist == other.AlbumArtist &&
+		s.DiscNumber == other.DiscNumber &&
+		sameID(s.ArtistID, other.ArtistID) &&
+		sameID(s.AlbumID, other.AlbumID) &&
+		s.FileSize == other.FileSize &&
+		s.FileModTime.Equal(other.FileModTime) &&
+		s.ContentHash == other.ContentHash
+}
+
+// sameID compara dos IDs opcionales.
+func sameID(a, b *uuid.UUID) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
 }
@@ -0,0 +1,13 @@
+package models
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// HashToken returns the hash stored for a token secret (API tokens, password
+// reset links...). The secrets are random, so a fast hash is enough.
+func HashToken(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,50 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TwoFactor is the TOTP authenticator of a user. It is created unconfirmed
+// when enrollment starts and only enforced at login once ConfirmedAt is set.
+type TwoFactor struct {
+	UserID       uuid.UUID  `json:"user_id" gorm:"type:uuid;primaryKey"`
+	Secret       string     `json:"-" gorm:"type:varchar(64);not null"` // Base32; needed in clear to compute the codes
+	ConfirmedAt  *time.Time `json:"confirmed_at,omitempty"`
+	LastUsedStep int64      `json:"-" gorm:"not null;default:0"` // Time step of the last accepted code, so it cannot be replayed
+	CreatedAt    time.Time  `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt    time.Time  `json:"updated_at" gorm:"autoUpdateTime"`
+
+	User User `json:"-" gorm:"foreignKey:UserID;references:ID;constraint:OnDelete:CASCADE"`
+}
+
+// Enabled reports whether the authenticator is confirmed and required at login.
+func (t *TwoFactor) Enabled() bool {
+	return t.ConfirmedAt != nil
+}
+
+// RecoveryCode is a single-use code to log in without the authenticator.
+// Only the hash of the code is stored; it is deleted once used.
+type RecoveryCode struct {
+	ID        uuid.UUID `json:"id" gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	UserID    uuid.UUID `json:"user_id" gorm:"type:uuid;not null;index"`
+	CodeHash  string    `json:"-" gorm:"type:varchar(64);not null"`
+	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
+
+	User User `json:"-" gorm:"foreignKey:UserID;references:ID;constraint:OnDelete:CASCADE"`
+}
+
+// PendingLogin is the first step of a login with two-factor authentication:
+// the password was right and the session is created once a valid code is
+// given with the pending token. Only the hash of the token is stored.
+type PendingLogin struct {
+	TokenHash  string    `json:"-" gorm:"type:varchar(64);primaryKey"`
+	UserID     uuid.UUID `json:"user_id" gorm:"type:uuid;not null;index"`
+	Persistent bool      `json:"persistent" gorm:"not null;default:false"` // "Remember me" of the first step
+	Attempts   int       `json:"attempts" gorm:"not null;default:0"`       // Wrong codes given so far
+	ExpiresAt  time.Time `json:"expires_at" gorm:"not null;index"`
+	CreatedAt  time.Time `json:"created_at" gorm:"autoCreateTime"`
+
+	User User `json:"-" gorm:"foreignKey:UserID;references:ID;constraint:OnDelete:CASCADE"`
+}
@@ -16,4 +16,14 @@ type User struct {
 	UpdatedAt time.Time `json:"updated_at" db:"updated_at" gorm:"autoUpdateTime"`
 
 	Authentication *Authentication `gorm:"foreignKey:UserID;references:ID"`
+
+	// Access control: role, and explicit permissions overriding the role defaults when not nil
+	Role        string   `json:"role" gorm:"type:varchar(20);not null;default:user"`
+	Permissions []string `json:"permissions,omitempty" gorm:"serializer:json;type:jsonb"`
+
+	// Account state managed by admins
+	DisabledAt            *time.Time `json:"disabled_at,omitempty"`                                 // Disabled accounts cannot log in nor use their sessions
+	PasswordResetRequired bool       `json:"password_reset_required" gorm:"not null;default:false"` // The user must change their password
+
+	EmailVerifiedAt *time.Time `json:"email_verified_at,omitempty"` // Set when the user opens the verification link; cleared when the email changes
 }
@@ -0,0 +1,153 @@
+// Package scheduler runs periodic maintenance tasks inside the API process,
+// on cron-like schedules.
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/demispreviotto/cajitamusical/cajitamusical-backend/internal/dto/task"
+	"github.com/robfig/cron/v3"
+)
+
+// Disabled is the schedule value that turns a task off.
+const Disabled = "off"
+
+// TaskFunc is the work of a task. ctx is cancelled when the scheduler stops.
+type TaskFunc func(ctx context.Context) error
+
+// Scheduler runs registered tasks on their schedules. A task never overlaps
+// with itself: a run that comes due while the previous one is still going is
+// skipped.
+type Scheduler struct {
+	cron   *cron.Cron
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu    sync.Mutex
+	tasks []*scheduledTask
+}
+
+// scheduledTask is a registered task and the outcome of its last run.
+type scheduledTask struct {
+	name     string
+	schedule string
+	run      TaskFunc
+	entryID  cron.EntryID
+
+	// Guarded by Scheduler.mu
+	running      bool
+	lastRunAt    *time.Time
+	lastDuration time.Duration
+	lastError    string
+}
+
+// New creates a stopped scheduler.
+func New() *Scheduler {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Scheduler{
+		cron:   cron.New(),
+		ctx:    ctx,
+		cancel: cancel,
+	}
+}
+
+// Add registers a task. schedule uses the standard five-field cron syntax or
+// descriptors such as "@hourly" and "@every 30m"; Disabled (or an empty
+// schedule) skips the task.
+func (s *Scheduler) Add(name, schedule string, run TaskFunc) error {
+	if schedule == "" || schedule == Disabled {
+		log.Printf("Scheduler: Task %q is disabled", name)
+		return nil
+	}
+
+	t := &scheduledTask{name: name, schedule: schedule, run: run}
+	entryID, err := s.cron.AddFunc(schedule, func() { s.runTask(t) })
+	if err != nil {
+		return fmt.Errorf("invalid schedule %q for task %q: %w", schedule, name, err)
+	}
+	t.entryID = entryID
+
+	s.mu.Lock()
+	s.tasks = append(s.tasks, t)
+	s.mu.Unlock()
+	return nil
+}
+
+// Start begins running the tasks in the background.
+func (s *Scheduler) Start() {
+	s.cron.Start()
+	log.Printf("Scheduler: Started with %d task(s)", len(s.tasks))
+}
+
+// Stop prevents new runs, cancels the context of the running tasks and waits
+// for them to return, or for ctx to be done.
+func (s *Scheduler) Stop(ctx context.Context) error {
+	stopped := s.cron.Stop()
+	s.cancel()
+
+	select {
+	case <-stopped.Done():
+		log.Println("Scheduler: Stopped")
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("scheduler tasks did not stop in time: %w", ctx.Err())
+	}
+}
+
+// Status returns the state of every registered task.
+func (s *Scheduler) Status() *task.ListTasksResponse {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	response := &task.ListTasksResponse{Tasks: make([]task.TaskStatusResponse, 0, len(s.tasks))}
+	for _, t := range s.tasks {
+		status := task.TaskStatusResponse{
+			Name:               t.name,
+			Schedule:           t.schedule,
+			Running:            t.running,
+			LastRunAt:          t.lastRunAt,
+			LastDurationMillis: t.lastDuration.Milliseconds(),
+			LastError:          t.lastError,
+		}
+		if next := s.cron.Entry(t.entryID).Next; !next.IsZero() {
+			status.NextRunAt = &next
+		}
+		response.Tasks = append(response.Tasks, status)
+	}
+	return response
+}
+
+// runTask runs t unless its previous run is still going, and records the outcome.
+func (s *Scheduler) runTask(t *scheduledTask) {
+	s.mu.Lock()
+	if t.running {
+		s.mu.Unlock()
+		log.Printf("Scheduler: Skipping task %q, previous run still in progress", t.name)
+		return
+	}
+	t.running = true
+	s.mu.Unlock()
+
+	startedAt := time.Now()
+	err := t.run(s.ctx)
+	duration := time.Since(startedAt)
+	if err != nil {
+		log.Printf("Scheduler: Task %q failed after %s: %v", t.name, duration, err)
+	} else {
+		log.Printf("Scheduler: Task %q completed in %s", t.name, duration)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t.running = false
+	t.lastRunAt = &startedAt
+	t.lastDuration = duration
+	t.lastError = ""
+	if err != nil {
+		t.lastError = err.Error()
+	}
+}
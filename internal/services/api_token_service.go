@@ -0,0 +1,120 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"time"
+
+	"github.com/demispreviotto/cajitamusical/cajitamusical-backend/internal/db"
+	"github.com/demispreviotto/cajitamusical/cajitamusical-backend/internal/dto/token"
+	"github.com/demispreviotto/cajitamusical/cajitamusical-backend/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Errors returned by APITokenServicer.
+var (
+	ErrInvalidScope     = errors.New("invalid scope")
+	ErrAPITokenNotFound = errors.New("API token not found")
+)
+
+// APITokenServicer defines the interface for managing personal API tokens.
+type APITokenServicer interface {
+	CreateToken(ctx context.Context, user *models.User, input token.CreateAPITokenInput) (*token.CreateAPITokenResponse, error)
+	ListTokens(ctx context.Context, userID uuid.UUID) ([]token.APITokenResponse, error)
+	RevokeToken(ctx context.Context, userID, tokenID uuid.UUID) error
+}
+
+// apiTokenService is the concrete implementation of APITokenServicer.
+type apiTokenService struct {
+	apiTokenDB db.APITokenDBer
+}
+
+// NewAPITokenService creates a new instance of APITokenService.
+func NewAPITokenService(apiTokenDB db.APITokenDBer) APITokenServicer {
+	return &apiTokenService{apiTokenDB: apiTokenDB}
+}
+
+// CreateToken creates a token for user and returns its secret, which is not
+// stored and cannot be retrieved later. Only admins can grant the admin scope.
+func (s *apiTokenService) CreateToken(ctx context.Context, user *models.User, input token.CreateAPITokenInput) (*token.CreateAPITokenResponse, error) {
+	scopes := slices.Compact(slices.Sorted(slices.Values(input.Scopes)))
+	for _, scope := range scopes {
+		if !models.IsValidAPITokenScope(scope) || (scope == models.APITokenScopeAdmin && !user.IsAdmin()) {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidScope, scope)
+		}
+	}
+
+	secret, err := newTokenSecret(models.APITokenPrefix)
+	if err != nil {
+		log.Printf("Service: Failed to generate API token secret: %v", err)
+		return nil, errors.New("failed to create API token")
+	}
+
+	apiToken := &models.APIToken{
+		UserID:    user.ID,
+		Name:      input.Name,
+		TokenHash: models.HashToken(secret),
+		Hint:      secret[:len(models.APITokenPrefix)+6],
+		Scopes:    scopes,
+	}
+	if input.ExpiresInDays != nil {
+		expiresAt := time.Now().AddDate(0, 0, *input.ExpiresInDays)
+		apiToken.ExpiresAt = &expiresAt
+	}
+
+	if err := s.apiTokenDB.CreateAPIToken(ctx, apiToken); err != nil {
+		log.Printf("Service: Failed to create API token for user %s: %v", user.ID.String(), err)
+		return nil, errors.New("failed to create API token")
+	}
+	log.Printf("Service: User %s created API token %q", user.Username, apiToken.Name)
+
+	return &token.CreateAPITokenResponse{
+		APITokenResponse: mapAPITokenToResponse(apiToken, time.Now()),
+		Token:            secret,
+	}, nil
+}
+
+// ListTokens returns the tokens of the user, including expired ones.
+func (s *apiTokenService) ListTokens(ctx context.Context, userID uuid.UUID) ([]token.APITokenResponse, error) {
+	tokens, err := s.apiTokenDB.ListAPITokens(ctx, userID)
+	if err != nil {
+		log.Printf("Service: Failed to list API tokens of user %s: %v", userID.String(), err)
+		return nil, errors.New("failed to retrieve API tokens")
+	}
+
+	now := time.Now()
+	response := make([]token.APITokenResponse, 0, len(tokens))
+	for i := range tokens {
+		response = append(response, mapAPITokenToResponse(&tokens[i], now))
+	}
+	return response, nil
+}
+
+// RevokeToken deletes a token of the user.
+func (s *apiTokenService) RevokeToken(ctx context.Context, userID, tokenID uuid.UUID) error {
+	if err := s.apiTokenDB.DeleteAPIToken(ctx, userID, tokenID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrAPITokenNotFound
+		}
+		log.Printf("Service: Failed to revoke API token %s: %v", tokenID.String(), err)
+		return errors.New("failed to revoke API token")
+	}
+	return nil
+}
+
+func mapAPITokenToResponse(apiToken *models.APIToken, now time.Time) token.APITokenResponse {
+	return token.APITokenResponse{
+		ID:         apiToken.ID,
+		Name:       apiToken.Name,
+		Hint:       apiToken.Hint,
+		Scopes:     apiToken.Scopes,
+		ExpiresAt:  apiToken.ExpiresAt,
+		LastUsedAt: apiToken.LastUsedAt,
+		CreatedAt:  apiToken.CreatedAt,
+		Expired:    apiToken.Expired(now),
+	}
+}
@@ -10,35 +10,120 @@ import (
 
 	"github.com/demispreviotto/cajitamusical/cajitamusical-backend/internal/db"
 	"github.com/demispreviotto/cajitamusical/cajitamusical-backend/internal/dto/auth"
-	"github.com/demispreviotto/cajitamusical/cajitamusical-backend/internal/dto/user"
+	"github.com/demispreviotto/cajitamusical/cajitamusical-backend/internal/dto/passkey"
 	"github.com/demispreviotto/cajitamusical/cajitamusical-backend/internal/models"
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/webauthn"
 	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
 )
 
+// Errores devueltos por AuthServicer.
+var (
+	ErrAccountDisabled  = errors.New("account disabled") // Cuenta desactivada por un administrador
+	ErrEmailNotVerified = errors.New("email not verified")
+	ErrSessionNotFound  = errors.New("session not found")
+
+	ErrInvalidPendingLogin = errors.New("invalid or expired login, please log in again") // Token del primer paso desconocido o caducado
+)
+
+const (
+	// pendingLoginTTL es el tiempo para dar el código de doble factor tras la contraseña.
+	pendingLoginTTL = 5 * time.Minute
+	// maxPendingLoginAttempts es el número de códigos erróneos tras el que hay
+	// que volver a dar la contraseña.
+	maxPendingLoginAttempts = 5
+)
+
 // AuthServicer define la interfaz para las operaciones del servicio de autenticación.
 //
 //go:generate mockgen -source=auth_service.go -destination=mocks/mock_auth_service.go
 type AuthServicer interface {
-	Login(ctx context.Context, username, password, userAgent, ipAddress string) (*auth.LoginResponse, *models.Session, error)
+	Login(ctx context.Context, input auth.LoginUserInput, userAgent, ipAddress string) (*auth.LoginResponse, *models.Session, error)
+	CompleteTwoFactorLogin(ctx context.Context, input auth.TwoFactorLoginInput, userAgent, ipAddress string) (*auth.LoginResponse, *models.Session, error)
+	BeginPasskeyLogin(ctx context.Context, input passkey.BeginLoginInput, ipAddress string) (*passkey.LoginOptionsResponse, error)
+	FinishPasskeyLogin(ctx context.Context, input passkey.FinishLoginInput, userAgent, ipAddress string) (*auth.LoginResponse, *models.Session, error)
 	Logout(ctx context.Context, sessionID uuid.UUID) error
 	CleanupExpiredSessions(ctx context.Context) error
+	ListSessions(ctx context.Context, userID, currentSessionID uuid.UUID) ([]auth.SessionResponse, error)
+	RevokeSession(ctx context.Context, userID, sessionID uuid.UUID) error
+	RevokeOtherSessions(ctx context.Context, userID, currentSessionID uuid.UUID) error
 }
 
 // authService es la implementación concreta de AuthServicer.
 type authService struct {
-	userDB    db.UserDBer    // Dependencia de la interfaz de la capa DB de usuarios
-	sessionDB db.SessionDBer // Dependencia de la interfaz de la capa DB de sesiones
+	userDB          db.UserDBer          // Dependencia de la interfaz de la capa DB de usuarios
+	sessionDB       db.SessionDBer       // Dependencia de la interfaz de la capa DB de sesiones
+	loginThrottleDB db.LoginThrottleDBer // Intentos fallidos por usuario e IP
+	twoFactorDB     db.TwoFactorDBer     // Doble factor (TOTP) e inicios de sesión pendientes del código
+	passkeyDB       db.PasskeyDBer       // Passkeys (WebAuthn) y sus retos
+	sessionPolicy   models.SessionPolicy
+	throttlePolicy  loginThrottlePolicy
+	webAuthn        *webauthn.WebAuthn
+
+	requireEmailVerification bool // REQUIRE_EMAIL_VERIFICATION: no se puede entrar sin verificar el email
 }
 
 // NewAuthService crea una nueva instancia de AuthService.
-func NewAuthService(userDB db.UserDBer, sessionDB db.SessionDBer) AuthServicer {
-	return &authService{userDB: userDB, sessionDB: sessionDB}
+func NewAuthService(userDB db.UserDBer, sessionDB db.SessionDBer, loginThrottleDB db.LoginThrottleDBer, twoFactorDB db.TwoFactorDBer, passkeyDB db.PasskeyDBer, sessionPolicy models.SessionPolicy, webAuthn *webauthn.WebAuthn) AuthServicer {
+	return &authService{
+		userDB:          userDB,
+		sessionDB:       sessionDB,
+		loginThrottleDB: loginThrottleDB,
+		twoFactorDB:     twoFactorDB,
+		passkeyDB:       passkeyDB,
+		sessionPolicy:   sessionPolicy,
+		throttlePolicy:  loginThrottlePolicyFromEnv(),
+		webAuthn:        webAuthn,
+
+		requireEmailVerification: os.Getenv("REQUIRE_EMAIL_VERIFICATION") == "true",
+	}
+}
+
+// SessionPolicyFromEnv lee la duración de las sesiones del entorno:
+// SESSION_DURATION_HOURS (24 por defecto), SESSION_MAX_LIFETIME_HOURS (720),
+// SESSION_RENEW_THRESHOLD (la mitad de la duración) y
+// SESSION_LAST_SEEN_INTERVAL (5m).
+func SessionPolicyFromEnv() models.SessionPolicy {
+	policy := models.SessionPolicy{
+		Duration:         24 * time.Hour,
+		MaxLifetime:      720 * time.Hour,
+		LastSeenInterval: 5 * time.Minute,
+	}
+	if dur, err := time.ParseDuration(os.Getenv("SESSION_DURATION_HOURS") + "h"); err == nil && dur > 0 {
+		policy.Duration = dur
+	}
+	if dur, err := time.ParseDuration(os.Getenv("SESSION_MAX_LIFETIME_HOURS") + "h"); err == nil && dur >= 0 {
+		policy.MaxLifetime = dur
+	}
+	policy.RenewThreshold = policy.Duration / 2
+	if dur, err := time.ParseDuration(os.Getenv("SESSION_RENEW_THRESHOLD")); err == nil && dur >= 0 {
+		policy.RenewThreshold = dur
+	}
+	if dur, err := time.ParseDuration(os.Getenv("SESSION_LAST_SEEN_INTERVAL")); err == nil && dur >= 0 {
+		policy.LastSeenInterval = dur
+	}
+	return policy
 }
 
-// Login maneja la lógica de negocio para el inicio de sesión.
-func (s *authService) Login(ctx context.Context, username, password, userAgent, ipAddress string) (*auth.LoginResponse, *models.Session, error) {
+// Login maneja la lógica de negocio para el inicio de sesión. Si el usuario
+// tiene doble factor, no crea la sesión: devuelve un token pendiente que se
+// canjea con un código en CompleteTwoFactorLogin.
+func (s *authService) Login(ctx context.Context, input auth.LoginUserInput, userAgent, ipAddress string) (*auth.LoginResponse, *models.Session, error) {
+	username := input.Username
+	now := time.Now()
+
+	// 0. Rechazar el intento si el usuario o la IP tienen que esperar. Si no,
+	// contarlo ya como fallido: así los intentos simultáneos no superan el límite.
+	throttleKeys := []string{userThrottleKey(username)}
+	if ipAddress != "" {
+		throttleKeys = append(throttleKeys, ipThrottleKey(ipAddress))
+	}
+	if err := s.reserveLoginAttempt(ctx, throttleKeys, now); err != nil {
+		return nil, nil, err
+	}
+
 	// 1. Obtener usuario y contraseña hasheada de la DB
 	userModel, hashedPassword, err := s.userDB.GetUserByUsername(ctx, username)
 	if err != nil {
@@ -46,21 +131,280 @@ func (s *authService) Login(ctx context.Context, username, password, userAgent,
 			return nil, nil, errors.New("invalid credentials")
 		}
 		log.Printf("Service: Failed to get user by username %s from DB: %v", username, err)
+		s.releaseLoginAttempt(ctx, throttleKeys)
 		return nil, nil, errors.New("login failed due to server error")
 	}
 
 	// 2. Comparar contraseñas
-	if err := bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password)); err != nil {
+	if err := bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(input.Password)); err != nil {
+		if err := s.userDB.RecordLogin(ctx, userModel.ID, now, false); err != nil {
+			log.Printf("Service: Failed to record failed login of user %s: %v", userModel.ID.String(), err)
+		}
 		return nil, nil, errors.New("invalid credentials")
 	}
+	s.releaseLoginAttempt(ctx, throttleKeys)
+	if userModel.DisabledAt != nil {
+		return nil, nil, ErrAccountDisabled
+	}
+	if s.requireEmailVerification && userModel.EmailVerifiedAt == nil {
+		return nil, nil, ErrEmailNotVerified
+	}
+
+	// 3. Con doble factor, la contraseña solo da paso al segundo paso. Los
+	// fallos no se olvidan hasta que el código también es correcto.
+	twoFactor, err := s.twoFactorDB.GetTwoFactor(ctx, userModel.ID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		log.Printf("Service: Failed to get two-factor authentication of user %s: %v", userModel.ID.String(), err)
+		return nil, nil, errors.New("login failed due to server error")
+	}
+	if twoFactor != nil && twoFactor.Enabled() {
+		loginResponse, err := s.startPendingLogin(ctx, userModel, input.RememberMe, now)
+		return loginResponse, nil, err
+	}
+
+	s.recordLoginSuccess(ctx, userModel, now)
+	return s.createSession(ctx, userModel, input.RememberMe, userAgent, ipAddress, now)
+}
+
+// startPendingLogin guarda el primer paso de un inicio de sesión con doble
+// factor y devuelve el token con el que completarlo.
+func (s *authService) startPendingLogin(ctx context.Context, userModel *models.User, persistent bool, now time.Time) (*auth.LoginResponse, error) {
+	pendingToken, err := newTokenSecret("")
+	if err != nil {
+		log.Printf("Service: Failed to generate pending login token: %v", err)
+		return nil, errors.New("login failed due to server error")
+	}
+
+	expiresAt := now.Add(pendingLoginTTL)
+	pending := &models.PendingLogin{
+		TokenHash:  models.HashToken(pendingToken),
+		UserID:     userModel.ID,
+		Persistent: persistent,
+		ExpiresAt:  expiresAt,
+	}
+	if err := s.twoFactorDB.CreatePendingLogin(ctx, pending); err != nil {
+		log.Printf("Service: Failed to create pending login for user %s: %v", userModel.ID.String(), err)
+		return nil, errors.New("login failed due to server error")
+	}
+
+	return &auth.LoginResponse{
+		Message:           "Two-factor authentication required",
+		TwoFactorRequired: true,
+		PendingToken:      pendingToken,
+		PendingExpiresAt:  &expiresAt,
+	}, nil
+}
+
+// CompleteTwoFactorLogin es el segundo paso del inicio de sesión con doble
+// factor: crea la sesión si el código (del autenticador o de recuperación) es
+// válido. Tras maxPendingLoginAttempts códigos erróneos hay que volver a
+// empezar, y los fallos cuentan para el bloqueo como los de contraseña.
+func (s *authService) CompleteTwoFactorLogin(ctx context.Context, input auth.TwoFactorLoginInput, userAgent, ipAddress string) (*auth.LoginResponse, *models.Session, error) {
+	now := time.Now()
+	tokenHash := models.HashToken(input.PendingToken)
+
+	pending, err := s.twoFactorDB.GetPendingLogin(ctx, tokenHash, now)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, ErrInvalidPendingLogin
+		}
+		log.Printf("Service: Failed to get pending login: %v", err)
+		return nil, nil, errors.New("login failed due to server error")
+	}
+	userModel, err := s.userDB.GetUserByID(ctx, pending.UserID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, ErrInvalidPendingLogin
+		}
+		log.Printf("Service: Failed to get user %s of pending login: %v", pending.UserID.String(), err)
+		return nil, nil, errors.New("login failed due to server error")
+	}
+
+	throttleKeys := []string{userThrottleKey(userModel.Username)}
+	if ipAddress != "" {
+		throttleKeys = append(throttleKeys, ipThrottleKey(ipAddress))
+	}
+	if err := s.checkLoginThrottle(ctx, throttleKeys, now); err != nil {
+		return nil, nil, err
+	}
+	if userModel.DisabledAt != nil {
+		return nil, nil, ErrAccountDisabled
+	}
+
+	// El doble factor pudo desactivarse entre los dos pasos
+	twoFactor, err := s.twoFactorDB.GetTwoFactor(ctx, userModel.ID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		log.Printf("Service: Failed to get two-factor authentication of user %s: %v", userModel.ID.String(), err)
+		return nil, nil, errors.New("login failed due to server error")
+	}
+	if twoFactor == nil || !twoFactor.Enabled() {
+		return nil, nil, ErrInvalidPendingLogin
+	}
+
+	if err := checkTwoFactorCode(ctx, s.twoFactorDB, twoFactor, input.Code, now); err != nil {
+		if errors.Is(err, ErrInvalidTwoFactorCode) {
+			if err := s.twoFactorDB.RecordPendingLoginFailure(ctx, tokenHash, maxPendingLoginAttempts); err != nil {
+				log.Printf("Service: Failed to record failed two-factor code of user %s: %v", userModel.ID.String(), err)
+			}
+			s.recordLoginFailure(ctx, throttleKeys, userModel, now)
+		}
+		return nil, nil, err
+	}
+
+	// Consumir el token pendiente: con dos peticiones a la vez, solo una crea sesión
+	if _, err := s.twoFactorDB.ConsumePendingLogin(ctx, tokenHash, now); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, ErrInvalidPendingLogin
+		}
+		log.Printf("Service: Failed to consume pending login of user %s: %v", userModel.ID.String(), err)
+		return nil, nil, errors.New("login failed due to server error")
+	}
+
+	s.recordLoginSuccess(ctx, userModel, now)
+	return s.createSession(ctx, userModel, pending.Persistent, userAgent, ipAddress, now)
+}
+
+// BeginPasskeyLogin guarda un reto y devuelve las opciones para que el
+// navegador lo firme con una passkey. Con un nombre de usuario se limitan a sus
+// passkeys; un usuario desconocido recibe las mismas opciones que sin nombre,
+// para no revelar qué usuarios existen. Cada reto guardado cuenta como un
+// intento fallido de la IP hasta que se completa el inicio de sesión, para que
+// no se puedan acumular retos sin límite.
+func (s *authService) BeginPasskeyLogin(ctx context.Context, input passkey.BeginLoginInput, ipAddress string) (*passkey.LoginOptionsResponse, error) {
+	var throttleKeys []string
+	if ipAddress != "" {
+		throttleKeys = []string{passkeyThrottleKey(ipAddress)}
+		if err := s.reserveLoginAttempt(ctx, throttleKeys, time.Now()); err != nil {
+			return nil, err
+		}
+	}
+
+	var userID *uuid.UUID
+	var user *passkeyUser
+	if input.Username != "" {
+		userModel, _, err := s.userDB.GetUserByUsername(ctx, input.Username)
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			log.Printf("Service: Failed to get user by username %s from DB: %v", input.Username, err)
+			s.releaseLoginAttempt(ctx, throttleKeys)
+			return nil, errors.New("failed to start passkey login")
+		}
+		if userModel != nil {
+			passkeys, err := s.passkeyDB.ListPasskeys(ctx, userModel.ID)
+			if err != nil {
+				log.Printf("Service: Failed to list passkeys of user %s: %v", userModel.ID.String(), err)
+				s.releaseLoginAttempt(ctx, throttleKeys)
+				return nil, errors.New("failed to start passkey login")
+			}
+			userID = &userModel.ID
+			if len(passkeys) > 0 {
+				user = &passkeyUser{user: userModel, passkeys: passkeys}
+			}
+		}
+	}
 
-	// 3. Crear una nueva sesión
-	sessionDurationStr := os.Getenv("SESSION_DURATION_HOURS")
-	sessionDurationHours := 24 // Default
-	if dur, errParse := time.ParseDuration(sessionDurationStr + "h"); errParse == nil {
-		sessionDurationHours = int(dur.Hours())
+	var assertion *protocol.CredentialAssertion
+	var err error
+	if user != nil {
+		assertion, _, err = s.webAuthn.BeginLogin(user)
+	} else {
+		assertion, _, err = s.webAuthn.BeginDiscoverableLogin()
+	}
+	if err != nil {
+		log.Printf("Service: Failed to create passkey login options: %v", err)
+		s.releaseLoginAttempt(ctx, throttleKeys)
+		return nil, errors.New("failed to start passkey login")
 	}
-	expiresAt := time.Now().Add(time.Duration(sessionDurationHours) * time.Hour)
+	challenge, err := createWebAuthnChallenge(ctx, s.passkeyDB, models.WebAuthnLogin, userID, assertion.Response.Challenge)
+	if err != nil {
+		s.releaseLoginAttempt(ctx, throttleKeys)
+		return nil, err
+	}
+	return &passkey.LoginOptionsResponse{
+		ChallengeID: challenge.ID,
+		PublicKey:   assertion.Response,
+	}, nil
+}
+
+// FinishPasskeyLogin comprueba la firma del reto con la passkey y crea la
+// sesión, como un inicio de sesión con contraseña. La passkey exige verificar
+// al usuario en el dispositivo, así que no se pide el doble factor. Tampoco se
+// aplica el bloqueo por intentos fallidos: una firma no se puede adivinar, y
+// así un atacante probando contraseñas no impide entrar al usuario.
+func (s *authService) FinishPasskeyLogin(ctx context.Context, input passkey.FinishLoginInput, userAgent, ipAddress string) (*auth.LoginResponse, *models.Session, error) {
+	now := time.Now()
+
+	// 1. Gastar el reto y buscar la passkey con la que se firmó
+	challenge, err := s.passkeyDB.ConsumeWebAuthnChallenge(ctx, input.ChallengeID, models.WebAuthnLogin, now)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, ErrInvalidPasskey
+		}
+		log.Printf("Service: Failed to get passkey login challenge: %v", err)
+		return nil, nil, errors.New("login failed due to server error")
+	}
+	parsed, err := input.Credential.Parse()
+	if err != nil {
+		log.Printf("Service: Passkey login rejected: %v", err)
+		return nil, nil, ErrInvalidPasskey
+	}
+	passkeyModel, err := s.passkeyDB.GetPasskeyByCredentialID(ctx, parsed.RawID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, ErrInvalidPasskey
+		}
+		log.Printf("Service: Failed to get passkey: %v", err)
+		return nil, nil, errors.New("login failed due to server error")
+	}
+	if challenge.UserID != nil && *challenge.UserID != passkeyModel.UserID {
+		return nil, nil, ErrInvalidPasskey
+	}
+
+	userModel, err := s.userDB.GetUserByID(ctx, passkeyModel.UserID)
+	if err != nil {
+		log.Printf("Service: Failed to get user %s of passkey: %v", passkeyModel.UserID.String(), err)
+		return nil, nil, errors.New("login failed due to server error")
+	}
+
+	// 2. Comprobar la firma, el user handle y que el contador avanza
+	user := &passkeyUser{user: userModel, passkeys: []models.Passkey{*passkeyModel}}
+	credential, err := s.webAuthn.ValidateLogin(user, webAuthnSession(s.webAuthn, challenge, userModel.ID), parsed)
+	if err != nil {
+		log.Printf("Service: Passkey login with %s rejected: %v", passkeyModel.ID.String(), err)
+		return nil, nil, ErrInvalidPasskey
+	}
+	if credential.Authenticator.CloneWarning {
+		log.Printf("Service: Warning: Passkey %s of user %s sent a stale signature counter, it may have been cloned", passkeyModel.ID.String(), passkeyModel.UserID.String())
+		return nil, nil, ErrInvalidPasskey
+	}
+
+	if userModel.DisabledAt != nil {
+		return nil, nil, ErrAccountDisabled
+	}
+	if s.requireEmailVerification && userModel.EmailVerifiedAt == nil {
+		return nil, nil, ErrEmailNotVerified
+	}
+
+	// 3. Guardar el contador; si otra petición lo cambió a la vez, solo una entra
+	if err := s.passkeyDB.RecordPasskeyUse(ctx, passkeyModel.ID, passkeyModel.SignCount, int64(credential.Authenticator.SignCount), now); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, ErrInvalidPasskey
+		}
+		log.Printf("Service: Failed to record use of passkey %s: %v", passkeyModel.ID.String(), err)
+		return nil, nil, errors.New("login failed due to server error")
+	}
+
+	// El reto gastado deja de contar contra la IP
+	if ipAddress != "" {
+		s.releaseLoginAttempt(ctx, []string{passkeyThrottleKey(ipAddress)})
+	}
+	s.recordLoginSuccess(ctx, userModel, now)
+	return s.createSession(ctx, userModel, input.RememberMe, userAgent, ipAddress, now)
+}
+
+// createSession crea la sesión de un inicio de sesión correcto y la respuesta
+// con los datos del usuario.
+func (s *authService) createSession(ctx context.Context, userModel *models.User, persistent bool, userAgent, ipAddress string, now time.Time) (*auth.LoginResponse, *models.Session, error) {
+	expiresAt := s.sessionPolicy.ExpiresAt(now, now)
 
 	// Convertir string de IP a net.IP
 	clientIP := net.ParseIP(ipAddress)
@@ -71,10 +415,13 @@ func (s *authService) Login(ctx context.Context, username, password, userAgent,
 	session := &models.Session{
 		SessionID: uuid.New(), // Generar un nuevo UUID para la sesión
 		UserID:    userModel.ID,
-		CreatedAt: time.Now(),
+		CreatedAt: now,
 		ExpiresAt: expiresAt,
 		UserAgent: userAgent,
 		IPAddress: clientIP,
+
+		LastSeenAt: &now,
+		Persistent: persistent,
 	}
 
 	if err := s.sessionDB.CreateSession(ctx, session); err != nil {
@@ -82,22 +429,82 @@ func (s *authService) Login(ctx context.Context, username, password, userAgent,
 		return nil, nil, errors.New("failed to create session")
 	}
 
-	// 4. Mapear el modelo de usuario a DTO de respuesta
-	responseUser := user.UserInfo{
-		ID:       userModel.ID,
-		Username: userModel.Username,
-		Email:    userModel.Email,
-		Name:     userModel.Name,
-	}
-
+	// Mapear el modelo de usuario a DTO de respuesta
 	loginResponse := &auth.LoginResponse{
 		Message: "Login successful",
-		User:    responseUser,
+		User:    MapUserToInfo(userModel),
 	}
 
 	return loginResponse, session, nil
 }
 
+// checkLoginThrottle devuelve un *LoginThrottledError si alguna de las claves
+// tiene que esperar antes de volver a intentarlo.
+func (s *authService) checkLoginThrottle(ctx context.Context, keys []string, now time.Time) error {
+	throttles, err := s.loginThrottleDB.GetLoginThrottles(ctx, keys)
+	if err != nil {
+		log.Printf("Service: Failed to get login throttles: %v", err)
+		return errors.New("login failed due to server error")
+	}
+	return s.throttlePolicy.check(throttles, now)
+}
+
+// reserveLoginAttempt es checkLoginThrottle, pero además cuenta el intento
+// como fallido en la misma transacción. Si resulta válido se devuelve con
+// releaseLoginAttempt.
+func (s *authService) reserveLoginAttempt(ctx context.Context, keys []string, now time.Time) error {
+	err := s.loginThrottleDB.ReserveLoginAttempt(ctx, keys, now, now.Add(-s.throttlePolicy.window), func(throttles []models.LoginThrottle) error {
+		return s.throttlePolicy.check(throttles, now)
+	})
+	if err != nil {
+		if errors.Is(err, ErrLoginThrottled) {
+			return err
+		}
+		log.Printf("Service: Failed to reserve login attempt: %v", err)
+		return errors.New("login failed due to server error")
+	}
+	return nil
+}
+
+// releaseLoginAttempt descuenta el intento reservado por reserveLoginAttempt.
+// Los errores solo se registran.
+func (s *authService) releaseLoginAttempt(ctx context.Context, keys []string) {
+	for _, key := range keys {
+		if err := s.loginThrottleDB.ReleaseLoginAttempt(ctx, key); err != nil {
+			log.Printf("Service: Failed to release login attempt for %s: %v", key, err)
+		}
+	}
+}
+
+// recordLoginFailure cuenta un intento fallido para el usuario y la IP, y
+// guarda su fecha en la autenticación del usuario si existe. Los errores solo
+// se registran: el intento ya ha fallado.
+func (s *authService) recordLoginFailure(ctx context.Context, keys []string, userModel *models.User, now time.Time) {
+	windowStart := now.Add(-s.throttlePolicy.window)
+	for _, key := range keys {
+		if err := s.loginThrottleDB.RecordLoginFailure(ctx, key, now, windowStart); err != nil {
+			log.Printf("Service: Failed to record failed login for %s: %v", key, err)
+		}
+	}
+	if userModel != nil {
+		if err := s.userDB.RecordLogin(ctx, userModel.ID, now, false); err != nil {
+			log.Printf("Service: Failed to record failed login of user %s: %v", userModel.ID.String(), err)
+		}
+	}
+}
+
+// recordLoginSuccess guarda la fecha del inicio de sesión y olvida los fallos
+// del usuario. Los de la IP se mantienen: un atacante con una cuenta propia no
+// puede reiniciarlos.
+func (s *authService) recordLoginSuccess(ctx context.Context, userModel *models.User, now time.Time) {
+	if err := s.loginThrottleDB.ResetLoginThrottle(ctx, userThrottleKey(userModel.Username)); err != nil {
+		log.Printf("Service: Failed to reset login throttle of user %s: %v", userModel.ID.String(), err)
+	}
+	if err := s.userDB.RecordLogin(ctx, userModel.ID, now, true); err != nil {
+		log.Printf("Service: Failed to record login of user %s: %v", userModel.ID.String(), err)
+	}
+}
+
 // Logout maneja la lógica de negocio para cerrar sesión.
 func (s *authService) Logout(ctx context.Context, sessionID uuid.UUID) error {
 	err := s.sessionDB.DeleteSession(ctx, sessionID)
@@ -115,5 +522,57 @@ func (s *authService) CleanupExpiredSessions(ctx context.Context) error {
 		log.Printf("Service: Error during expired session cleanup: %v", err)
 		return errors.New("failed to cleanup expired sessions")
 	}
+	// Los contadores de intentos fallidos ya olvidados tampoco hacen falta
+	if err := s.loginThrottleDB.DeleteStaleLoginThrottles(ctx, time.Now().Add(-s.throttlePolicy.window)); err != nil {
+		log.Printf("Service: Error during login throttle cleanup: %v", err)
+		return errors.New("failed to cleanup login throttles")
+	}
+	// Ni los inicios de sesión que no llegaron a dar el código de doble factor
+	if err := s.twoFactorDB.DeleteExpiredPendingLogins(ctx, time.Now()); err != nil {
+		log.Printf("Service: Error during pending login cleanup: %v", err)
+		return errors.New("failed to cleanup pending logins")
+	}
+	if err := s.passkeyDB.DeleteExpiredWebAuthnChallenges(ctx, time.Now()); err != nil {
+		log.Printf("Service: Error during WebAuthn challenge cleanup: %v", err)
+		return errors.New("failed to cleanup passkey challenges")
+	}
+	return nil
+}
+
+// ListSessions devuelve las sesiones vigentes del usuario, marcando la actual.
+func (s *authService) ListSessions(ctx context.Context, userID, currentSessionID uuid.UUID) ([]auth.SessionResponse, error) {
+	sessions, err := s.sessionDB.GetSessionsByUser(ctx, userID)
+	if err != nil {
+		log.Printf("Service: Failed to get sessions of user %s: %v", userID.String(), err)
+		return nil, errors.New("failed to retrieve sessions")
+	}
+
+	response := make([]auth.SessionResponse, 0, len(sessions))
+	for i := range sessions {
+		sessionResponse := mapSessionToResponse(&sessions[i])
+		sessionResponse.Current = sessions[i].SessionID == currentSessionID
+		response = append(response, sessionResponse)
+	}
+	return response, nil
+}
+
+// RevokeSession cierra una sesión del usuario, por ejemplo la de un dispositivo perdido.
+func (s *authService) RevokeSession(ctx context.Context, userID, sessionID uuid.UUID) error {
+	if err := s.sessionDB.DeleteUserSession(ctx, userID, sessionID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrSessionNotFound
+		}
+		log.Printf("Service: Failed to revoke session %s: %v", sessionID.String(), err)
+		return errors.New("failed to revoke session")
+	}
+	return nil
+}
+
+// RevokeOtherSessions cierra todas las sesiones del usuario salvo la actual.
+func (s *authService) RevokeOtherSessions(ctx context.Context, userID, currentSessionID uuid.UUID) error {
+	if err := s.sessionDB.DeleteOtherSessions(ctx, userID, currentSessionID); err != nil {
+		log.Printf("Service: Failed to revoke other sessions of user %s: %v", userID.String(), err)
+		return errors.New("failed to revoke sessions")
+	}
 	return nil
 }
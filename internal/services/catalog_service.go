@@ -0,0 +1,182 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/demispreviotto/cajitamusical/cajitamusical-backend/internal/db"
+	"github.com/demispreviotto/cajitamusical/cajitamusical-backend/internal/dto/catalog"
+	"github.com/demispreviotto/cajitamusical/cajitamusical-backend/internal/dto/song"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Errors returned by CatalogServicer.
+var (
+	ErrArtistNotFound = errors.New("artist not found")
+	ErrAlbumNotFound  = errors.New("album not found")
+)
+
+// defaultCatalogLimit is the page size used when the client does not send one.
+const defaultCatalogLimit = 100
+
+// CatalogServicer defines the interface for browsing artists and albums.
+type CatalogServicer interface {
+	ListArtists(ctx context.Context, query catalog.ListArtistsQuery) (*catalog.ListArtistsResponse, error)
+	GetArtist(ctx context.Context, artistID uuid.UUID) (*catalog.ArtistDetailResponse, error)
+	ListAlbums(ctx context.Context, query catalog.ListAlbumsQuery) (*catalog.ListAlbumsResponse, error)
+	GetAlbum(ctx context.Context, userID, albumID uuid.UUID) (*catalog.AlbumDetailResponse, error)
+}
+
+// catalogService is the concrete implementation of CatalogServicer.
+type catalogService struct {
+	songAnnotator
+	artistDB db.ArtistDBer
+	albumDB  db.AlbumDBer
+}
+
+// NewCatalogService creates a new instance of CatalogService.
+func NewCatalogService(artistDB db.ArtistDBer, albumDB db.AlbumDBer, favoriteDB db.FavoriteDBer, historyDB db.HistoryDBer) CatalogServicer {
+	return &catalogService{
+		songAnnotator: songAnnotator{favoriteDB: favoriteDB, historyDB: historyDB},
+		artistDB:      artistDB,
+		albumDB:       albumDB,
+	}
+}
+
+// ListArtists returns a page of artists ordered by name.
+func (s *catalogService) ListArtists(ctx context.Context, query catalog.ListArtistsQuery) (*catalog.ListArtistsResponse, error) {
+	if query.Limit == 0 {
+		query.Limit = defaultCatalogLimit
+	}
+
+	artists, total, err := s.artistDB.ListArtists(ctx, query.Limit, query.Offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get artists from DB: %w", err)
+	}
+
+	response := &catalog.ListArtistsResponse{
+		Artists: make([]catalog.ArtistResponse, 0, len(artists)),
+		Total:   int(total),
+		Limit:   query.Limit,
+		Offset:  query.Offset,
+	}
+	for i := range artists {
+		response.Artists = append(response.Artists, mapArtistToResponse(&artists[i]))
+	}
+	return response, nil
+}
+
+// GetArtist returns an artist with all of its albums, oldest first.
+func (s *catalogService) GetArtist(ctx context.Context, artistID uuid.UUID) (*catalog.ArtistDetailResponse, error) {
+	artist, err := s.artistDB.GetArtistByID(ctx, artistID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrArtistNotFound
+		}
+		log.Printf("Service: Failed to get artist %s from DB: %v", artistID.String(), err)
+		return nil, errors.New("failed to retrieve artist")
+	}
+
+	albums, _, err := s.albumDB.ListAlbums(ctx, &artistID, -1, 0) // -1: no limit
+	if err != nil {
+		log.Printf("Service: Failed to get albums of artist %s from DB: %v", artistID.String(), err)
+		return nil, errors.New("failed to retrieve artist")
+	}
+
+	response := &catalog.ArtistDetailResponse{
+		ArtistResponse: mapArtistToResponse(artist),
+		Albums:         make([]catalog.AlbumResponse, 0, len(albums)),
+	}
+	for i := range albums {
+		response.Albums = append(response.Albums, mapAlbumToResponse(&albums[i]))
+	}
+	return response, nil
+}
+
+// ListAlbums returns a page of albums, optionally only those of one artist.
+func (s *catalogService) ListAlbums(ctx context.Context, query catalog.ListAlbumsQuery) (*catalog.ListAlbumsResponse, error) {
+	if query.Limit == 0 {
+		query.Limit = defaultCatalogLimit
+	}
+
+	var artistID *uuid.UUID
+	if query.ArtistID != "" {
+		id, err := uuid.Parse(query.ArtistID)
+		if err != nil {
+			return nil, ErrArtistNotFound
+		}
+		artistID = &id
+	}
+
+	albums, total, err := s.albumDB.ListAlbums(ctx, artistID, query.Limit, query.Offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get albums from DB: %w", err)
+	}
+
+	response := &catalog.ListAlbumsResponse{
+		Albums: make([]catalog.AlbumResponse, 0, len(albums)),
+		Total:  int(total),
+		Limit:  query.Limit,
+		Offset: query.Offset,
+	}
+	for i := range albums {
+		response.Albums = append(response.Albums, mapAlbumToResponse(&albums[i]))
+	}
+	return response, nil
+}
+
+// GetAlbum returns an album with its tracks sorted by disc and track number.
+func (s *catalogService) GetAlbum(ctx context.Context, userID, albumID uuid.UUID) (*catalog.AlbumDetailResponse, error) {
+	album, err := s.albumDB.GetAlbumByID(ctx, albumID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrAlbumNotFound
+		}
+		log.Printf("Service: Failed to get album %s from DB: %v", albumID.String(), err)
+		return nil, errors.New("failed to retrieve album")
+	}
+
+	tracks, err := s.albumDB.GetAlbumTracks(ctx, albumID)
+	if err != nil {
+		log.Printf("Service: Failed to get tracks of album %s from DB: %v", albumID.String(), err)
+		return nil, errors.New("failed to retrieve album")
+	}
+
+	response := &catalog.AlbumDetailResponse{
+		AlbumResponse: mapAlbumToResponse(album),
+		Tracks:        make([]song.SongResponse, 0, len(tracks)),
+	}
+	for i := range tracks {
+		response.Tracks = append(response.Tracks, mapSongToResponse(&tracks[i]))
+	}
+	if err := s.annotate(ctx, userID, songPointers(response.Tracks)); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+func mapArtistToResponse(a *db.ArtistSummary) catalog.ArtistResponse {
+	return catalog.ArtistResponse{
+		ID:                   a.ID,
+		Name:                 a.Name,
+		AlbumCount:           a.AlbumCount,
+		TrackCount:           a.TrackCount,
+		TotalDurationSeconds: a.TotalDurationSeconds,
+	}
+}
+
+func mapAlbumToResponse(a *db.AlbumSummary) catalog.AlbumResponse {
+	return catalog.AlbumResponse{
+		ID:                   a.ID,
+		Title:                a.Title,
+		ArtistID:             a.ArtistID,
+		Artist:               a.ArtistName,
+		Year:                 a.Year,
+		TrackCount:           a.TrackCount,
+		TotalDurationSeconds: a.TotalDurationSeconds,
+		AlbumArtURL:          albumArtURL(a.SamplePath),
+	}
+}
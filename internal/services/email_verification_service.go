@@ -0,0 +1,155 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/demispreviotto/cajitamusical/cajitamusical-backend/internal/db"
+	"github.com/demispreviotto/cajitamusical/cajitamusical-backend/internal/dto/user"
+	"github.com/demispreviotto/cajitamusical/cajitamusical-backend/internal/mail"
+	"github.com/demispreviotto/cajitamusical/cajitamusical-backend/internal/models"
+	"gorm.io/gorm"
+)
+
+// ErrInvalidVerificationToken is returned for unknown, expired or outdated
+// verification links.
+var ErrInvalidVerificationToken = errors.New("invalid or expired email verification token")
+
+// emailVerificationTokenTTL is the validity of a verification link.
+const emailVerificationTokenTTL = 48 * time.Hour
+
+// EmailVerificationServicer defines the interface for verifying the email
+// addresses of users.
+type EmailVerificationServicer interface {
+	SendVerification(ctx context.Context, user *models.User) error
+	ResendVerification(ctx context.Context, input user.ResendVerificationInput) error
+	VerifyEmail(ctx context.Context, token string) error
+	CleanupExpiredTokens(ctx context.Context) error
+}
+
+// emailVerificationService is the concrete implementation of EmailVerificationServicer.
+type emailVerificationService struct {
+	userDB              db.UserDBer
+	emailVerificationDB db.EmailVerificationDBer
+	mailer              mail.Mailer
+	verifyURL           string        // GET /api/verify-email as seen by the users
+	resendInterval      time.Duration // Minimum time between two emails to the same user
+}
+
+// NewEmailVerificationService creates a new instance of EmailVerificationService.
+// The link sent points to verifyURL (see EmailVerificationURLFromEnv) and can
+// be sent again after EMAIL_VERIFICATION_RESEND_INTERVAL (1m by default).
+func NewEmailVerificationService(userDB db.UserDBer, emailVerificationDB db.EmailVerificationDBer, mailer mail.Mailer, verifyURL string) EmailVerificationServicer {
+	resendInterval := time.Minute
+	if d, err := time.ParseDuration(os.Getenv("EMAIL_VERIFICATION_RESEND_INTERVAL")); err == nil && d >= 0 {
+		resendInterval = d
+	}
+	return &emailVerificationService{
+		userDB:              userDB,
+		emailVerificationDB: emailVerificationDB,
+		mailer:              mailer,
+		verifyURL:           verifyURL,
+		resendInterval:      resendInterval,
+	}
+}
+
+// EmailVerificationURLFromEnv returns the public address of GET
+// /api/verify-email: EMAIL_VERIFICATION_URL, or else PUBLIC_URL followed by
+// /api/verify-email. One of them must be set.
+func EmailVerificationURLFromEnv() (string, error) {
+	if verifyURL := os.Getenv("EMAIL_VERIFICATION_URL"); verifyURL != "" {
+		return verifyURL, nil
+	}
+	if publicURL := os.Getenv("PUBLIC_URL"); publicURL != "" {
+		return strings.TrimSuffix(publicURL, "/") + "/api/verify-email", nil
+	}
+	return "", errors.New("EMAIL_VERIFICATION_URL or PUBLIC_URL must be set")
+}
+
+// SendVerification emails a verification link for the current address of
+// userModel. The email is sent in the background.
+func (s *emailVerificationService) SendVerification(ctx context.Context, userModel *models.User) error {
+	secret, err := newTokenSecret("")
+	if err != nil {
+		log.Printf("Service: Failed to generate email verification token: %v", err)
+		return errors.New("failed to send verification email")
+	}
+	verificationToken := &models.EmailVerificationToken{
+		UserID:    userModel.ID,
+		Email:     userModel.Email,
+		TokenHash: models.HashToken(secret),
+		ExpiresAt: time.Now().Add(emailVerificationTokenTTL),
+	}
+	if err := s.emailVerificationDB.CreateEmailVerificationToken(ctx, verificationToken); err != nil {
+		log.Printf("Service: Failed to store email verification token of user %s: %v", userModel.ID.String(), err)
+		return errors.New("failed to send verification email")
+	}
+
+	link := s.verifyURL + "?token=" + url.QueryEscape(secret)
+	msg := mail.Message{
+		To:      userModel.Email,
+		Subject: "Confirm your Cajita Musical email",
+		Body: fmt.Sprintf("Hi %s,\n\nTo confirm that this is the email of your account %q, open this link within %s:\n\n%s\n\n"+
+			"If you did not create an account, ignore this email.\n",
+			userModel.Name, userModel.Username, emailVerificationTokenTTL, link),
+	}
+	go sendMailInBackground(s.mailer, msg)
+	return nil
+}
+
+// ResendVerification sends a new link to an unverified email. Like password
+// resets, it succeeds whether or not the email is registered, and silently
+// skips users who got a link less than resendInterval ago.
+func (s *emailVerificationService) ResendVerification(ctx context.Context, input user.ResendVerificationInput) error {
+	userModel, err := s.userDB.GetUserByEmail(ctx, input.Email)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		log.Printf("Service: Failed to get user by email for verification: %v", err)
+		return errors.New("failed to send verification email")
+	}
+	if userModel.EmailVerifiedAt != nil || userModel.DisabledAt != nil {
+		return nil
+	}
+
+	latest, err := s.emailVerificationDB.GetLatestEmailVerificationToken(ctx, userModel.ID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		log.Printf("Service: Failed to get latest verification of user %s: %v", userModel.ID.String(), err)
+		return errors.New("failed to send verification email")
+	}
+	if latest != nil && time.Since(latest.CreatedAt) < s.resendInterval {
+		log.Printf("Service: Verification email to user %s throttled", userModel.Username)
+		return nil
+	}
+	return s.SendVerification(ctx, userModel)
+}
+
+// VerifyEmail marks the email of the user of token as verified.
+func (s *emailVerificationService) VerifyEmail(ctx context.Context, token string) error {
+	userID, err := s.emailVerificationDB.VerifyEmail(ctx, models.HashToken(token), time.Now())
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrInvalidVerificationToken
+		}
+		log.Printf("Service: Failed to verify email: %v", err)
+		return errors.New("failed to verify email")
+	}
+	log.Printf("Service: Email of user %s verified", userID.String())
+	return nil
+}
+
+// CleanupExpiredTokens removes the expired verification tokens.
+func (s *emailVerificationService) CleanupExpiredTokens(ctx context.Context) error {
+	if err := s.emailVerificationDB.DeleteExpiredEmailVerificationTokens(ctx, time.Now()); err != nil {
+		log.Printf("Service: Error during email verification token cleanup: %v", err)
+		return errors.New("failed to cleanup email verification tokens")
+	}
+	return nil
+}
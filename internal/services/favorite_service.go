@@ -0,0 +1,161 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/demispreviotto/cajitamusical/cajitamusical-backend/internal/db"
+	"github.com/demispreviotto/cajitamusical/cajitamusical-backend/internal/dto/favorite"
+	"github.com/demispreviotto/cajitamusical/cajitamusical-backend/internal/dto/song"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// FavoriteServicer defines the interface for per-user favorites.
+type FavoriteServicer interface {
+	ListFavorites(ctx context.Context, userID uuid.UUID) (*favorite.FavoritesResponse, error)
+	StarSong(ctx context.Context, userID, songID uuid.UUID) error
+	UnstarSong(ctx context.Context, userID, songID uuid.UUID) error
+	StarAlbum(ctx context.Context, userID, albumID uuid.UUID) error
+	UnstarAlbum(ctx context.Context, userID, albumID uuid.UUID) error
+	StarArtist(ctx context.Context, userID, artistID uuid.UUID) error
+	UnstarArtist(ctx context.Context, userID, artistID uuid.UUID) error
+}
+
+// favoriteService is the concrete implementation of FavoriteServicer.
+type favoriteService struct {
+	songAnnotator
+	favoriteDB db.FavoriteDBer
+	songDB     db.SongDBer
+	artistDB   db.ArtistDBer
+	albumDB    db.AlbumDBer
+}
+
+// NewFavoriteService creates a new instance of FavoriteService.
+func NewFavoriteService(favoriteDB db.FavoriteDBer, songDB db.SongDBer, artistDB db.ArtistDBer, albumDB db.AlbumDBer, historyDB db.HistoryDBer) FavoriteServicer {
+	return &favoriteService{
+		songAnnotator: songAnnotator{favoriteDB: favoriteDB, historyDB: historyDB},
+		favoriteDB:    favoriteDB,
+		songDB:        songDB,
+		artistDB:      artistDB,
+		albumDB:       albumDB,
+	}
+}
+
+// ListFavorites returns the songs, albums and artists starred by a user.
+func (s *favoriteService) ListFavorites(ctx context.Context, userID uuid.UUID) (*favorite.FavoritesResponse, error) {
+	songs, err := s.favoriteDB.GetFavoriteSongs(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get favorite songs from DB: %w", err)
+	}
+	albums, err := s.favoriteDB.GetFavoriteAlbums(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get favorite albums from DB: %w", err)
+	}
+	artists, err := s.favoriteDB.GetFavoriteArtists(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get favorite artists from DB: %w", err)
+	}
+
+	response := &favorite.FavoritesResponse{
+		Songs:   make([]song.SongResponse, 0, len(songs)),
+		Albums:  make([]favorite.FavoriteAlbumResponse, 0, len(albums)),
+		Artists: make([]favorite.FavoriteArtistResponse, 0, len(artists)),
+	}
+	for i := range songs {
+		response.Songs = append(response.Songs, mapSongToResponse(&songs[i]))
+	}
+	if err := s.annotate(ctx, userID, songPointers(response.Songs)); err != nil {
+		return nil, err
+	}
+	for _, a := range albums {
+		response.Albums = append(response.Albums, favorite.FavoriteAlbumResponse{
+			ID:        a.AlbumID,
+			Title:     a.Album.Title,
+			ArtistID:  a.Album.ArtistID,
+			Artist:    a.Album.Artist.Name,
+			CreatedAt: a.CreatedAt,
+		})
+	}
+	for _, a := range artists {
+		response.Artists = append(response.Artists, favorite.FavoriteArtistResponse{ID: a.ArtistID, Name: a.Artist.Name, CreatedAt: a.CreatedAt})
+	}
+	return response, nil
+}
+
+// StarSong marks a library song as favorite.
+func (s *favoriteService) StarSong(ctx context.Context, userID, songID uuid.UUID) error {
+	if _, err := s.songDB.GetSongByID(ctx, songID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrSongNotFound
+		}
+		log.Printf("Service: Failed to get song %s: %v", songID.String(), err)
+		return errors.New("failed to star song")
+	}
+	if err := s.favoriteDB.AddFavoriteSong(ctx, userID, songID); err != nil {
+		log.Printf("Service: Failed to star song %s for user %s: %v", songID.String(), userID.String(), err)
+		return errors.New("failed to star song")
+	}
+	return nil
+}
+
+// UnstarSong removes a song from the favorites of a user.
+func (s *favoriteService) UnstarSong(ctx context.Context, userID, songID uuid.UUID) error {
+	if err := s.favoriteDB.RemoveFavoriteSong(ctx, userID, songID); err != nil {
+		log.Printf("Service: Failed to unstar song %s for user %s: %v", songID.String(), userID.String(), err)
+		return errors.New("failed to unstar song")
+	}
+	return nil
+}
+
+// StarAlbum marks an album of the catalog as favorite.
+func (s *favoriteService) StarAlbum(ctx context.Context, userID, albumID uuid.UUID) error {
+	if _, err := s.albumDB.GetAlbumByID(ctx, albumID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrAlbumNotFound
+		}
+		log.Printf("Service: Failed to get album %s: %v", albumID.String(), err)
+		return errors.New("failed to star album")
+	}
+	if err := s.favoriteDB.AddFavoriteAlbum(ctx, userID, albumID); err != nil {
+		log.Printf("Service: Failed to star album %s for user %s: %v", albumID.String(), userID.String(), err)
+		return errors.New("failed to star album")
+	}
+	return nil
+}
+
+// UnstarAlbum removes an album from the favorites of a user.
+func (s *favoriteService) UnstarAlbum(ctx context.Context, userID, albumID uuid.UUID) error {
+	if err := s.favoriteDB.RemoveFavoriteAlbum(ctx, userID, albumID); err != nil {
+		log.Printf("Service: Failed to unstar album %s for user %s: %v", albumID.String(), userID.String(), err)
+		return errors.New("failed to unstar album")
+	}
+	return nil
+}
+
+// StarArtist marks an artist of the catalog as favorite.
+func (s *favoriteService) StarArtist(ctx context.Context, userID, artistID uuid.UUID) error {
+	if _, err := s.artistDB.GetArtistByID(ctx, artistID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrArtistNotFound
+		}
+		log.Printf("Service: Failed to get artist %s: %v", artistID.String(), err)
+		return errors.New("failed to star artist")
+	}
+	if err := s.favoriteDB.AddFavoriteArtist(ctx, userID, artistID); err != nil {
+		log.Printf("Service: Failed to star artist %s for user %s: %v", artistID.String(), userID.String(), err)
+		return errors.New("failed to star artist")
+	}
+	return nil
+}
+
+// UnstarArtist removes an artist from the favorites of a user.
+func (s *favoriteService) UnstarArtist(ctx context.Context, userID, artistID uuid.UUID) error {
+	if err := s.favoriteDB.RemoveFavoriteArtist(ctx, userID, artistID); err != nil {
+		log.Printf("Service: Failed to unstar artist %s for user %s: %v", artistID.String(), userID.String(), err)
+		return errors.New("failed to unstar artist")
+	}
+	return nil
+}
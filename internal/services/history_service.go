@@ -0,0 +1,169 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/demispreviotto/cajitamusical/cajitamusical-backend/internal/db"
+	"github.com/demispreviotto/cajitamusical/cajitamusical-backend/internal/dto/history"
+	"github.com/demispreviotto/cajitamusical/cajitamusical-backend/internal/dto/song"
+	"github.com/demispreviotto/cajitamusical/cajitamusical-backend/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Errors returned by HistoryServicer.
+var (
+	ErrPlayNotFound        = errors.New("play not found")
+	ErrPlayIDRequired      = errors.New("play_id is required for progress events")
+	ErrPlayAlreadyFinished = errors.New("play already finished")
+)
+
+// defaultHistoryLimit is the page size used when the client does not send one.
+const defaultHistoryLimit = 50
+
+// HistoryServicer defines the interface for play history business logic.
+type HistoryServicer interface {
+	RecordEvent(ctx context.Context, userID uuid.UUID, input history.PlayEventInput) (*history.PlayEventResponse, error)
+	GetHistory(ctx context.Context, userID uuid.UUID, query history.ListHistoryQuery) (*history.ListHistoryResponse, error)
+}
+
+// historyService is the concrete implementation of HistoryServicer.
+type historyService struct {
+	songAnnotator
+	historyDB db.HistoryDBer
+	songDB    db.SongDBer
+}
+
+// NewHistoryService creates a new instance of HistoryService.
+func NewHistoryService(historyDB db.HistoryDBer, songDB db.SongDBer, favoriteDB db.FavoriteDBer) HistoryServicer {
+	return &historyService{
+		songAnnotator: songAnnotator{favoriteDB: favoriteDB, historyDB: historyDB},
+		historyDB:     historyDB,
+		songDB:        songDB,
+	}
+}
+
+// RecordEvent stores a playback event. "start" opens a new play, "progress"
+// updates its position, and "completed"/"skipped" close it. Finished plays are
+// what play counts and skip statistics are computed from.
+func (s *historyService) RecordEvent(ctx context.Context, userID uuid.UUID, input history.PlayEventInput) (*history.PlayEventResponse, error) {
+	now := time.Now()
+
+	// Events that open a new play: "start", and a completion/skip reported without a play ID
+	if input.Event == history.EventStart || (input.PlayID == nil && input.Event != history.EventProgress) {
+		if _, err := s.songDB.GetSongByID(ctx, input.SongID); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, ErrSongNotFound
+			}
+			log.Printf("Service: Failed to get song %s: %v", input.SongID.String(), err)
+			return nil, errors.New("failed to record play event")
+		}
+
+		play := &models.PlayHistory{
+			UserID:          userID,
+			SongID:          input.SongID,
+			Status:          models.PlayStatusStarted,
+			PositionSeconds: input.PositionSeconds,
+			StartedAt:       now,
+		}
+		applyPlayEvent(play, input, now)
+
+		if err := s.historyDB.CreatePlay(ctx, play); err != nil {
+			log.Printf("Service: Failed to create play for user %s: %v", userID.String(), err)
+			return nil, errors.New("failed to record play event")
+		}
+		return mapPlayToEventResponse(play), nil
+	}
+
+	if input.PlayID == nil {
+		return nil, ErrPlayIDRequired
+	}
+
+	play, err := s.historyDB.GetPlayByID(ctx, *input.PlayID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPlayNotFound
+		}
+		log.Printf("Service: Failed to get play %s: %v", input.PlayID.String(), err)
+		return nil, errors.New("failed to record play event")
+	}
+	if play.UserID != userID || play.SongID != input.SongID {
+		return nil, ErrPlayNotFound
+	}
+	if play.Status != models.PlayStatusStarted {
+		return nil, ErrPlayAlreadyFinished
+	}
+
+	play.PositionSeconds = input.PositionSeconds
+	applyPlayEvent(play, input, now)
+
+	if err := s.historyDB.UpdatePlay(ctx, play); err != nil {
+		log.Printf("Service: Failed to update play %s: %v", play.ID.String(), err)
+		return nil, errors.New("failed to record play event")
+	}
+	return mapPlayToEventResponse(play), nil
+}
+
+// GetHistory returns a page of the play history of a user, most recent first.
+func (s *historyService) GetHistory(ctx context.Context, userID uuid.UUID, query history.ListHistoryQuery) (*history.ListHistoryResponse, error) {
+	limit := query.Limit
+	if limit == 0 {
+		limit = defaultHistoryLimit
+	}
+
+	plays, total, err := s.historyDB.GetHistoryByUser(ctx, userID, limit, query.Offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get play history from DB: %w", err)
+	}
+
+	response := &history.ListHistoryResponse{
+		Entries: make([]history.HistoryEntryResponse, 0, len(plays)),
+		Total:   int(total),
+		Limit:   limit,
+		Offset:  query.Offset,
+	}
+	for i := range plays {
+		play := &plays[i]
+		response.Entries = append(response.Entries, history.HistoryEntryResponse{
+			PlayID:          play.ID,
+			Status:          play.Status,
+			PositionSeconds: play.PositionSeconds,
+			StartedAt:       play.StartedAt,
+			EndedAt:         play.EndedAt,
+			Song:            mapSongToResponse(&play.Song),
+		})
+	}
+
+	songs := make([]*song.SongResponse, 0, len(response.Entries))
+	for i := range response.Entries {
+		songs = append(songs, &response.Entries[i].Song)
+	}
+	if err := s.annotate(ctx, userID, songs); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// applyPlayEvent closes a play when the event is "completed" or "skipped".
+func applyPlayEvent(play *models.PlayHistory, input history.PlayEventInput, now time.Time) {
+	switch input.Event {
+	case history.EventCompleted:
+		play.Status = models.PlayStatusCompleted
+		play.EndedAt = &now
+	case history.EventSkipped:
+		play.Status = models.PlayStatusSkipped
+		play.EndedAt = &now
+	}
+}
+
+func mapPlayToEventResponse(play *models.PlayHistory) *history.PlayEventResponse {
+	return &history.PlayEventResponse{
+		PlayID:          play.ID,
+		Status:          play.Status,
+		PositionSeconds: play.PositionSeconds,
+	}
+}
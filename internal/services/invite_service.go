@@ -0,0 +1,116 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/demispreviotto/cajitamusical/cajitamusical-backend/internal/db"
+	"github.com/demispreviotto/cajitamusical/cajitamusical-backend/internal/dto/invite"
+	"github.com/demispreviotto/cajitamusical/cajitamusical-backend/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ErrInviteNotFound is returned when revoking an unknown invite.
+var ErrInviteNotFound = errors.New("invite not found")
+
+// InviteServicer defines the interface for managing invites (admins only).
+// Invites are redeemed by UserServicer.RegisterUser.
+type InviteServicer interface {
+	CreateInvite(ctx context.Context, createdBy uuid.UUID, input invite.CreateInviteInput) (*invite.CreateInviteResponse, error)
+	ListInvites(ctx context.Context) ([]invite.InviteResponse, error)
+	RevokeInvite(ctx context.Context, inviteID uuid.UUID) error
+}
+
+// inviteService is the concrete implementation of InviteServicer.
+type inviteService struct {
+	inviteDB db.InviteDBer
+}
+
+// NewInviteService creates a new instance of InviteService.
+func NewInviteService(inviteDB db.InviteDBer) InviteServicer {
+	return &inviteService{inviteDB: inviteDB}
+}
+
+// CreateInvite creates an invite and returns its code, which is not stored
+// and cannot be retrieved later.
+func (s *inviteService) CreateInvite(ctx context.Context, createdBy uuid.UUID, input invite.CreateInviteInput) (*invite.CreateInviteResponse, error) {
+	code, err := newTokenSecret("")
+	if err != nil {
+		log.Printf("Service: Failed to generate invite code: %v", err)
+		return nil, errors.New("failed to create invite")
+	}
+
+	inviteModel := &models.Invite{
+		CodeHash:  models.HashToken(code),
+		Hint:      code[:6],
+		Role:      models.RoleUser,
+		MaxUses:   1,
+		CreatedBy: &createdBy,
+	}
+	if input.Role != "" {
+		inviteModel.Role = input.Role
+	}
+	if input.MaxUses != nil {
+		inviteModel.MaxUses = *input.MaxUses
+	}
+	if input.ExpiresInDays != nil {
+		expiresAt := time.Now().AddDate(0, 0, *input.ExpiresInDays)
+		inviteModel.ExpiresAt = &expiresAt
+	}
+
+	if err := s.inviteDB.CreateInvite(ctx, inviteModel); err != nil {
+		log.Printf("Service: Failed to create invite: %v", err)
+		return nil, errors.New("failed to create invite")
+	}
+	log.Printf("Service: Invite %s (role %s, %d uses) created by %s", inviteModel.Hint, inviteModel.Role, inviteModel.MaxUses, createdBy.String())
+
+	return &invite.CreateInviteResponse{
+		InviteResponse: mapInviteToResponse(inviteModel, time.Now()),
+		Code:           code,
+	}, nil
+}
+
+// ListInvites returns every invite, including expired and used up ones.
+func (s *inviteService) ListInvites(ctx context.Context) ([]invite.InviteResponse, error) {
+	invites, err := s.inviteDB.ListInvites(ctx)
+	if err != nil {
+		log.Printf("Service: Failed to list invites: %v", err)
+		return nil, errors.New("failed to retrieve invites")
+	}
+
+	now := time.Now()
+	response := make([]invite.InviteResponse, 0, len(invites))
+	for i := range invites {
+		response = append(response, mapInviteToResponse(&invites[i], now))
+	}
+	return response, nil
+}
+
+// RevokeInvite deletes an invite so it can no longer be redeemed.
+func (s *inviteService) RevokeInvite(ctx context.Context, inviteID uuid.UUID) error {
+	if err := s.inviteDB.DeleteInvite(ctx, inviteID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrInviteNotFound
+		}
+		log.Printf("Service: Failed to revoke invite %s: %v", inviteID.String(), err)
+		return errors.New("failed to revoke invite")
+	}
+	return nil
+}
+
+func mapInviteToResponse(inviteModel *models.Invite, now time.Time) invite.InviteResponse {
+	return invite.InviteResponse{
+		ID:        inviteModel.ID,
+		Hint:      inviteModel.Hint,
+		Role:      inviteModel.Role,
+		MaxUses:   inviteModel.MaxUses,
+		Uses:      inviteModel.Uses,
+		ExpiresAt: inviteModel.ExpiresAt,
+		CreatedBy: inviteModel.CreatedBy,
+		CreatedAt: inviteModel.CreatedAt,
+		Usable:    inviteModel.Usable(now),
+	}
+}
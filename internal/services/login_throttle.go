@@ -0,0 +1,127 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/demispreviotto/cajitamusical/cajitamusical-backend/internal/models"
+)
+
+// ErrLoginThrottled is wrapped by LoginThrottledError.
+var ErrLoginThrottled = errors.New("too many failed login attempts")
+
+// LoginThrottledError is returned by Login while a username or client IP has
+// to wait before trying again.
+type LoginThrottledError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LoginThrottledError) Error() string {
+	return fmt.Sprintf("%v, retry in %s", ErrLoginThrottled, e.RetryAfter.Round(time.Second))
+}
+
+func (e *LoginThrottledError) Unwrap() error {
+	return ErrLoginThrottled
+}
+
+// loginLimits are the thresholds of one kind of throttle key.
+type loginLimits struct {
+	freeAttempts int // Failures allowed before the backoff starts
+	maxAttempts  int // Failures that lock the key for lockoutDuration
+}
+
+// loginThrottlePolicy decides how long a username or IP has to wait after
+// failed logins: nothing for the first free attempts, then a delay doubling
+// from baseDelay with each failure, and a lockout once maxAttempts is reached.
+// Failures are forgotten after window without new ones.
+type loginThrottlePolicy struct {
+	user            loginLimits
+	ip              loginLimits // Higher: many users may share an address. Also for passkey logins
+	baseDelay       time.Duration
+	lockoutDuration time.Duration
+	window          time.Duration
+}
+
+// loginThrottlePolicyFromEnv reads LOGIN_MAX_ATTEMPTS (10 by default),
+// LOGIN_MAX_ATTEMPTS_PER_IP (50) and LOGIN_LOCKOUT_DURATION (15m).
+func loginThrottlePolicyFromEnv() loginThrottlePolicy {
+	policy := loginThrottlePolicy{
+		user:            loginLimits{freeAttempts: 3, maxAttempts: 10},
+		ip:              loginLimits{freeAttempts: 10, maxAttempts: 50},
+		baseDelay:       time.Second,
+		lockoutDuration: 15 * time.Minute,
+		window:          time.Hour,
+	}
+	if n, err := strconv.Atoi(os.Getenv("LOGIN_MAX_ATTEMPTS")); err == nil && n > 0 {
+		policy.user.maxAttempts = n
+	}
+	if n, err := strconv.Atoi(os.Getenv("LOGIN_MAX_ATTEMPTS_PER_IP")); err == nil && n > 0 {
+		policy.ip.maxAttempts = n
+	}
+	if d, err := time.ParseDuration(os.Getenv("LOGIN_LOCKOUT_DURATION")); err == nil && d > 0 {
+		policy.lockoutDuration = d
+	}
+	if policy.window < policy.lockoutDuration {
+		policy.window = policy.lockoutDuration
+	}
+	return policy
+}
+
+// userThrottleKey and ipThrottleKey build the keys of models.LoginThrottle.
+func userThrottleKey(username string) string {
+	return "user:" + strings.ToLower(username)
+}
+
+func ipThrottleKey(ipAddress string) string {
+	return "ip:" + ipAddress
+}
+
+// passkeyThrottleKey counts the passkey logins started from an IP and not
+// completed. Each one stores a challenge, so they are limited like failures.
+func passkeyThrottleKey(ipAddress string) string {
+	return "passkey:" + ipAddress
+}
+
+// check returns a *LoginThrottledError if any of throttles has to wait at now.
+func (p loginThrottlePolicy) check(throttles []models.LoginThrottle, now time.Time) error {
+	var retryAfter time.Duration
+	for _, throttle := range throttles {
+		retryAfter = max(retryAfter, p.retryAfter(throttle, now))
+	}
+	if retryAfter > 0 {
+		return &LoginThrottledError{RetryAfter: retryAfter}
+	}
+	return nil
+}
+
+// retryAfter returns how long the key of throttle must still wait at now, or
+// zero if it may try again.
+func (p loginThrottlePolicy) retryAfter(throttle models.LoginThrottle, now time.Time) time.Duration {
+	if now.Sub(throttle.LastFailureAt) >= p.window {
+		return 0
+	}
+
+	limits := p.user
+	if strings.HasPrefix(throttle.Key, "ip:") || strings.HasPrefix(throttle.Key, "passkey:") {
+		limits = p.ip
+	}
+
+	var delay time.Duration
+	switch {
+	case throttle.Failures >= limits.maxAttempts:
+		delay = p.lockoutDuration
+	case throttle.Failures > limits.freeAttempts:
+		delay = p.baseDelay
+		for i := limits.freeAttempts + 1; i < throttle.Failures && delay < p.lockoutDuration; i++ {
+			delay *= 2
+		}
+		delay = min(delay, p.lockoutDuration)
+	default:
+		return 0
+	}
+	return max(throttle.LastFailureAt.Add(delay).Sub(now), 0)
+}
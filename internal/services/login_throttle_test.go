@@ -0,0 +1,82 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/demispreviotto/cajitamusical/cajitamusical-backend/internal/models"
+)
+
+func TestLoginThrottlePolicyRetryAfter(t *testing.T) {
+	policy := loginThrottlePolicy{
+		user:            loginLimits{freeAttempts: 3, maxAttempts: 10},
+		ip:              loginLimits{freeAttempts: 10, maxAttempts: 50},
+		baseDelay:       time.Second,
+		lockoutDuration: 15 * time.Minute,
+		window:          time.Hour,
+	}
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		key      string
+		failures int
+		sinceAgo time.Duration // Time since the last failure
+		want     time.Duration
+	}{
+		{"free attempts", "user:alice", 3, 0, 0},
+		{"first delay", "user:alice", 4, 0, time.Second},
+		{"delay doubles", "user:alice", 5, 0, 2 * time.Second},
+		{"delay doubles again", "user:alice", 7, 0, 8 * time.Second},
+		{"delay partly waited", "user:alice", 7, 3 * time.Second, 5 * time.Second},
+		{"delay fully waited", "user:alice", 7, 8 * time.Second, 0},
+		{"lockout at max attempts", "user:alice", 10, 0, 15 * time.Minute},
+		{"lockout partly waited", "user:alice", 12, 5 * time.Minute, 10 * time.Minute},
+		{"lockout over", "user:alice", 12, 15 * time.Minute, 0},
+		{"failures forgotten after the window", "user:alice", 12, time.Hour, 0},
+		{"ip free attempts", "ip:192.0.2.1", 10, 0, 0},
+		{"ip first delay", "ip:192.0.2.1", 11, 0, time.Second},
+		{"ip delay capped by the lockout", "ip:192.0.2.1", 49, 0, 15 * time.Minute},
+		{"ip lockout", "ip:192.0.2.1", 50, 0, 15 * time.Minute},
+		{"passkey logins use the ip limits", "passkey:192.0.2.1", 10, 0, 0},
+		{"passkey login lockout", "passkey:192.0.2.1", 50, 0, 15 * time.Minute},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			throttle := models.LoginThrottle{Key: tt.key, Failures: tt.failures, LastFailureAt: now.Add(-tt.sinceAgo)}
+			if got := policy.retryAfter(throttle, now); got != tt.want {
+				t.Errorf("retryAfter = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoginThrottlePolicyCheck(t *testing.T) {
+	policy := loginThrottlePolicy{
+		user:            loginLimits{freeAttempts: 3, maxAttempts: 10},
+		ip:              loginLimits{freeAttempts: 10, maxAttempts: 50},
+		baseDelay:       time.Second,
+		lockoutDuration: 15 * time.Minute,
+		window:          time.Hour,
+	}
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	if err := policy.check(nil, now); err != nil {
+		t.Fatalf("check without failures: %v", err)
+	}
+
+	// The longest wait of the username and the IP wins
+	throttles := []models.LoginThrottle{
+		{Key: "user:alice", Failures: 5, LastFailureAt: now},
+		{Key: "ip:192.0.2.1", Failures: 50, LastFailureAt: now},
+	}
+	err := policy.check(throttles, now)
+	var throttled *LoginThrottledError
+	if !errors.As(err, &throttled) || !errors.Is(err, ErrLoginThrottled) {
+		t.Fatalf("check: error = %v, want a LoginThrottledError", err)
+	}
+	if throttled.RetryAfter != 15*time.Minute {
+		t.Errorf("RetryAfter = %s, want 15m", throttled.RetryAfter)
+	}
+}
@@ -0,0 +1,313 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/demispreviotto/cajitamusical/cajitamusical-backend/internal/db"
+	"github.com/demispreviotto/cajitamusical/cajitamusical-backend/internal/dto/passkey"
+	"github.com/demispreviotto/cajitamusical/cajitamusical-backend/internal/models"
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/protocol/webauthncbor"
+	"github.com/go-webauthn/webauthn/protocol/webauthncose"
+	"github.com/go-webauthn/webauthn/webauthn"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Errors returned by PasskeyServicer and by the passkey login.
+var (
+	ErrInvalidPasskey  = errors.New("passkey could not be verified")
+	ErrPasskeyExists   = errors.New("passkey already registered")
+	ErrPasskeyNotFound = errors.New("passkey not found")
+)
+
+// PasskeyServicer defines the interface for registering and managing the
+// passkeys of a user. Logging in with them is done by AuthServicer.
+type PasskeyServicer interface {
+	BeginRegistration(ctx context.Context, userModel *models.User, input passkey.BeginRegistrationInput) (*passkey.RegistrationOptionsResponse, error)
+	FinishRegistration(ctx context.Context, userID uuid.UUID, input passkey.FinishRegistrationInput) (*passkey.PasskeyResponse, error)
+	ListPasskeys(ctx context.Context, userID uuid.UUID) ([]passkey.PasskeyResponse, error)
+	DeletePasskey(ctx context.Context, userID, passkeyID uuid.UUID) error
+}
+
+// passkeyTimeout is how long the browser waits for the authenticator, and so
+// how long a challenge is kept.
+const passkeyTimeout = 5 * time.Minute
+
+// passkeyService is the concrete implementation of PasskeyServicer.
+type passkeyService struct {
+	passkeyDB   db.PasskeyDBer
+	userDB      db.UserDBer      // Password confirming a new passkey
+	twoFactorDB db.TwoFactorDBer // Code confirming a new passkey, with two-factor authentication
+	webAuthn    *webauthn.WebAuthn
+}
+
+// NewPasskeyService creates a new instance of PasskeyService.
+func NewPasskeyService(passkeyDB db.PasskeyDBer, userDB db.UserDBer, twoFactorDB db.TwoFactorDBer, webAuthn *webauthn.WebAuthn) PasskeyServicer {
+	return &passkeyService{passkeyDB: passkeyDB, userDB: userDB, twoFactorDB: twoFactorDB, webAuthn: webAuthn}
+}
+
+// WebAuthnFromEnv configures the WebAuthn relying party from WEBAUTHN_RP_ID
+// (the host of FRONTEND_ORIGIN by default), WEBAUTHN_RP_NAME ("Cajita
+// Musical") and WEBAUTHN_ORIGINS (comma-separated, FRONTEND_ORIGIN by default).
+// User verification is required: a passkey replaces both the password and the
+// second factor. Attestation is not requested, so any authenticator the user
+// controls is accepted.
+func WebAuthnFromEnv() (*webauthn.WebAuthn, error) {
+	frontendOrigin := strings.TrimSuffix(os.Getenv("FRONTEND_ORIGIN"), "/")
+	config := &webauthn.Config{
+		RPID:          os.Getenv("WEBAUTHN_RP_ID"),
+		RPDisplayName: os.Getenv("WEBAUTHN_RP_NAME"),
+		AuthenticatorSelection: protocol.AuthenticatorSelection{
+			ResidentKey:      protocol.ResidentKeyRequirementPreferred,
+			UserVerification: protocol.VerificationRequired,
+		},
+		AttestationPreference: protocol.PreferNoAttestation,
+		Timeouts: webauthn.TimeoutsConfig{
+			Login:        webauthn.TimeoutConfig{Timeout: passkeyTimeout},
+			Registration: webauthn.TimeoutConfig{Timeout: passkeyTimeout},
+		},
+	}
+	if config.RPID == "" {
+		if origin, err := url.Parse(frontendOrigin); err == nil {
+			config.RPID = origin.Hostname()
+		}
+	}
+	if config.RPDisplayName == "" {
+		config.RPDisplayName = "Cajita Musical"
+	}
+	for _, origin := range strings.Split(os.Getenv("WEBAUTHN_ORIGINS"), ",") {
+		if origin = strings.TrimSpace(origin); origin != "" {
+			config.RPOrigins = append(config.RPOrigins, strings.TrimSuffix(origin, "/"))
+		}
+	}
+	if len(config.RPOrigins) == 0 && frontendOrigin != "" {
+		config.RPOrigins = []string{frontendOrigin}
+	}
+	if config.RPID == "" {
+		return nil, errors.New("WEBAUTHN_RP_ID or FRONTEND_ORIGIN must be set")
+	}
+	return webauthn.New(config)
+}
+
+// BeginRegistration stores a new challenge and returns the options for the
+// browser to create a passkey for the user. A passkey signs in on its own, so
+// the password, and the two-factor code if enabled, must be given first.
+func (s *passkeyService) BeginRegistration(ctx context.Context, userModel *models.User, input passkey.BeginRegistrationInput) (*passkey.RegistrationOptionsResponse, error) {
+	if err := verifyUserPassword(ctx, s.userDB, userModel.ID, input.Password); err != nil {
+		return nil, err
+	}
+	twoFactor, err := s.twoFactorDB.GetTwoFactor(ctx, userModel.ID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		log.Printf("Service: Failed to get two-factor authentication of user %s: %v", userModel.ID.String(), err)
+		return nil, errors.New("failed to start passkey registration")
+	}
+	if twoFactor != nil && twoFactor.Enabled() {
+		if err := checkTwoFactorCode(ctx, s.twoFactorDB, twoFactor, input.Code, time.Now()); err != nil {
+			return nil, err
+		}
+	}
+
+	passkeys, err := s.passkeyDB.ListPasskeys(ctx, userModel.ID)
+	if err != nil {
+		log.Printf("Service: Failed to list passkeys of user %s: %v", userModel.ID.String(), err)
+		return nil, errors.New("failed to start passkey registration")
+	}
+	user := &passkeyUser{user: userModel, passkeys: passkeys}
+	creation, _, err := s.webAuthn.BeginRegistration(user, webauthn.WithExclusions(webauthn.Credentials(user.WebAuthnCredentials()).CredentialDescriptors()))
+	if err != nil {
+		log.Printf("Service: Failed to create passkey registration options: %v", err)
+		return nil, errors.New("failed to start passkey registration")
+	}
+	challenge, err := createWebAuthnChallenge(ctx, s.passkeyDB, models.WebAuthnRegistration, &userModel.ID, creation.Response.Challenge)
+	if err != nil {
+		return nil, err
+	}
+
+	return &passkey.RegistrationOptionsResponse{
+		ChallengeID: challenge.ID,
+		PublicKey:   creation.Response,
+	}, nil
+}
+
+// FinishRegistration verifies the credential created by the browser for the
+// challenge and stores it as a passkey of the user.
+func (s *passkeyService) FinishRegistration(ctx context.Context, userID uuid.UUID, input passkey.FinishRegistrationInput) (*passkey.PasskeyResponse, error) {
+	challenge, err := s.passkeyDB.ConsumeWebAuthnChallenge(ctx, input.ChallengeID, models.WebAuthnRegistration, time.Now())
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidPasskey
+		}
+		log.Printf("Service: Failed to get passkey registration challenge: %v", err)
+		return nil, errors.New("failed to register passkey")
+	}
+	if challenge.UserID == nil || *challenge.UserID != userID {
+		return nil, ErrInvalidPasskey
+	}
+
+	parsed, err := input.Credential.Parse()
+	if err != nil {
+		log.Printf("Service: Passkey registration of user %s rejected: %v", userID.String(), err)
+		return nil, ErrInvalidPasskey
+	}
+	// Registration only reads the user handle, checked against the challenge
+	credential, err := s.webAuthn.CreateCredential(&passkeyUser{user: &models.User{ID: userID}}, webAuthnSession(s.webAuthn, challenge, userID), parsed)
+	if err != nil {
+		log.Printf("Service: Passkey registration of user %s rejected: %v", userID.String(), err)
+		return nil, ErrInvalidPasskey
+	}
+	// The algorithm is read from the key: browsers may omit it from the response
+	var publicKey webauthncose.PublicKeyData
+	if err := webauthncbor.Unmarshal(credential.PublicKey, &publicKey); err != nil {
+		log.Printf("Service: Passkey registration of user %s rejected: %v", userID.String(), err)
+		return nil, ErrInvalidPasskey
+	}
+
+	_, err = s.passkeyDB.GetPasskeyByCredentialID(ctx, credential.ID)
+	if err == nil {
+		return nil, ErrPasskeyExists
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		log.Printf("Service: Failed to look up passkey credential: %v", err)
+		return nil, errors.New("failed to register passkey")
+	}
+
+	passkeyModel := &models.Passkey{
+		UserID:         userID,
+		Name:           input.Name,
+		CredentialID:   credential.ID,
+		PublicKey:      credential.PublicKey,
+		Algorithm:      publicKey.Algorithm,
+		SignCount:      int64(credential.Authenticator.SignCount),
+		BackupEligible: credential.Flags.BackupEligible,
+	}
+	for _, transport := range credential.Transport {
+		passkeyModel.Transports = append(passkeyModel.Transports, string(transport))
+	}
+	if passkeyModel.Name == "" {
+		passkeyModel.Name = "Passkey"
+	}
+	if err := s.passkeyDB.CreatePasskey(ctx, passkeyModel); err != nil {
+		log.Printf("Service: Failed to store passkey of user %s: %v", userID.String(), err)
+		return nil, errors.New("failed to register passkey")
+	}
+	log.Printf("Service: Passkey %q registered for user %s", passkeyModel.Name, userID.String())
+
+	response := mapPasskeyToResponse(passkeyModel)
+	return &response, nil
+}
+
+// ListPasskeys returns the passkeys of the user.
+func (s *passkeyService) ListPasskeys(ctx context.Context, userID uuid.UUID) ([]passkey.PasskeyResponse, error) {
+	passkeys, err := s.passkeyDB.ListPasskeys(ctx, userID)
+	if err != nil {
+		log.Printf("Service: Failed to list passkeys of user %s: %v", userID.String(), err)
+		return nil, errors.New("failed to retrieve passkeys")
+	}
+
+	response := make([]passkey.PasskeyResponse, 0, len(passkeys))
+	for i := range passkeys {
+		response = append(response, mapPasskeyToResponse(&passkeys[i]))
+	}
+	return response, nil
+}
+
+// DeletePasskey removes a passkey of the user, e.g. of a lost phone.
+func (s *passkeyService) DeletePasskey(ctx context.Context, userID, passkeyID uuid.UUID) error {
+	if err := s.passkeyDB.DeletePasskey(ctx, userID, passkeyID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrPasskeyNotFound
+		}
+		log.Printf("Service: Failed to delete passkey %s: %v", passkeyID.String(), err)
+		return errors.New("failed to delete passkey")
+	}
+	return nil
+}
+
+// createWebAuthnChallenge stores the challenge of a ceremony, valid for as
+// long as the browser waits for the authenticator.
+func createWebAuthnChallenge(ctx context.Context, passkeyDB db.PasskeyDBer, ceremony string, userID *uuid.UUID, random []byte) (*models.WebAuthnChallenge, error) {
+	challenge := &models.WebAuthnChallenge{
+		UserID:    userID,
+		Ceremony:  ceremony,
+		Challenge: random,
+		ExpiresAt: time.Now().Add(passkeyTimeout),
+	}
+	if err := passkeyDB.CreateWebAuthnChallenge(ctx, challenge); err != nil {
+		log.Printf("Service: Failed to store WebAuthn challenge: %v", err)
+		return nil, errors.New("failed to start passkey " + ceremony)
+	}
+	return challenge, nil
+}
+
+// webAuthnSession rebuilds the session data of a stored challenge for the
+// user with the given handle. Only the challenge is stored: the rest follows
+// from the relying party configuration, the same for every ceremony.
+func webAuthnSession(webAuthn *webauthn.WebAuthn, challenge *models.WebAuthnChallenge, userID uuid.UUID) webauthn.SessionData {
+	session := webauthn.SessionData{
+		Challenge:        protocol.URLEncodedBase64(challenge.Challenge).String(),
+		RelyingPartyID:   webAuthn.Config.RPID,
+		UserID:           userID[:],
+		UserVerification: webAuthn.Config.AuthenticatorSelection.UserVerification,
+	}
+	if challenge.Ceremony == models.WebAuthnRegistration {
+		session.CredParams = webauthn.CredentialParametersDefault()
+	}
+	return session
+}
+
+// passkeyUser is a user with their passkeys, as seen by the WebAuthn
+// ceremonies. The user handle is the user ID.
+type passkeyUser struct {
+	user     *models.User
+	passkeys []models.Passkey
+}
+
+func (u *passkeyUser) WebAuthnID() []byte {
+	return u.user.ID[:]
+}
+
+func (u *passkeyUser) WebAuthnName() string {
+	return u.user.Username
+}
+
+func (u *passkeyUser) WebAuthnDisplayName() string {
+	if u.user.Name != "" {
+		return u.user.Name
+	}
+	return u.user.Username
+}
+
+func (u *passkeyUser) WebAuthnCredentials() []webauthn.Credential {
+	credentials := make([]webauthn.Credential, 0, len(u.passkeys))
+	for _, passkeyModel := range u.passkeys {
+		credential := webauthn.Credential{
+			ID:              passkeyModel.CredentialID,
+			PublicKey:       passkeyModel.PublicKey,
+			AttestationType: "none",
+			Flags:           webauthn.CredentialFlags{BackupEligible: passkeyModel.BackupEligible},
+			Authenticator:   webauthn.Authenticator{SignCount: uint32(passkeyModel.SignCount)},
+		}
+		for _, transport := range passkeyModel.Transports {
+			credential.Transport = append(credential.Transport, protocol.AuthenticatorTransport(transport))
+		}
+		credentials = append(credentials, credential)
+	}
+	return credentials
+}
+
+func mapPasskeyToResponse(passkeyModel *models.Passkey) passkey.PasskeyResponse {
+	return passkey.PasskeyResponse{
+		ID:             passkeyModel.ID,
+		Name:           passkeyModel.Name,
+		BackupEligible: passkeyModel.BackupEligible,
+		LastUsedAt:     passkeyModel.LastUsedAt,
+		CreatedAt:      passkeyModel.CreatedAt,
+	}
+}
@@ -0,0 +1,324 @@
+package services
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"testing"
+
+	"github.com/demispreviotto/cajitamusical/cajitamusical-backend/internal/models"
+	"github.com/fxamacker/cbor/v2"
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/protocol/webauthncbor"
+	"github.com/go-webauthn/webauthn/protocol/webauthncose"
+	"github.com/google/uuid"
+)
+
+const testOrigin = "https://music.example.com"
+
+func TestWebAuthnFromEnv(t *testing.T) {
+	tests := []struct {
+		name        string
+		env         map[string]string
+		wantRPID    string
+		wantName    string
+		wantOrigins []string
+		wantErr     bool
+	}{
+		{
+			name:        "defaults from FRONTEND_ORIGIN",
+			env:         map[string]string{"FRONTEND_ORIGIN": "https://music.example.com/"},
+			wantRPID:    "music.example.com",
+			wantName:    "Cajita Musical",
+			wantOrigins: []string{"https://music.example.com"},
+		},
+		{
+			name: "explicit relying party",
+			env: map[string]string{
+				"FRONTEND_ORIGIN":  "https://music.example.com",
+				"WEBAUTHN_RP_ID":   "example.com",
+				"WEBAUTHN_RP_NAME": "Music",
+				"WEBAUTHN_ORIGINS": "https://music.example.com/, https://radio.example.com",
+			},
+			wantRPID:    "example.com",
+			wantName:    "Music",
+			wantOrigins: []string{"https://music.example.com", "https://radio.example.com"},
+		},
+		{
+			name:    "no relying party",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, key := range []string{"FRONTEND_ORIGIN", "WEBAUTHN_RP_ID", "WEBAUTHN_RP_NAME", "WEBAUTHN_ORIGINS"} {
+				t.Setenv(key, tt.env[key])
+			}
+			webAuthn, err := WebAuthnFromEnv()
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("WebAuthnFromEnv() succeeded, want an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("WebAuthnFromEnv: %v", err)
+			}
+			config := webAuthn.Config
+			if config.RPID != tt.wantRPID || config.RPDisplayName != tt.wantName {
+				t.Errorf("relying party = %q %q, want %q %q", config.RPID, config.RPDisplayName, tt.wantRPID, tt.wantName)
+			}
+			if len(config.RPOrigins) != len(tt.wantOrigins) {
+				t.Fatalf("RPOrigins = %v, want %v", config.RPOrigins, tt.wantOrigins)
+			}
+			for i := range tt.wantOrigins {
+				if config.RPOrigins[i] != tt.wantOrigins[i] {
+					t.Errorf("RPOrigins = %v, want %v", config.RPOrigins, tt.wantOrigins)
+				}
+			}
+		})
+	}
+}
+
+// testAuthenticator is a software authenticator with an ES256 credential. It
+// answers the ceremonies with the JSON a browser sends.
+type testAuthenticator struct {
+	key          *ecdsa.PrivateKey
+	credentialID []byte
+	userHandle   []byte
+}
+
+func newTestAuthenticator(t *testing.T, userID uuid.UUID) *testAuthenticator {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	return &testAuthenticator{key: key, credentialID: []byte(uuid.NewString()), userHandle: userID[:]}
+}
+
+// authData builds the authenticator data for the relying party, with the
+// attested credential when registering. The user is present and verified.
+func (a *testAuthenticator) authData(t *testing.T, rpID string, signCount uint32, attested bool) []byte {
+	t.Helper()
+	rpIDHash := sha256.Sum256([]byte(rpID))
+	flags := byte(protocol.FlagUserPresent | protocol.FlagUserVerified)
+	if attested {
+		flags |= byte(protocol.FlagAttestedCredentialData)
+	}
+	data := append(rpIDHash[:], flags)
+	data = binary.BigEndian.AppendUint32(data, signCount)
+	if attested {
+		coseKey, err := cbor.Marshal(map[int]any{
+			1:  2,  // kty: EC2
+			3:  -7, // alg: ES256
+			-1: 1,  // crv: P-256
+			-2: a.key.X.FillBytes(make([]byte, 32)),
+			-3: a.key.Y.FillBytes(make([]byte, 32)),
+		})
+		if err != nil {
+			t.Fatalf("Marshal COSE key: %v", err)
+		}
+		data = append(data, make([]byte, 16)...) // AAGUID
+		data = binary.BigEndian.AppendUint16(data, uint16(len(a.credentialID)))
+		data = append(data, a.credentialID...)
+		data = append(data, coseKey...)
+	}
+	return data
+}
+
+func testClientData(t *testing.T, ceremony string, challenge []byte) []byte {
+	t.Helper()
+	data, err := json.Marshal(map[string]string{
+		"type":      ceremony,
+		"challenge": base64.RawURLEncoding.EncodeToString(challenge),
+		"origin":    testOrigin,
+	})
+	if err != nil {
+		t.Fatalf("Marshal client data: %v", err)
+	}
+	return data
+}
+
+// decodeResponse decodes the JSON of a credential into the type bound by
+// the passkey handlers.
+func decodeResponse(t *testing.T, response map[string]any, into any) {
+	t.Helper()
+	data, err := json.Marshal(response)
+	if err != nil {
+		t.Fatalf("Marshal credential: %v", err)
+	}
+	if err := json.Unmarshal(data, into); err != nil {
+		t.Fatalf("Unmarshal credential: %v", err)
+	}
+}
+
+// register answers navigator.credentials.create() with a "none" attestation.
+func (a *testAuthenticator) register(t *testing.T, rpID string, challenge []byte) *protocol.ParsedCredentialCreationData {
+	t.Helper()
+	attestation, err := cbor.Marshal(map[string]any{
+		"fmt":      "none",
+		"attStmt":  map[string]any{},
+		"authData": a.authData(t, rpID, 0, true),
+	})
+	if err != nil {
+		t.Fatalf("Marshal attestation: %v", err)
+	}
+	var response protocol.CredentialCreationResponse
+	decodeResponse(t, map[string]any{
+		"id":    base64.RawURLEncoding.EncodeToString(a.credentialID),
+		"rawId": base64.RawURLEncoding.EncodeToString(a.credentialID),
+		"type":  "public-key",
+		"response": map[string]any{
+			"clientDataJSON":    base64.RawURLEncoding.EncodeToString(testClientData(t, "webauthn.create", challenge)),
+			"attestationObject": base64.RawURLEncoding.EncodeToString(attestation),
+			"transports":        []string{"internal"},
+		},
+	}, &response)
+	parsed, err := response.Parse()
+	if err != nil {
+		t.Fatalf("Parse registration: %v", err)
+	}
+	return parsed
+}
+
+// assert answers navigator.credentials.get(), signing with the credential key.
+func (a *testAuthenticator) assert(t *testing.T, rpID string, challenge []byte, signCount uint32) *protocol.ParsedCredentialAssertionData {
+	t.Helper()
+	authData := a.authData(t, rpID, signCount, false)
+	clientData := testClientData(t, "webauthn.get", challenge)
+	clientDataHash := sha256.Sum256(clientData)
+	digest := sha256.Sum256(append(append([]byte{}, authData...), clientDataHash[:]...))
+	signature, err := ecdsa.SignASN1(rand.Reader, a.key, digest[:])
+	if err != nil {
+		t.Fatalf("SignASN1: %v", err)
+	}
+
+	var response protocol.CredentialAssertionResponse
+	decodeResponse(t, map[string]any{
+		"id":    base64.RawURLEncoding.EncodeToString(a.credentialID),
+		"rawId": base64.RawURLEncoding.EncodeToString(a.credentialID),
+		"type":  "public-key",
+		"response": map[string]any{
+			"clientDataJSON":    base64.RawURLEncoding.EncodeToString(clientData),
+			"authenticatorData": base64.RawURLEncoding.EncodeToString(authData),
+			"signature":         base64.RawURLEncoding.EncodeToString(signature),
+			"userHandle":        base64.RawURLEncoding.EncodeToString(a.userHandle),
+		},
+	}, &response)
+	parsed, err := response.Parse()
+	if err != nil {
+		t.Fatalf("Parse assertion: %v", err)
+	}
+	return parsed
+}
+
+// TestPasskeyCeremonies runs the ceremonies the way the services do: only
+// the challenge is kept between the two steps, and the passkey is stored as
+// a models.Passkey.
+func TestPasskeyCeremonies(t *testing.T) {
+	t.Setenv("FRONTEND_ORIGIN", testOrigin)
+	for _, key := range []string{"WEBAUTHN_RP_ID", "WEBAUTHN_RP_NAME", "WEBAUTHN_ORIGINS"} {
+		t.Setenv(key, "")
+	}
+	webAuthn, err := WebAuthnFromEnv()
+	if err != nil {
+		t.Fatalf("WebAuthnFromEnv: %v", err)
+	}
+	rpID := webAuthn.Config.RPID
+	userModel := &models.User{ID: uuid.New(), Username: "alice"}
+	authenticator := newTestAuthenticator(t, userModel.ID)
+
+	// Registration
+	creation, _, err := webAuthn.BeginRegistration(&passkeyUser{user: userModel})
+	if err != nil {
+		t.Fatalf("BeginRegistration: %v", err)
+	}
+	if creation.Response.AuthenticatorSelection.UserVerification != protocol.VerificationRequired {
+		t.Errorf("registration UserVerification = %q, want required", creation.Response.AuthenticatorSelection.UserVerification)
+	}
+	challenge := &models.WebAuthnChallenge{Ceremony: models.WebAuthnRegistration, UserID: &userModel.ID, Challenge: creation.Response.Challenge}
+	credential, err := webAuthn.CreateCredential(&passkeyUser{user: userModel}, webAuthnSession(webAuthn, challenge, userModel.ID), authenticator.register(t, rpID, creation.Response.Challenge))
+	if err != nil {
+		t.Fatalf("CreateCredential: %v", err)
+	}
+	var publicKey webauthncose.PublicKeyData
+	if err := webauthncbor.Unmarshal(credential.PublicKey, &publicKey); err != nil || publicKey.Algorithm != -7 {
+		t.Errorf("public key algorithm = %d (%v), want ES256", publicKey.Algorithm, err)
+	}
+	passkeyModel := models.Passkey{
+		UserID:       userModel.ID,
+		CredentialID: credential.ID,
+		PublicKey:    credential.PublicKey,
+		Algorithm:    publicKey.Algorithm,
+		SignCount:    int64(credential.Authenticator.SignCount),
+		Transports:   []string{"internal"},
+	}
+
+	// Another user cannot finish the registration
+	other := uuid.New()
+	if _, err := webAuthn.CreateCredential(&passkeyUser{user: &models.User{ID: other}}, webAuthnSession(webAuthn, challenge, userModel.ID), authenticator.register(t, rpID, creation.Response.Challenge)); err == nil {
+		t.Errorf("CreateCredential for another user succeeded")
+	}
+
+	// Login
+	tests := []struct {
+		name          string
+		storedCount   int64
+		signCount     uint32
+		wrongRPID     bool
+		wrongUser     bool
+		wantErr       bool
+		wantCloneWarn bool
+	}{
+		{name: "valid", storedCount: 4, signCount: 5},
+		{name: "valid without counter", storedCount: 0, signCount: 0},
+		{name: "counter did not increase", storedCount: 7, signCount: 7, wantCloneWarn: true},
+		{name: "counter went backwards", storedCount: 7, signCount: 3, wantCloneWarn: true},
+		{name: "signed for another relying party", signCount: 1, wrongRPID: true, wantErr: true},
+		{name: "passkey of another user", signCount: 1, wrongUser: true, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stored := passkeyModel
+			stored.SignCount = tt.storedCount
+			user := &passkeyUser{user: userModel, passkeys: []models.Passkey{stored}}
+
+			assertion, _, err := webAuthn.BeginLogin(user)
+			if err != nil {
+				t.Fatalf("BeginLogin: %v", err)
+			}
+			challenge := &models.WebAuthnChallenge{Ceremony: models.WebAuthnLogin, UserID: &userModel.ID, Challenge: assertion.Response.Challenge}
+
+			signRPID := rpID
+			if tt.wrongRPID {
+				signRPID = "evil.example.com"
+			}
+			response := authenticator.assert(t, signRPID, assertion.Response.Challenge, tt.signCount)
+			if tt.wrongUser {
+				user = &passkeyUser{user: &models.User{ID: other}, passkeys: []models.Passkey{stored}}
+			}
+
+			credential, err := webAuthn.ValidateLogin(user, webAuthnSession(webAuthn, challenge, user.user.ID), response)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("ValidateLogin succeeded, want an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ValidateLogin: %v", err)
+			}
+			if credential.Authenticator.CloneWarning != tt.wantCloneWarn {
+				t.Errorf("CloneWarning = %t, want %t", credential.Authenticator.CloneWarning, tt.wantCloneWarn)
+			}
+			if !tt.wantCloneWarn && credential.Authenticator.SignCount != tt.signCount {
+				t.Errorf("SignCount = %d, want %d", credential.Authenticator.SignCount, tt.signCount)
+			}
+		})
+	}
+}
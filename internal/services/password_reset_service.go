@@ -0,0 +1,203 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/demispreviotto/cajitamusical/cajitamusical-backend/internal/db"
+	"github.com/demispreviotto/cajitamusical/cajitamusical-backend/internal/dto/user"
+	"github.com/demispreviotto/cajitamusical/cajitamusical-backend/internal/mail"
+	"github.com/demispreviotto/cajitamusical/cajitamusical-backend/internal/models"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// ErrInvalidResetToken is returned for unknown, used or expired reset links.
+var ErrInvalidResetToken = errors.New("invalid or expired password reset token")
+
+const (
+	// passwordResetResendInterval is the minimum time between two reset
+	// emails to the same user.
+	passwordResetResendInterval = time.Minute
+	// mailSendTimeout bounds the emails sent in the background.
+	mailSendTimeout = 30 * time.Second
+)
+
+// PasswordResetServicer defines the interface for the forgotten password flow.
+type PasswordResetServicer interface {
+	RequestReset(ctx context.Context, input user.ForgotPasswordInput) error
+	ResetPassword(ctx context.Context, input user.ResetPasswordInput) error
+	CleanupExpiredTokens(ctx context.Context) error
+}
+
+// passwordResetService is the concrete implementation of PasswordResetServicer.
+type passwordResetService struct {
+	userDB          db.UserDBer
+	passkeyDB       db.PasskeyDBer // Passkeys listed in the notice of the new password
+	passwordResetDB db.PasswordResetDBer
+	mailer          mail.Mailer
+	resetURL        string        // Page of the frontend receiving the token
+	tokenTTL        time.Duration // Validity of a reset link
+}
+
+// NewPasswordResetService creates a new instance of PasswordResetService. The
+// link sent points to PASSWORD_RESET_URL (FRONTEND_ORIGIN + "/reset-password"
+// by default) and is valid for PASSWORD_RESET_TOKEN_TTL (1h by default).
+func NewPasswordResetService(userDB db.UserDBer, passkeyDB db.PasskeyDBer, passwordResetDB db.PasswordResetDBer, mailer mail.Mailer) PasswordResetServicer {
+	resetURL := os.Getenv("PASSWORD_RESET_URL")
+	if resetURL == "" {
+		resetURL = strings.TrimSuffix(os.Getenv("FRONTEND_ORIGIN"), "/") + "/reset-password"
+	}
+	tokenTTL := time.Hour
+	if d, err := time.ParseDuration(os.Getenv("PASSWORD_RESET_TOKEN_TTL")); err == nil && d > 0 {
+		tokenTTL = d
+	}
+	return &passwordResetService{
+		userDB:          userDB,
+		passkeyDB:       passkeyDB,
+		passwordResetDB: passwordResetDB,
+		mailer:          mailer,
+		resetURL:        resetURL,
+		tokenTTL:        tokenTTL,
+	}
+}
+
+// RequestReset emails a reset link to the user with the given email. It
+// succeeds whether or not the email is registered, so it cannot be used to
+// find out which addresses have an account; the email is sent in the
+// background for the same reason.
+func (s *passwordResetService) RequestReset(ctx context.Context, input user.ForgotPasswordInput) error {
+	userModel, err := s.userDB.GetUserByEmail(ctx, input.Email)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		log.Printf("Service: Failed to get user by email for password reset: %v", err)
+		return errors.New("failed to request password reset")
+	}
+	if userModel.DisabledAt != nil {
+		log.Printf("Service: Password reset requested for disabled user %s, ignored", userModel.Username)
+		return nil
+	}
+
+	now := time.Now()
+	latest, err := s.passwordResetDB.GetLatestPasswordResetToken(ctx, userModel.ID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		log.Printf("Service: Failed to get latest password reset of user %s: %v", userModel.ID.String(), err)
+		return errors.New("failed to request password reset")
+	}
+	if latest != nil && now.Sub(latest.CreatedAt) < passwordResetResendInterval {
+		return nil
+	}
+
+	secret, err := newTokenSecret("")
+	if err != nil {
+		log.Printf("Service: Failed to generate password reset token: %v", err)
+		return errors.New("failed to request password reset")
+	}
+	resetToken := &models.PasswordResetToken{
+		UserID:    userModel.ID,
+		TokenHash: models.HashToken(secret),
+		ExpiresAt: now.Add(s.tokenTTL),
+	}
+	if err := s.passwordResetDB.CreatePasswordResetToken(ctx, resetToken); err != nil {
+		log.Printf("Service: Failed to store password reset token of user %s: %v", userModel.ID.String(), err)
+		return errors.New("failed to request password reset")
+	}
+
+	link := s.resetURL + "?token=" + url.QueryEscape(secret)
+	msg := mail.Message{
+		To:      userModel.Email,
+		Subject: "Reset your Cajita Musical password",
+		Body: fmt.Sprintf("Hi %s,\n\nSomeone asked to reset the password of your account %q. "+
+			"To choose a new password, open this link within %s:\n\n%s\n\n"+
+			"If it was not you, ignore this email: your password will not change.\n",
+			userModel.Name, userModel.Username, s.tokenTTL, link),
+	}
+	go sendMailInBackground(s.mailer, msg)
+	return nil
+}
+
+// ResetPassword sets a new password with a reset token, which can only be
+// used once, and revokes every session and API token of the user. The user is
+// told by email, along with the passkeys that can still sign in.
+func (s *passwordResetService) ResetPassword(ctx context.Context, input user.ResetPasswordInput) error {
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(input.NewPassword), bcrypt.DefaultCost)
+	if err != nil {
+		log.Printf("Service: Failed to hash reset password: %v", err)
+		return errors.New("failed to process password")
+	}
+
+	resetToken, err := s.passwordResetDB.ResetPassword(ctx, models.HashToken(input.Token), time.Now(), string(hashedPassword))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrInvalidResetToken
+		}
+		log.Printf("Service: Failed to reset password: %v", err)
+		return errors.New("failed to reset password")
+	}
+	log.Printf("Service: Password of user %s reset", resetToken.UserID.String())
+
+	userModel, err := s.userDB.GetUserByID(ctx, resetToken.UserID)
+	if err != nil {
+		log.Printf("Service: Failed to get user %s to notify the password reset: %v", resetToken.UserID.String(), err)
+		return nil
+	}
+	sendPasswordChangedNotice(ctx, s.passkeyDB, s.mailer, userModel)
+	return nil
+}
+
+// CleanupExpiredTokens removes the expired reset tokens.
+func (s *passwordResetService) CleanupExpiredTokens(ctx context.Context) error {
+	if err := s.passwordResetDB.DeleteExpiredPasswordResetTokens(ctx, time.Now()); err != nil {
+		log.Printf("Service: Error during password reset token cleanup: %v", err)
+		return errors.New("failed to cleanup password reset tokens")
+	}
+	return nil
+}
+
+// sendMailInBackground sends msg outside of the request that triggered it,
+// logging failures.
+func sendMailInBackground(mailer mail.Mailer, msg mail.Message) {
+	ctx, cancel := context.WithTimeout(context.Background(), mailSendTimeout)
+	defer cancel()
+	if err := mailer.Send(ctx, msg); err != nil {
+		log.Printf("Service: Failed to send %q email to %s: %v", msg.Subject, msg.To, err)
+	}
+}
+
+// sendPasswordChangedNotice tells the user that their password changed and
+// their other sessions and API tokens were revoked. Passkeys are not: they
+// are listed so the user can remove any they do not recognise.
+func sendPasswordChangedNotice(ctx context.Context, passkeyDB db.PasskeyDBer, mailer mail.Mailer, userModel *models.User) {
+	if userModel.Email == "" {
+		return
+	}
+	passkeys, err := passkeyDB.ListPasskeys(ctx, userModel.ID)
+	if err != nil {
+		log.Printf("Service: Failed to list passkeys of user %s: %v", userModel.ID.String(), err)
+	}
+
+	var body strings.Builder
+	fmt.Fprintf(&body, "Hi %s,\n\nThe password of your account %q was changed. "+
+		"Your other sessions and your API tokens were revoked.\n", userModel.Name, userModel.Username)
+	if len(passkeys) > 0 {
+		body.WriteString("\nThese passkeys can still sign in to your account. Remove any you do not recognise:\n\n")
+		for _, passkey := range passkeys {
+			fmt.Fprintf(&body, "  - %s (added %s)\n", passkey.Name, passkey.CreatedAt.Format(time.DateOnly))
+		}
+	}
+	body.WriteString("\nIf you did not make this change, reset your password and contact an administrator.\n")
+
+	go sendMailInBackground(mailer, mail.Message{
+		To:      userModel.Email,
+		Subject: "Your Cajita Musical password was changed",
+		Body:    body.String(),
+	})
+}
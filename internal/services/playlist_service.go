@@ -0,0 +1,263 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/demispreviotto/cajitamusical/cajitamusical-backend/internal/db"
+	"github.com/demispreviotto/cajitamusical/cajitamusical-backend/internal/dto/playlist"
+	"github.com/demispreviotto/cajitamusical/cajitamusical-backend/internal/dto/song"
+	"github.com/demispreviotto/cajitamusical/cajitamusical-backend/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Errors returned by PlaylistServicer. Handlers map them to HTTP status codes.
+var (
+	ErrPlaylistNotFound     = errors.New("playlist not found")
+	ErrPlaylistItemNotFound = errors.New("playlist item not found")
+	ErrInvalidPlaylistName  = errors.New("invalid playlist name")
+	ErrInvalidPlaylistOrder = errors.New("item_ids must list every playlist item exactly once")
+)
+
+// PlaylistServicer defines the interface for playlist business logic.
+// Every method is scoped to the owner: playlists of other users are reported as not found.
+type PlaylistServicer interface {
+	ListPlaylists(ctx context.Context, userID uuid.UUID) (*playlist.ListPlaylistsResponse, error)
+	CreatePlaylist(ctx context.Context, userID uuid.UUID, input playlist.CreatePlaylistInput) (*playlist.PlaylistResponse, error)
+	GetPlaylist(ctx context.Context, userID, playlistID uuid.UUID) (*playlist.PlaylistResponse, error)
+	UpdatePlaylist(ctx context.Context, userID, playlistID uuid.UUID, input playlist.UpdatePlaylistInput) (*playlist.PlaylistResponse, error)
+	DeletePlaylist(ctx context.Context, userID, playlistID uuid.UUID) error
+	AddSong(ctx context.Context, userID, playlistID uuid.UUID, input playlist.AddSongInput) (*playlist.PlaylistResponse, error)
+	RemoveItem(ctx context.Context, userID, playlistID, itemID uuid.UUID) (*playlist.PlaylistResponse, error)
+	ReorderPlaylist(ctx context.Context, userID, playlistID uuid.UUID, input playlist.ReorderPlaylistInput) (*playlist.PlaylistResponse, error)
+}
+
+// playlistService is the concrete implementation of PlaylistServicer.
+type playlistService struct {
+	songAnnotator
+	playlistDB db.PlaylistDBer
+	songDB     db.SongDBer
+}
+
+// NewPlaylistService creates a new instance of PlaylistService.
+func NewPlaylistService(playlistDB db.PlaylistDBer, songDB db.SongDBer, favoriteDB db.FavoriteDBer, historyDB db.HistoryDBer) PlaylistServicer {
+	return &playlistService{
+		songAnnotator: songAnnotator{favoriteDB: favoriteDB, historyDB: historyDB},
+		playlistDB:    playlistDB,
+		songDB:        songDB,
+	}
+}
+
+// ListPlaylists returns the playlists of a user, without their tracks.
+func (s *playlistService) ListPlaylists(ctx context.Context, userID uuid.UUID) (*playlist.ListPlaylistsResponse, error) {
+	playlists, err := s.playlistDB.GetPlaylistsByUser(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get playlists from DB: %w", err)
+	}
+
+	response := &playlist.ListPlaylistsResponse{Playlists: make([]playlist.PlaylistResponse, 0, len(playlists))}
+	for _, p := range playlists {
+		response.Playlists = append(response.Playlists, playlist.PlaylistResponse{
+			ID:                   p.ID,
+			Name:                 p.Name,
+			Description:          p.Description,
+			TrackCount:           p.TrackCount,
+			TotalDurationSeconds: p.TotalDurationSeconds,
+			CreatedAt:            p.CreatedAt,
+			UpdatedAt:            p.UpdatedAt,
+		})
+	}
+	return response, nil
+}
+
+// CreatePlaylist creates an empty playlist owned by userID.
+func (s *playlistService) CreatePlaylist(ctx context.Context, userID uuid.UUID, input playlist.CreatePlaylistInput) (*playlist.PlaylistResponse, error) {
+	name := strings.TrimSpace(input.Name)
+	if name == "" {
+		return nil, ErrInvalidPlaylistName
+	}
+
+	playlistModel := &models.Playlist{
+		UserID:      userID,
+		Name:        name,
+		Description: strings.TrimSpace(input.Description),
+	}
+	if err := s.playlistDB.CreatePlaylist(ctx, playlistModel); err != nil {
+		log.Printf("Service: Failed to create playlist for user %s: %v", userID.String(), err)
+		return nil, errors.New("failed to create playlist")
+	}
+
+	response := mapPlaylistToResponse(playlistModel)
+	return &response, nil
+}
+
+// GetPlaylist returns a playlist with its tracks in order.
+func (s *playlistService) GetPlaylist(ctx context.Context, userID, playlistID uuid.UUID) (*playlist.PlaylistResponse, error) {
+	playlistModel, err := s.getOwnedPlaylist(ctx, userID, playlistID)
+	if err != nil {
+		return nil, err
+	}
+	return s.playlistResponse(ctx, userID, playlistModel)
+}
+
+// UpdatePlaylist renames a playlist and/or changes its description.
+func (s *playlistService) UpdatePlaylist(ctx context.Context, userID, playlistID uuid.UUID, input playlist.UpdatePlaylistInput) (*playlist.PlaylistResponse, error) {
+	playlistModel, err := s.getOwnedPlaylist(ctx, userID, playlistID)
+	if err != nil {
+		return nil, err
+	}
+
+	if input.Name != nil {
+		name := strings.TrimSpace(*input.Name)
+		if name == "" {
+			return nil, ErrInvalidPlaylistName
+		}
+		playlistModel.Name = name
+	}
+	if input.Description != nil {
+		playlistModel.Description = strings.TrimSpace(*input.Description)
+	}
+
+	if err := s.playlistDB.UpdatePlaylist(ctx, playlistModel); err != nil {
+		log.Printf("Service: Failed to update playlist %s: %v", playlistID.String(), err)
+		return nil, errors.New("failed to update playlist")
+	}
+	return s.playlistResponse(ctx, userID, playlistModel)
+}
+
+// DeletePlaylist removes a playlist owned by userID.
+func (s *playlistService) DeletePlaylist(ctx context.Context, userID, playlistID uuid.UUID) error {
+	if _, err := s.getOwnedPlaylist(ctx, userID, playlistID); err != nil {
+		return err
+	}
+	if err := s.playlistDB.DeletePlaylist(ctx, playlistID); err != nil {
+		log.Printf("Service: Failed to delete playlist %s: %v", playlistID.String(), err)
+		return errors.New("failed to delete playlist")
+	}
+	return nil
+}
+
+// AddSong appends a song from the library to the end of a playlist.
+func (s *playlistService) AddSong(ctx context.Context, userID, playlistID uuid.UUID, input playlist.AddSongInput) (*playlist.PlaylistResponse, error) {
+	if _, err := s.getOwnedPlaylist(ctx, userID, playlistID); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.songDB.GetSongByID(ctx, input.SongID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrSongNotFound
+		}
+		log.Printf("Service: Failed to get song %s: %v", input.SongID.String(), err)
+		return nil, errors.New("failed to add song to playlist")
+	}
+
+	if _, err := s.playlistDB.AddSong(ctx, playlistID, input.SongID); err != nil {
+		log.Printf("Service: Failed to add song %s to playlist %s: %v", input.SongID.String(), playlistID.String(), err)
+		return nil, errors.New("failed to add song to playlist")
+	}
+	return s.GetPlaylist(ctx, userID, playlistID)
+}
+
+// RemoveItem removes a single entry from a playlist.
+func (s *playlistService) RemoveItem(ctx context.Context, userID, playlistID, itemID uuid.UUID) (*playlist.PlaylistResponse, error) {
+	if _, err := s.getOwnedPlaylist(ctx, userID, playlistID); err != nil {
+		return nil, err
+	}
+
+	if err := s.playlistDB.RemoveItem(ctx, playlistID, itemID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPlaylistItemNotFound
+		}
+		log.Printf("Service: Failed to remove item %s from playlist %s: %v", itemID.String(), playlistID.String(), err)
+		return nil, errors.New("failed to remove song from playlist")
+	}
+	return s.GetPlaylist(ctx, userID, playlistID)
+}
+
+// ReorderPlaylist applies a new order to the items of a playlist.
+func (s *playlistService) ReorderPlaylist(ctx context.Context, userID, playlistID uuid.UUID, input playlist.ReorderPlaylistInput) (*playlist.PlaylistResponse, error) {
+	playlistModel, err := s.getOwnedPlaylist(ctx, userID, playlistID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(input.ItemIDs) != len(playlistModel.Items) {
+		return nil, ErrInvalidPlaylistOrder
+	}
+	remaining := make(map[uuid.UUID]bool, len(playlistModel.Items))
+	for _, item := range playlistModel.Items {
+		remaining[item.ID] = true
+	}
+	for _, id := range input.ItemIDs {
+		if !remaining[id] {
+			return nil, ErrInvalidPlaylistOrder
+		}
+		delete(remaining, id)
+	}
+
+	if err := s.playlistDB.ReorderItems(ctx, playlistID, input.ItemIDs); err != nil {
+		log.Printf("Service: Failed to reorder playlist %s: %v", playlistID.String(), err)
+		return nil, errors.New("failed to reorder playlist")
+	}
+	return s.GetPlaylist(ctx, userID, playlistID)
+}
+
+// getOwnedPlaylist loads a playlist and checks that it belongs to userID.
+func (s *playlistService) getOwnedPlaylist(ctx context.Context, userID, playlistID uuid.UUID) (*models.Playlist, error) {
+	playlistModel, err := s.playlistDB.GetPlaylistByID(ctx, playlistID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPlaylistNotFound
+		}
+		log.Printf("Service: Failed to get playlist %s from DB: %v", playlistID.String(), err)
+		return nil, errors.New("failed to retrieve playlist")
+	}
+	if playlistModel.UserID != userID {
+		return nil, ErrPlaylistNotFound
+	}
+	return playlistModel, nil
+}
+
+// playlistResponse maps a playlist to its DTO and annotates its tracks for userID.
+func (s *playlistService) playlistResponse(ctx context.Context, userID uuid.UUID, playlistModel *models.Playlist) (*playlist.PlaylistResponse, error) {
+	response := mapPlaylistToResponse(playlistModel)
+
+	tracks := make([]*song.SongResponse, 0, len(response.Tracks))
+	for i := range response.Tracks {
+		tracks = append(tracks, &response.Tracks[i].Song)
+	}
+	if err := s.annotate(ctx, userID, tracks); err != nil {
+		log.Printf("Service: Failed to annotate playlist %s: %v", playlistModel.ID.String(), err)
+		return nil, errors.New("failed to retrieve playlist")
+	}
+	return &response, nil
+}
+
+// mapPlaylistToResponse maps a playlist model, loaded with its items and songs, to its DTO.
+func mapPlaylistToResponse(p *models.Playlist) playlist.PlaylistResponse {
+	response := playlist.PlaylistResponse{
+		ID:          p.ID,
+		Name:        p.Name,
+		Description: p.Description,
+		TrackCount:  len(p.Items),
+		CreatedAt:   p.CreatedAt,
+		UpdatedAt:   p.UpdatedAt,
+		Tracks:      make([]playlist.PlaylistTrackResponse, 0, len(p.Items)),
+	}
+
+	for i := range p.Items {
+		item := &p.Items[i]
+		response.TotalDurationSeconds += item.Song.DurationSeconds
+		response.Tracks = append(response.Tracks, playlist.PlaylistTrackResponse{
+			ItemID:   item.ID,
+			Position: item.Position,
+			AddedAt:  item.AddedAt,
+			Song:     mapSongToResponse(&item.Song),
+		})
+	}
+	return response
+}
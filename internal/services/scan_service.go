@@ -0,0 +1,259 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/demispreviotto/cajitamusical/cajitamusical-backend/internal/db"
+	"github.com/demispreviotto/cajitamusical/cajitamusical-backend/internal/dto/song"
+	"github.com/google/uuid"
+)
+
+// Errors returned by ScanServicer.
+var (
+	ErrScanInProgress = db.ErrScanInProgress
+	ErrScanNotFound   = errors.New("scan not found")
+	ErrScanFinished   = errors.New("scan already finished")
+	ErrScanStopped    = errors.New("library scans are stopped")
+)
+
+// maxFinishedScanJobs is how many finished scan jobs are kept for GetScan.
+const maxFinishedScanJobs = 20
+
+// ScanServicer defines the interface for running library scans in the background.
+type ScanServicer interface {
+	StartScan(ctx context.Context) (*song.ScanJobResponse, error)
+	GetScan(jobID uuid.UUID) (*song.ScanJobResponse, error)
+	CancelScan(jobID uuid.UUID) (*song.ScanJobResponse, error)
+	WaitScan(ctx context.Context, jobID uuid.UUID) (*song.ScanJobResponse, error)
+	Stop(ctx context.Context) error
+}
+
+// scanJob is a scan started by this process.
+type scanJob struct {
+	response song.ScanJobResponse // Guarded by scanService.mu
+	cancel   context.CancelFunc
+	done     chan struct{} // Closed once the job has finished
+}
+
+// scanService is the concrete implementation of ScanServicer. Jobs live in
+// memory; only one scan runs at a time across all instances, enforced by the
+// scan lock of SongDBer.
+type scanService struct {
+	songDB db.SongDBer
+	ctx    context.Context // Parent of the job contexts, cancelled by Stop
+	cancel context.CancelFunc
+	wg     sync.WaitGroup // Running jobs
+
+	mu       sync.Mutex
+	jobs     map[uuid.UUID]*scanJob
+	finished []uuid.UUID // Finished jobs, oldest first
+	running  *scanJob
+}
+
+// NewScanService creates a new instance of ScanService.
+func NewScanService(songDB db.SongDBer) ScanServicer {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &scanService{
+		songDB: songDB,
+		ctx:    ctx,
+		cancel: cancel,
+		jobs:   make(map[uuid.UUID]*scanJob),
+	}
+}
+
+// StartScan starts a library scan in the background and returns its job. If
+// a scan is already running it returns ErrScanInProgress, along with the job
+// when it was started by this instance. After Stop it returns ErrScanStopped.
+func (s *scanService) StartScan(ctx context.Context) (*song.ScanJobResponse, error) {
+	if response, err := s.checkCanStart(); err != nil {
+		return response, err
+	}
+
+	// The scan lock is a database round-trip, so it is taken without holding s.mu
+	unlock, err := s.songDB.TryLockScan(ctx)
+	if err != nil {
+		if errors.Is(err, ErrScanInProgress) {
+			response, _ := s.checkCanStart()
+			return response, ErrScanInProgress
+		}
+		log.Printf("Service: Failed to take the library scan lock: %v", err)
+		return nil, errors.New("failed to start music scan")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Stop may have been called while the lock was being taken
+	if s.ctx.Err() != nil {
+		unlock()
+		return nil, ErrScanStopped
+	}
+
+	// The scan outlives the request that started it, but not the service
+	scanCtx, cancel := context.WithCancel(s.ctx)
+	job := &scanJob{
+		response: song.ScanJobResponse{
+			ID:        uuid.New(),
+			Status:    song.ScanStatusRunning,
+			Progress:  song.ScanProgress{Phase: song.ScanPhaseQueued},
+			StartedAt: time.Now(),
+		},
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+	s.jobs[job.response.ID] = job
+	s.running = job
+
+	s.wg.Add(1)
+	go s.run(scanCtx, job, unlock)
+
+	response := job.response
+	return &response, nil
+}
+
+// checkCanStart returns ErrScanStopped after Stop, or ErrScanInProgress along
+// with the running job when this instance is already scanning.
+func (s *scanService) checkCanStart() (*song.ScanJobResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.ctx.Err() != nil {
+		return nil, ErrScanStopped
+	}
+	if s.running != nil {
+		response := s.running.response
+		return &response, ErrScanInProgress
+	}
+	return nil, nil
+}
+
+// GetScan returns the current state of a scan job.
+func (s *scanService) GetScan(jobID uuid.UUID) (*song.ScanJobResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job, ok := s.jobs[jobID]
+	if !ok {
+		return nil, ErrScanNotFound
+	}
+	response := job.response
+	return &response, nil
+}
+
+// CancelScan asks a running scan to stop. The job reports the "cancelled"
+// status once the scan has stopped.
+func (s *scanService) CancelScan(jobID uuid.UUID) (*song.ScanJobResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job, ok := s.jobs[jobID]
+	if !ok {
+		return nil, ErrScanNotFound
+	}
+	if job.response.Status != song.ScanStatusRunning {
+		return nil, ErrScanFinished
+	}
+	job.cancel()
+	log.Printf("Service: Cancellation requested for music scan %s", jobID.String())
+
+	response := job.response
+	return &response, nil
+}
+
+// WaitScan waits for a scan job to finish, or for ctx to be done, and returns
+// its final state.
+func (s *scanService) WaitScan(ctx context.Context, jobID uuid.UUID) (*song.ScanJobResponse, error) {
+	s.mu.Lock()
+	job, ok := s.jobs[jobID]
+	s.mu.Unlock()
+	if !ok {
+		return nil, ErrScanNotFound
+	}
+
+	select {
+	case <-job.done:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	response := job.response
+	return &response, nil
+}
+
+// Stop prevents new scans, cancels the running one and waits for it to
+// return, or for ctx to be done.
+func (s *scanService) Stop(ctx context.Context) error {
+	s.mu.Lock()
+	s.cancel()
+	s.mu.Unlock()
+
+	stopped := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+		log.Println("Service: Library scans stopped")
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("library scan did not stop in time: %w", ctx.Err())
+	}
+}
+
+// run performs the scan of job and records its outcome.
+func (s *scanService) run(ctx context.Context, job *scanJob, unlock func()) {
+	defer s.wg.Done()
+	defer unlock()
+	defer job.cancel()
+
+	log.Printf("Starting music library scan %s...", job.response.ID.String())
+	result, err := s.songDB.ScanAndStoreSongs(ctx, func(progress song.ScanProgress) {
+		s.mu.Lock()
+		job.response.Progress = progress
+		s.mu.Unlock()
+	})
+
+	switch {
+	case err == nil:
+		log.Printf("Music library scan complete. Added: %d, Updated: %d, Removed: %d, Unchanged: %d. Errors: %d",
+			result.Added, result.Updated, result.Removed, result.Unchanged, len(result.Errors))
+	case errors.Is(err, context.Canceled):
+		log.Printf("Music library scan %s cancelled", job.response.ID.String())
+	default:
+		log.Printf("Music library scan failed: %v", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	job.response.FinishedAt = &now
+	job.response.Result = result
+	switch {
+	case err == nil:
+		job.response.Status = song.ScanStatusCompleted
+	case errors.Is(err, context.Canceled):
+		job.response.Status = song.ScanStatusCancelled
+	default:
+		job.response.Status = song.ScanStatusFailed
+		job.response.Error = err.Error()
+	}
+	s.running = nil
+	close(job.done)
+
+	// Forget the oldest finished jobs
+	s.finished = append(s.finished, job.response.ID)
+	for len(s.finished) > maxFinishedScanJobs {
+		delete(s.jobs, s.finished[0])
+		s.finished = s.finished[1:]
+	}
+}
@@ -0,0 +1,95 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/demispreviotto/cajitamusical/cajitamusical-backend/internal/db"
+	"github.com/demispreviotto/cajitamusical/cajitamusical-backend/internal/dto/song"
+)
+
+// blockingSongDB runs scans that last until their context is cancelled.
+type blockingSongDB struct {
+	db.SongDBer // Calls outside the scan panic
+
+	started  chan struct{}
+	unlocked chan struct{}
+}
+
+func (b *blockingSongDB) TryLockScan(ctx context.Context) (func(), error) {
+	return func() { close(b.unlocked) }, nil
+}
+
+func (b *blockingSongDB) ScanAndStoreSongs(ctx context.Context, progress func(song.ScanProgress)) (*song.MusicScanResult, error) {
+	close(b.started)
+	<-ctx.Done()
+	return &song.MusicScanResult{}, ctx.Err()
+}
+
+func TestScanServiceStopCancelsRunningScan(t *testing.T) {
+	songDB := &blockingSongDB{started: make(chan struct{}), unlocked: make(chan struct{})}
+	scanService := NewScanService(songDB)
+
+	job, err := scanService.StartScan(context.Background())
+	if err != nil {
+		t.Fatalf("StartScan: %v", err)
+	}
+	<-songDB.started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := scanService.Stop(ctx); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+
+	// Stop only returns once the scan has finished and released the lock
+	select {
+	case <-songDB.unlocked:
+	default:
+		t.Fatalf("Stop returned before the scan lock was released")
+	}
+	finished, err := scanService.GetScan(job.ID)
+	if err != nil {
+		t.Fatalf("GetScan: %v", err)
+	}
+	if finished.Status != song.ScanStatusCancelled {
+		t.Errorf("Status = %q, want %q", finished.Status, song.ScanStatusCancelled)
+	}
+
+	if _, err := scanService.StartScan(context.Background()); !errors.Is(err, ErrScanStopped) {
+		t.Fatalf("StartScan after Stop: error = %v, want ErrScanStopped", err)
+	}
+}
+
+func TestScanServiceWaitScanReturnsFinalState(t *testing.T) {
+	songDB := &blockingSongDB{started: make(chan struct{}), unlocked: make(chan struct{})}
+	scanService := NewScanService(songDB)
+
+	job, err := scanService.StartScan(context.Background())
+	if err != nil {
+		t.Fatalf("StartScan: %v", err)
+	}
+	<-songDB.started
+
+	// Still running: WaitScan gives up with ctx
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := scanService.WaitScan(ctx, job.ID); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("WaitScan while running: error = %v, want DeadlineExceeded", err)
+	}
+
+	if _, err := scanService.CancelScan(job.ID); err != nil {
+		t.Fatalf("CancelScan: %v", err)
+	}
+	ctx, cancel = context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	finished, err := scanService.WaitScan(ctx, job.ID)
+	if err != nil {
+		t.Fatalf("WaitScan: %v", err)
+	}
+	if finished.Status != song.ScanStatusCancelled {
+		t.Errorf("Status = %q, want %q", finished.Status, song.ScanStatusCancelled)
+	}
+}
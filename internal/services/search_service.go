@@ -0,0 +1,93 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/demispreviotto/cajitamusical/cajitamusical-backend/internal/db"
+	"github.com/demispreviotto/cajitamusical/cajitamusical-backend/internal/dto/search"
+	"github.com/demispreviotto/cajitamusical/cajitamusical-backend/internal/dto/song"
+	"github.com/google/uuid"
+)
+
+// ErrInvalidSearchQuery is returned when the query has no searchable words.
+var ErrInvalidSearchQuery = errors.New("search query must contain at least one letter or digit")
+
+// defaultSearchLimit is the number of results per group when the client does not send a limit.
+const defaultSearchLimit = 20
+
+// SearchServicer defines the interface for library search.
+type SearchServicer interface {
+	Search(ctx context.Context, userID uuid.UUID, query search.SearchQuery) (*search.SearchResponse, error)
+}
+
+// searchService is the concrete implementation of SearchServicer.
+type searchService struct {
+	songAnnotator
+	searchDB db.SearchDBer
+}
+
+// NewSearchService creates a new instance of SearchService.
+func NewSearchService(searchDB db.SearchDBer, favoriteDB db.FavoriteDBer, historyDB db.HistoryDBer) SearchServicer {
+	return &searchService{
+		songAnnotator: songAnnotator{favoriteDB: favoriteDB, historyDB: historyDB},
+		searchDB:      searchDB,
+	}
+}
+
+// Search runs an accent-insensitive, prefix-matching search and returns songs,
+// albums and artists grouped and ranked by relevance.
+func (s *searchService) Search(ctx context.Context, userID uuid.UUID, query search.SearchQuery) (*search.SearchResponse, error) {
+	tsQuery := db.BuildSearchQuery(query.Q)
+	if tsQuery == "" {
+		return nil, ErrInvalidSearchQuery
+	}
+	limit := query.Limit
+	if limit == 0 {
+		limit = defaultSearchLimit
+	}
+
+	songs, err := s.searchDB.SearchSongs(ctx, tsQuery, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search songs: %w", err)
+	}
+	albums, err := s.searchDB.SearchAlbums(ctx, tsQuery, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search albums: %w", err)
+	}
+	artists, err := s.searchDB.SearchArtists(ctx, tsQuery, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search artists: %w", err)
+	}
+
+	response := &search.SearchResponse{
+		Query:   query.Q,
+		Songs:   make([]song.SongResponse, 0, len(songs)),
+		Albums:  make([]search.AlbumResult, 0, len(albums)),
+		Artists: make([]search.ArtistResult, 0, len(artists)),
+	}
+	for i := range songs {
+		response.Songs = append(response.Songs, mapSongToResponse(&songs[i]))
+	}
+	if err := s.annotate(ctx, userID, songPointers(response.Songs)); err != nil {
+		return nil, err
+	}
+	for _, a := range albums {
+		response.Albums = append(response.Albums, search.AlbumResult{
+			Album:       a.Album,
+			Artist:      a.Artist,
+			Year:        a.Year,
+			TrackCount:  a.TrackCount,
+			AlbumArtURL: albumArtURL(a.SamplePath),
+		})
+	}
+	for _, a := range artists {
+		response.Artists = append(response.Artists, search.ArtistResult{
+			Artist:     a.Artist,
+			TrackCount: a.TrackCount,
+			AlbumCount: a.AlbumCount,
+		})
+	}
+	return response, nil
+}
@@ -0,0 +1,57 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/demispreviotto/cajitamusical/cajitamusical-backend/internal/db"
+	"github.com/demispreviotto/cajitamusical/cajitamusical-backend/internal/dto/song"
+	"github.com/google/uuid"
+)
+
+// songAnnotator fills the per-user fields of SongResponse (favorite flag,
+// play count and last play) for the user making the request. Services that
+// return songs embed it.
+type songAnnotator struct {
+	favoriteDB db.FavoriteDBer
+	historyDB  db.HistoryDBer
+}
+
+// annotate sets the per-user fields of songs in place.
+func (a *songAnnotator) annotate(ctx context.Context, userID uuid.UUID, songs []*song.SongResponse) error {
+	if len(songs) == 0 {
+		return nil
+	}
+
+	songIDs := make([]uuid.UUID, 0, len(songs))
+	for _, s := range songs {
+		songIDs = append(songIDs, s.ID)
+	}
+
+	favorites, err := a.favoriteDB.GetFavoriteSongIDs(ctx, userID, songIDs)
+	if err != nil {
+		return fmt.Errorf("failed to get favorite songs from DB: %w", err)
+	}
+	stats, err := a.historyDB.GetPlayStats(ctx, userID, songIDs)
+	if err != nil {
+		return fmt.Errorf("failed to get play stats from DB: %w", err)
+	}
+
+	for _, s := range songs {
+		s.Favorite = favorites[s.ID]
+		if stat, ok := stats[s.ID]; ok {
+			s.PlayCount = stat.PlayCount
+			s.LastPlayedAt = stat.LastPlayedAt
+		}
+	}
+	return nil
+}
+
+// songPointers returns pointers to the elements of songs, for annotate.
+func songPointers(songs []song.SongResponse) []*song.SongResponse {
+	pointers := make([]*song.SongResponse, len(songs))
+	for i := range songs {
+		pointers[i] = &songs[i]
+	}
+	return pointers
+}
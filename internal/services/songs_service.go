@@ -2,6 +2,7 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"os"
@@ -14,10 +15,18 @@ import (
 	"github.com/google/uuid"
 )
 
+// Errors returned by SongServicer and other services working with songs.
+var (
+	ErrSongNotFound     = errors.New("song not found")
+	ErrInvalidYearRange = errors.New("year_from must not be greater than year_to")
+)
+
+// defaultLibraryLimit is the page size used when the client does not send one.
+const defaultLibraryLimit = 100
+
 // SongServicer defines the interface for song-related business logic.
 type SongServicer interface {
-	GetLibrary(ctx context.Context) ([]song.SongResponse, error)
-	ScanMusicLibrary(ctx context.Context) (*song.MusicScanResult, error)
+	GetLibrary(ctx context.Context, userID uuid.UUID, query song.ListSongsQuery) (*song.ListSongsResponse, error)
 	GetSongFilePath(songID string) (string, error)
 	GetAlbumArtPath(imageFileName string) (string, error)
 	// Add other song service methods here (e.g., GetSongByID, UpdateSongMetadata)
@@ -25,35 +34,52 @@ type SongServicer interface {
 
 // songService is the concrete implementation of SongServicer.
 type songService struct {
+	songAnnotator
 	songDB db.SongDBer
 }
 
 // NewSongService creates a new instance of SongService.
-func NewSongService(songDB db.SongDBer) SongServicer {
-	return &songService{songDB: songDB}
+func NewSongService(songDB db.SongDBer, favoriteDB db.FavoriteDBer, historyDB db.HistoryDBer) SongServicer {
+	return &songService{
+		songAnnotator: songAnnotator{favoriteDB: favoriteDB, historyDB: historyDB},
+		songDB:        songDB,
+	}
 }
 
-// GetLibrary retrieves the song library from the database and maps them to SongResponse DTOs.
-func (s *songService) GetLibrary(ctx context.Context) ([]song.SongResponse, error) {
-	songs, err := s.songDB.GetSongLibrary(ctx)
+// GetLibrary retrieves a page of the song library from the database and maps
+// them to SongResponse DTOs, annotated for the requesting user.
+func (s *songService) GetLibrary(ctx context.Context, userID uuid.UUID, query song.ListSongsQuery) (*song.ListSongsResponse, error) {
+	if query.YearFrom > 0 && query.YearTo > 0 && query.YearFrom > query.YearTo {
+		return nil, ErrInvalidYearRange
+	}
+	if query.Limit == 0 {
+		query.Limit = defaultLibraryLimit
+	}
+	if query.Sort == "" {
+		query.Sort = "title"
+	}
+
+	songs, total, err := s.songDB.GetSongLibrary(ctx, query)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get song library from DB: %w", err)
 	}
 
-	var songResponses []song.SongResponse
-	for _, s := range songs {
-		songResponses = append(songResponses, mapSongToResponse(&s))
+	response := &song.ListSongsResponse{
+		Songs:  make([]song.SongResponse, 0, len(songs)),
+		Total:  int(total),
+		Limit:  query.Limit,
+		Offset: query.Offset,
+	}
+	for i := range songs {
+		response.Songs = append(response.Songs, mapSongToResponse(&songs[i]))
+	}
+	if err := s.annotate(ctx, userID, songPointers(response.Songs)); err != nil {
+		return nil, err
 	}
-	return songResponses, nil
+	return response, nil
 }
 
 func mapSongToResponse(s *models.Song) song.SongResponse {
-	// Example: FilePath "Artist/Album/Song.mp3" -> AlbumArtURL "/api/album-art/Artist/Album/thumb.jpg"
-	albumArtURL := ""
-	if s.FilePath != "" {
-		albumArtURL = "/api/album-art/" + filepath.ToSlash(filepath.Join(filepath.Dir(s.FilePath), "thumb.jpg"))
-	}
-
 	return song.SongResponse{
 		ID:              s.ID,
 		Title:           s.Title,
@@ -67,8 +93,21 @@ func mapSongToResponse(s *models.Song) song.SongResponse {
 		Filename:        s.Filename,
 		CreatedAt:       s.CreatedAt,
 		UpdatedAt:       s.UpdatedAt,
-		AlbumArtURL:     albumArtURL, // Set the derived URL here
+		AlbumArtURL:     albumArtURL(s.FilePath), // Set the derived URL here
+		AlbumArtist:     s.AlbumArtist,
+		DiscNumber:      s.DiscNumber,
+		ArtistID:        s.ArtistID,
+		AlbumID:         s.AlbumID,
+	}
+}
+
+// albumArtURL derives the album art URL from the file path of a song.
+// Example: FilePath "Artist/Album/Song.mp3" -> AlbumArtURL "/api/album-art/Artist/Album/thumb.jpg"
+func albumArtURL(filePath string) string {
+	if filePath == "" {
+		return ""
 	}
+	return "/api/album-art/" + filepath.ToSlash(filepath.Join(filepath.Dir(filePath), "thumb.jpg"))
 }
 
 func (s *songService) GetAlbumArtPath(songIDStr string) (string, error) {
@@ -120,24 +159,6 @@ func (s *songService) GetAlbumArtPath(songIDStr string) (string, error) {
 	return fullPath, nil
 }
 
-// ScanMusicLibrary triggers the music directory scan and database update.
-func (s *songService) ScanMusicLibrary(ctx context.Context) (*song.MusicScanResult, error) {
-	log.Println("Starting music library scan...")
-	result, err := s.songDB.ScanAndStoreSongs(ctx)
-	if err != nil {
-		log.Printf("Music library scan failed: %v", err)
-		return nil, fmt.Errorf("failed to scan music library: %w", err)
-	}
-	log.Printf("Music library scan complete. Added: %d, Updated: %d, Removed: %d. Errors: %d",
-		result.Added, result.Updated, result.Removed, len(result.Errors))
-	if len(result.Errors) > 0 {
-		for _, errMsg := range result.Errors {
-			log.Printf("Scan Error: %s", errMsg)
-		}
-	}
-	return result, nil
-}
-
 // GetSongFilePath retrieves the full file path for a song based on its ID.
 func (s *songService) GetSongFilePath(songIDStr string) (string, error) {
 	if songIDStr == "" {
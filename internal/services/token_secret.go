@@ -0,0 +1,15 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+)
+
+// newTokenSecret returns prefix followed by 32 random bytes, URL-safe.
+func newTokenSecret(prefix string) (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return prefix + base64.RawURLEncoding.EncodeToString(buf), nil
+}
@@ -0,0 +1,267 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/demispreviotto/cajitamusical/cajitamusical-backend/internal/db"
+	"github.com/demispreviotto/cajitamusical/cajitamusical-backend/internal/dto/auth"
+	"github.com/demispreviotto/cajitamusical/cajitamusical-backend/internal/models"
+	"github.com/demispreviotto/cajitamusical/cajitamusical-backend/internal/totp"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Errors returned by TwoFactorServicer and by the second step of the login.
+var (
+	ErrTwoFactorEnabled     = errors.New("two-factor authentication is already enabled")
+	ErrTwoFactorNotEnabled  = errors.New("two-factor authentication is not enabled")
+	ErrNoTwoFactorPending   = errors.New("no two-factor enrollment to confirm")
+	ErrInvalidTwoFactorCode = errors.New("invalid two-factor code")
+)
+
+const (
+	// recoveryCodeCount is how many recovery codes are issued at a time.
+	recoveryCodeCount = 10
+	// totpSkew is how many 30 second steps of clock drift are tolerated
+	// in each direction.
+	totpSkew = 1
+)
+
+// recoveryCodeEncoding spells recovery codes in lowercase base32.
+var recoveryCodeEncoding = base32.NewEncoding("abcdefghijklmnopqrstuvwxyz234567").WithPadding(base32.NoPadding)
+
+// TwoFactorServicer defines the interface for enrolling in and managing TOTP
+// two-factor authentication. The login itself is done by AuthServicer.
+type TwoFactorServicer interface {
+	GetStatus(ctx context.Context, userID uuid.UUID) (*auth.TwoFactorStatusResponse, error)
+	BeginEnrollment(ctx context.Context, userModel *models.User) (*auth.TwoFactorSetupResponse, error)
+	ConfirmEnrollment(ctx context.Context, userID uuid.UUID, input auth.TwoFactorCodeInput) (*auth.RecoveryCodesResponse, error)
+	RegenerateRecoveryCodes(ctx context.Context, userID uuid.UUID, input auth.TwoFactorCodeInput) (*auth.RecoveryCodesResponse, error)
+	Disable(ctx context.Context, userID uuid.UUID, input auth.DisableTwoFactorInput) error
+	Reset(ctx context.Context, userID uuid.UUID) error
+}
+
+// twoFactorService is the concrete implementation of TwoFactorServicer.
+type twoFactorService struct {
+	userDB      db.UserDBer
+	twoFactorDB db.TwoFactorDBer
+	issuer      string // Name shown by authenticator apps
+}
+
+// NewTwoFactorService creates a new instance of TwoFactorService. The issuer
+// shown in authenticator apps is TOTP_ISSUER ("Cajita Musical" by default).
+func NewTwoFactorService(userDB db.UserDBer, twoFactorDB db.TwoFactorDBer) TwoFactorServicer {
+	issuer := os.Getenv("TOTP_ISSUER")
+	if issuer == "" {
+		issuer = "Cajita Musical"
+	}
+	return &twoFactorService{userDB: userDB, twoFactorDB: twoFactorDB, issuer: issuer}
+}
+
+// GetStatus reports whether the user has two-factor authentication and how
+// many recovery codes are left.
+func (s *twoFactorService) GetStatus(ctx context.Context, userID uuid.UUID) (*auth.TwoFactorStatusResponse, error) {
+	twoFactor, err := s.twoFactorDB.GetTwoFactor(ctx, userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return &auth.TwoFactorStatusResponse{}, nil
+	}
+	if err != nil {
+		log.Printf("Service: Failed to get two-factor status of user %s: %v", userID.String(), err)
+		return nil, errors.New("failed to retrieve two-factor status")
+	}
+
+	status := &auth.TwoFactorStatusResponse{
+		Enabled:     twoFactor.Enabled(),
+		Pending:     !twoFactor.Enabled(),
+		ConfirmedAt: twoFactor.ConfirmedAt,
+	}
+	if status.Enabled {
+		status.RecoveryCodesRemaining, err = s.twoFactorDB.CountRecoveryCodes(ctx, userID)
+		if err != nil {
+			log.Printf("Service: Failed to count recovery codes of user %s: %v", userID.String(), err)
+			return nil, errors.New("failed to retrieve two-factor status")
+		}
+	}
+	return status, nil
+}
+
+// BeginEnrollment generates a new secret for the user. It is not required at
+// login until ConfirmEnrollment proves the authenticator app has it; starting
+// again replaces an unconfirmed secret.
+func (s *twoFactorService) BeginEnrollment(ctx context.Context, userModel *models.User) (*auth.TwoFactorSetupResponse, error) {
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		log.Printf("Service: Failed to generate TOTP secret: %v", err)
+		return nil, errors.New("failed to start two-factor enrollment")
+	}
+
+	if err := s.twoFactorDB.StartTwoFactorEnrollment(ctx, userModel.ID, secret); err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return nil, ErrTwoFactorEnabled
+		}
+		log.Printf("Service: Failed to start two-factor enrollment of user %s: %v", userModel.ID.String(), err)
+		return nil, errors.New("failed to start two-factor enrollment")
+	}
+
+	return &auth.TwoFactorSetupResponse{
+		Secret:          secret,
+		ProvisioningURI: totp.ProvisioningURI(secret, s.issuer, userModel.Username),
+	}, nil
+}
+
+// ConfirmEnrollment enables two-factor authentication once the user enters a
+// code from the authenticator app, and returns the recovery codes.
+func (s *twoFactorService) ConfirmEnrollment(ctx context.Context, userID uuid.UUID, input auth.TwoFactorCodeInput) (*auth.RecoveryCodesResponse, error) {
+	twoFactor, err := s.twoFactorDB.GetTwoFactor(ctx, userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrNoTwoFactorPending
+	}
+	if err != nil {
+		log.Printf("Service: Failed to get two-factor enrollment of user %s: %v", userID.String(), err)
+		return nil, errors.New("failed to confirm two-factor enrollment")
+	}
+	if twoFactor.Enabled() {
+		return nil, ErrTwoFactorEnabled
+	}
+
+	step, ok := totp.Validate(twoFactor.Secret, strings.TrimSpace(input.Code), time.Now(), totpSkew)
+	if !ok {
+		return nil, ErrInvalidTwoFactorCode
+	}
+
+	codes, codeHashes, err := newRecoveryCodes()
+	if err != nil {
+		log.Printf("Service: Failed to generate recovery codes: %v", err)
+		return nil, errors.New("failed to confirm two-factor enrollment")
+	}
+	if err := s.twoFactorDB.ConfirmTwoFactor(ctx, userID, step, codeHashes); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNoTwoFactorPending
+		}
+		log.Printf("Service: Failed to confirm two-factor enrollment of user %s: %v", userID.String(), err)
+		return nil, errors.New("failed to confirm two-factor enrollment")
+	}
+	log.Printf("Service: Two-factor authentication enabled for user %s", userID.String())
+
+	return &auth.RecoveryCodesResponse{RecoveryCodes: codes}, nil
+}
+
+// RegenerateRecoveryCodes replaces the recovery codes of the user after
+// checking a current two-factor code.
+func (s *twoFactorService) RegenerateRecoveryCodes(ctx context.Context, userID uuid.UUID, input auth.TwoFactorCodeInput) (*auth.RecoveryCodesResponse, error) {
+	twoFactor, err := s.getEnabledTwoFactor(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkTwoFactorCode(ctx, s.twoFactorDB, twoFactor, input.Code, time.Now()); err != nil {
+		return nil, err
+	}
+
+	codes, codeHashes, err := newRecoveryCodes()
+	if err != nil {
+		log.Printf("Service: Failed to generate recovery codes: %v", err)
+		return nil, errors.New("failed to regenerate recovery codes")
+	}
+	if err := s.twoFactorDB.ReplaceRecoveryCodes(ctx, userID, codeHashes); err != nil {
+		log.Printf("Service: Failed to replace recovery codes of user %s: %v", userID.String(), err)
+		return nil, errors.New("failed to regenerate recovery codes")
+	}
+	return &auth.RecoveryCodesResponse{RecoveryCodes: codes}, nil
+}
+
+// Disable turns off two-factor authentication after checking the password.
+func (s *twoFactorService) Disable(ctx context.Context, userID uuid.UUID, input auth.DisableTwoFactorInput) error {
+	if err := verifyUserPassword(ctx, s.userDB, userID, input.Password); err != nil {
+		return err
+	}
+	return s.Reset(ctx, userID)
+}
+
+// Reset removes the authenticator and recovery codes of a user, for admins
+// helping someone who lost both.
+func (s *twoFactorService) Reset(ctx context.Context, userID uuid.UUID) error {
+	if err := s.twoFactorDB.DeleteTwoFactor(ctx, userID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrTwoFactorNotEnabled
+		}
+		log.Printf("Service: Failed to disable two-factor authentication of user %s: %v", userID.String(), err)
+		return errors.New("failed to disable two-factor authentication")
+	}
+	log.Printf("Service: Two-factor authentication disabled for user %s", userID.String())
+	return nil
+}
+
+func (s *twoFactorService) getEnabledTwoFactor(ctx context.Context, userID uuid.UUID) (*models.TwoFactor, error) {
+	twoFactor, err := s.twoFactorDB.GetTwoFactor(ctx, userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrTwoFactorNotEnabled
+	}
+	if err != nil {
+		log.Printf("Service: Failed to get two-factor authentication of user %s: %v", userID.String(), err)
+		return nil, errors.New("failed to retrieve two-factor authentication")
+	}
+	if !twoFactor.Enabled() {
+		return nil, ErrTwoFactorNotEnabled
+	}
+	return twoFactor, nil
+}
+
+// checkTwoFactorCode accepts either a current code from the authenticator of
+// a confirmed twoFactor or one of the user's unused recovery codes, and uses
+// it up so it cannot be given again. Wrong codes return ErrInvalidTwoFactorCode.
+func checkTwoFactorCode(ctx context.Context, twoFactorDB db.TwoFactorDBer, twoFactor *models.TwoFactor, code string, now time.Time) error {
+	code = strings.TrimSpace(code)
+	userID := twoFactor.UserID
+
+	var err error
+	if step, ok := totp.Validate(twoFactor.Secret, code, now, totpSkew); ok {
+		err = twoFactorDB.UseTOTPStep(ctx, userID, step)
+	} else if len(code) == totp.Digits {
+		return ErrInvalidTwoFactorCode // A wrong authenticator code, not a recovery code
+	} else {
+		err = twoFactorDB.UseRecoveryCode(ctx, userID, hashRecoveryCode(code))
+		if err == nil {
+			log.Printf("Service: User %s used a recovery code", userID.String())
+		}
+	}
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrInvalidTwoFactorCode
+	}
+	if err != nil {
+		log.Printf("Service: Failed to check two-factor code of user %s: %v", userID.String(), err)
+		return errors.New("failed to check two-factor code")
+	}
+	return nil
+}
+
+// newRecoveryCodes returns recoveryCodeCount codes formatted as "xxxxx-xxxxx"
+// and their hashes.
+func newRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, 0, recoveryCodeCount)
+	codeHashes := make([]string, 0, recoveryCodeCount)
+	buf := make([]byte, 10) // 16 base32 characters; the first 10 (50 bits) are kept
+	for range recoveryCodeCount {
+		if _, err := rand.Read(buf); err != nil {
+			return nil, nil, err
+		}
+		code := recoveryCodeEncoding.EncodeToString(buf)[:10]
+		codes = append(codes, code[:5]+"-"+code[5:])
+		codeHashes = append(codeHashes, hashRecoveryCode(code))
+	}
+	return codes, codeHashes, nil
+}
+
+// hashRecoveryCode hashes a recovery code ignoring case, spaces and dashes,
+// so it can be typed however it was written down.
+func hashRecoveryCode(code string) string {
+	code = strings.NewReplacer("-", "", " ", "").Replace(strings.ToLower(code))
+	return models.HashToken(code)
+}
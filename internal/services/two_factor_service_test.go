@@ -0,0 +1,95 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/demispreviotto/cajitamusical/cajitamusical-backend/internal/db"
+	"github.com/demispreviotto/cajitamusical/cajitamusical-backend/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// memoryTwoFactorDB keeps the last used step and the recovery codes of one
+// user in memory, with the same single-use rules as db.TwoFactorDBer.
+type memoryTwoFactorDB struct {
+	db.TwoFactorDBer // Calls outside the code checks panic
+
+	lastUsedStep       int64
+	recoveryCodeHashes map[string]bool
+}
+
+func (m *memoryTwoFactorDB) UseTOTPStep(ctx context.Context, userID uuid.UUID, step int64) error {
+	if step <= m.lastUsedStep {
+		return gorm.ErrRecordNotFound
+	}
+	m.lastUsedStep = step
+	return nil
+}
+
+func (m *memoryTwoFactorDB) UseRecoveryCode(ctx context.Context, userID uuid.UUID, codeHash string) error {
+	if !m.recoveryCodeHashes[codeHash] {
+		return gorm.ErrRecordNotFound
+	}
+	delete(m.recoveryCodeHashes, codeHash)
+	return nil
+}
+
+// testTOTPSecret is "12345678901234567890", the seed of the RFC 6238 test
+// vectors; 287082 is its code at T=59 (step 1).
+const testTOTPSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestCheckTwoFactorCodeRejectsReuse(t *testing.T) {
+	ctx := context.Background()
+	twoFactor := &models.TwoFactor{UserID: uuid.New(), Secret: testTOTPSecret}
+	twoFactorDB := &memoryTwoFactorDB{}
+	now := time.Unix(59, 0)
+
+	if err := checkTwoFactorCode(ctx, twoFactorDB, twoFactor, "287082", now); err != nil {
+		t.Fatalf("first use: %v", err)
+	}
+	// Still inside the window, but already used
+	for _, later := range []time.Duration{0, 20 * time.Second} {
+		if err := checkTwoFactorCode(ctx, twoFactorDB, twoFactor, " 287082 ", now.Add(later)); !errors.Is(err, ErrInvalidTwoFactorCode) {
+			t.Fatalf("reuse %s later: error = %v, want ErrInvalidTwoFactorCode", later, err)
+		}
+	}
+}
+
+func TestCheckTwoFactorCodeRejectsOlderStep(t *testing.T) {
+	ctx := context.Background()
+	twoFactor := &models.TwoFactor{UserID: uuid.New(), Secret: testTOTPSecret}
+	twoFactorDB := &memoryTwoFactorDB{lastUsedStep: 2}
+
+	// The code of step 1 is inside the window at T=60 (step 2), but a later
+	// code was already accepted
+	if err := checkTwoFactorCode(ctx, twoFactorDB, twoFactor, "287082", time.Unix(60, 0)); !errors.Is(err, ErrInvalidTwoFactorCode) {
+		t.Fatalf("error = %v, want ErrInvalidTwoFactorCode", err)
+	}
+}
+
+func TestCheckTwoFactorCodeRecoveryCodes(t *testing.T) {
+	ctx := context.Background()
+	twoFactor := &models.TwoFactor{UserID: uuid.New(), Secret: testTOTPSecret}
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		t.Fatalf("newRecoveryCodes: %v", err)
+	}
+	twoFactorDB := &memoryTwoFactorDB{recoveryCodeHashes: map[string]bool{}}
+	for _, hash := range hashes {
+		twoFactorDB.recoveryCodeHashes[hash] = true
+	}
+	now := time.Unix(59, 0)
+
+	if err := checkTwoFactorCode(ctx, twoFactorDB, twoFactor, codes[0], now); err != nil {
+		t.Fatalf("first use of a recovery code: %v", err)
+	}
+	if err := checkTwoFactorCode(ctx, twoFactorDB, twoFactor, codes[0], now); !errors.Is(err, ErrInvalidTwoFactorCode) {
+		t.Fatalf("reused recovery code: error = %v, want ErrInvalidTwoFactorCode", err)
+	}
+	if err := checkTwoFactorCode(ctx, twoFactorDB, twoFactor, "000000", now); !errors.Is(err, ErrInvalidTwoFactorCode) {
+		t.Fatalf("wrong code: error = %v, want ErrInvalidTwoFactorCode", err)
+	}
+}
@@ -0,0 +1,75 @@
+package services
+
+import "strings"
+
+// userAgentInfo is the device description shown for a session.
+type userAgentInfo struct {
+	Browser string
+	OS      string
+	Device  string // "desktop", "mobile", "tablet" or "other"
+}
+
+// userAgentRule maps a User-Agent token to a name. Rules are checked in order,
+// so more specific tokens go first (Edge and Opera also send "Chrome", Chrome
+// also sends "Safari").
+type userAgentRule struct {
+	token string
+	name  string
+}
+
+var browserRules = []userAgentRule{
+	{"Edg/", "Edge"},
+	{"OPR/", "Opera"},
+	{"Firefox/", "Firefox"},
+	{"FxiOS/", "Firefox"},
+	{"CriOS/", "Chrome"},
+	{"Chrome/", "Chrome"},
+	{"Safari/", "Safari"},
+	{"curl/", "curl"},
+	{"Wget/", "Wget"},
+}
+
+var osRules = []userAgentRule{
+	{"iPhone", "iOS"},
+	{"iPad", "iPadOS"},
+	{"Android", "Android"},
+	{"CrOS", "ChromeOS"},
+	{"Windows", "Windows"},
+	{"Mac OS X", "macOS"},
+	{"Macintosh", "macOS"},
+	{"Linux", "Linux"},
+}
+
+// parseUserAgent extracts the browser, operating system and kind of device
+// from a User-Agent header. Unknown values are left empty.
+func parseUserAgent(userAgent string) userAgentInfo {
+	info := userAgentInfo{Device: "other"}
+	if userAgent == "" {
+		return info
+	}
+
+	info.Browser = matchUserAgentRule(userAgent, browserRules)
+	info.OS = matchUserAgentRule(userAgent, osRules)
+
+	switch {
+	case strings.Contains(userAgent, "iPad") || strings.Contains(userAgent, "Tablet"):
+		info.Device = "tablet"
+	case strings.Contains(userAgent, "Mobile") || strings.Contains(userAgent, "iPhone"):
+		info.Device = "mobile"
+	case info.OS == "Android":
+		// Android browsers without "Mobile" run on tablets
+		info.Device = "tablet"
+	case info.OS != "":
+		info.Device = "desktop"
+	}
+	return info
+}
+
+func matchUserAgentRule(userAgent string, rules []userAgentRule) string {
+	for _, rule := range rules {
+		if strings.Contains(userAgent, rule.token) {
+			return rule.name
+		}
+	}
+	return ""
+}
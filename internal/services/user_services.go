@@ -3,33 +3,97 @@ package services
 import (
 	"context"
 	"errors"
+	"fmt"
 	"log" // Temporal para logging, en un proyecto grande se usaría un logger estructurado
+	"os"
+	"time"
 
 	"github.com/demispreviotto/cajitamusical/cajitamusical-backend/internal/db"
+	"github.com/demispreviotto/cajitamusical/cajitamusical-backend/internal/dto/auth"
 	"github.com/demispreviotto/cajitamusical/cajitamusical-backend/internal/dto/user"
+	"github.com/demispreviotto/cajitamusical/cajitamusical-backend/internal/mail"
 	"github.com/demispreviotto/cajitamusical/cajitamusical-backend/internal/models"
 	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm" // Para manejar errores específicos de GORM como record not found
 )
 
+// Errores devueltos por UserServicer.
+var (
+	ErrUserNotFound      = errors.New("user not found")
+	ErrInvalidPermission = errors.New("invalid permission")
+	ErrLastAdmin         = db.ErrLastAdmin
+	ErrCannotModifySelf  = errors.New("admins cannot disable or delete their own account here")
+	ErrEmailTaken        = errors.New("email already registered")
+	ErrInvalidPassword   = errors.New("current password is incorrect")
+	ErrPasswordRequired  = errors.New("current password is required to change the email")
+
+	ErrRegistrationClosed = errors.New("registration is closed")
+	ErrInviteRequired     = errors.New("registration requires an invite code")
+	ErrInvalidInvite      = errors.New("invite code is invalid, expired or used up")
+)
+
+// defaultUserListLimit is the page size used when the client does not send one.
+const defaultUserListLimit = 50
+
 // UserServicer define la interfaz para las operaciones del servicio de usuarios.
 //
 //go:generate mockgen -source=user_service.go -destination=mocks/mock_user_service.go
 type UserServicer interface {
 	RegisterUser(ctx context.Context, input user.RegisterUserInput) (*user.UserResponse, error)
+	RegistrationMode() string
 	GetUserByID(ctx context.Context, userID string) (*user.UserInfo, error) // Asume que userID es string para compatibilidad inicial, luego cambiar a uuid.UUID
-	// Agrega otros métodos de servicio de usuario aquí (ej. UpdateUser, DeleteUser)
+	UpdateUserAccess(ctx context.Context, userID uuid.UUID, input user.UpdateAccessInput) (*user.UserInfo, error)
+	BootstrapAdmin(ctx context.Context, username string) error
+	ListUsers(ctx context.Context, query user.ListUsersQuery) (*user.ListUsersResponse, error)
+	GetUserSessions(ctx context.Context, userID uuid.UUID) ([]auth.SessionResponse, error)
+	SetUserDisabled(ctx context.Context, actorID, userID uuid.UUID, disabled bool) error
+	ForcePasswordReset(ctx context.Context, userID uuid.UUID) error
+	DeleteUser(ctx context.Context, actorID, userID uuid.UUID) error
+	UpdateProfile(ctx context.Context, userID uuid.UUID, input user.UpdateProfileInput) (*user.UserInfo, error)
+	ChangePassword(ctx context.Context, userID, currentSessionID uuid.UUID, input user.ChangePasswordInput) error
+	DeleteAccount(ctx context.Context, userID uuid.UUID, input user.DeleteAccountInput) error
+	// Agrega otros métodos de servicio de usuario aquí
 }
 
 // userService es la implementación concreta de UserServicer.
 type userService struct {
-	userDB db.UserDBer // Dependencia de la interfaz de la capa DB
+	userDB        db.UserDBer               // Dependencia de la interfaz de la capa DB
+	sessionDB     db.SessionDBer            // Para revocar las sesiones de cuentas desactivadas o borradas
+	passkeyDB     db.PasskeyDBer            // Passkeys listadas en el aviso de cambio de contraseña
+	emailVerifier EmailVerificationServicer // Envía el enlace de verificación de los emails nuevos
+	mailer        mail.Mailer               // Avisa al usuario de los cambios en su cuenta
+
+	registrationMode string // models.RegistrationOpen, RegistrationInviteOnly o RegistrationClosed
+}
+
+// NewUserService crea una nueva instancia de UserService. El modo de registro
+// se lee de REGISTRATION_MODE (open por defecto).
+func NewUserService(userDB db.UserDBer, sessionDB db.SessionDBer, passkeyDB db.PasskeyDBer, emailVerifier EmailVerificationServicer, mailer mail.Mailer) UserServicer {
+	registrationMode := os.Getenv("REGISTRATION_MODE")
+	switch registrationMode {
+	case models.RegistrationOpen, models.RegistrationInviteOnly, models.RegistrationClosed:
+	case "":
+		registrationMode = models.RegistrationOpen
+	default:
+		// Ante un valor desconocido, mejor no dejar registrarse a cualquiera
+		log.Printf("Service: Unknown REGISTRATION_MODE %q, registration closed", registrationMode)
+		registrationMode = models.RegistrationClosed
+	}
+	return &userService{
+		userDB:        userDB,
+		sessionDB:     sessionDB,
+		passkeyDB:     passkeyDB,
+		emailVerifier: emailVerifier,
+		mailer:        mailer,
+
+		registrationMode: registrationMode,
+	}
 }
 
-// NewUserService crea una nueva instancia de UserService.
-func NewUserService(userDB db.UserDBer) UserServicer {
-	return &userService{userDB: userDB}
+// RegistrationMode devuelve el modo de registro configurado.
+func (s *userService) RegistrationMode() string {
+	return s.registrationMode
 }
 
 // RegisterUser maneja la lógica de negocio para registrar un nuevo usuario.
@@ -52,11 +116,28 @@ func (s *userService) RegisterUser(ctx context.Context, input user.RegisterUserI
 		Username: input.Username,
 		Email:    input.Email,
 		Name:     input.Name,
+		Role:     models.RoleUser, // CreateUser lo cambia a admin si es el primer usuario, o al de la invitación
 	}
 
-	// 3. Crear el usuario en la DB
-	err = s.userDB.CreateUser(ctx, userModel, string(hashedPassword))
+	// 3. Crear el usuario en la DB, aplicando el modo de registro. Con un
+	// código de invitación el rol es el de la invitación.
+	opts := db.CreateUserOptions{
+		Closed:        s.registrationMode == models.RegistrationClosed,
+		RequireInvite: s.registrationMode == models.RegistrationInviteOnly,
+	}
+	if input.InviteCode != "" {
+		opts.InviteCodeHash = models.HashToken(input.InviteCode)
+	}
+	err = s.userDB.CreateUser(ctx, userModel, string(hashedPassword), opts)
 	if err != nil {
+		switch {
+		case errors.Is(err, db.ErrRegistrationClosed):
+			return nil, ErrRegistrationClosed
+		case errors.Is(err, db.ErrInviteRequired):
+			return nil, ErrInviteRequired
+		case errors.Is(err, db.ErrInviteUnavailable):
+			return nil, ErrInvalidInvite
+		}
 		// Aquí puedes manejar errores específicos de la DB, como usuario/email ya existente
 		if errors.Is(err, gorm.ErrDuplicatedKey) { // Ejemplo, puede variar según el driver DB
 			return nil, errors.New("username or email already registered")
@@ -65,12 +146,18 @@ func (s *userService) RegisterUser(ctx context.Context, input user.RegisterUserI
 		return nil, errors.New("failed to register user")
 	}
 
+	// El usuario ya existe: si el email no llega puede pedir otro enlace
+	if err := s.emailVerifier.SendVerification(ctx, userModel); err != nil {
+		log.Printf("Service: Failed to send verification email to user %s: %v", userModel.Username, err)
+	}
+
 	// 4. Mapear el modelo de DB a DTO de respuesta
 	response := &user.UserResponse{
 		ID:       userModel.ID,
 		Username: userModel.Username,
 		Email:    userModel.Email,
 		Name:     userModel.Name,
+		Role:     userModel.Role,
 	}
 
 	return response, nil
@@ -87,19 +174,361 @@ func (s *userService) GetUserByID(ctx context.Context, userID string) (*user.Use
 	userModel, err := s.userDB.GetUserByID(ctx, userUUID)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("user not found")
+			return nil, ErrUserNotFound
 		}
 		log.Printf("Service: Failed to get user by ID %s from DB: %v", userID, err)
 		return nil, errors.New("failed to retrieve user")
 	}
 
 	// Mapear el modelo de DB a DTO de información de usuario
-	userInfo := &user.UserInfo{
-		ID:       userModel.ID,
-		Username: userModel.Username,
-		Email:    userModel.Email,
-		Name:     userModel.Name,
+	return MapUserToInfo(userModel), nil
+}
+
+// UpdateUserAccess cambia el rol y los permisos de un usuario. Siempre debe
+// quedar al menos un administrador.
+func (s *userService) UpdateUserAccess(ctx context.Context, userID uuid.UUID, input user.UpdateAccessInput) (*user.UserInfo, error) {
+	for _, permission := range input.Permissions {
+		if !models.IsValidPermission(permission) {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidPermission, permission)
+		}
+	}
+
+	userModel, err := s.userDB.GetUserByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUserNotFound
+		}
+		log.Printf("Service: Failed to get user by ID %s from DB: %v", userID.String(), err)
+		return nil, errors.New("failed to update user access")
+	}
+
+	if err := s.userDB.UpdateUserAccess(ctx, userID, input.Role, input.Permissions); err != nil {
+		if errors.Is(err, ErrLastAdmin) {
+			return nil, ErrLastAdmin
+		}
+		log.Printf("Service: Failed to update access of user %s: %v", userID.String(), err)
+		return nil, errors.New("failed to update user access")
+	}
+	log.Printf("Service: User %s now has role %s", userModel.Username, input.Role)
+
+	userModel.Role = input.Role
+	userModel.Permissions = input.Permissions
+	return MapUserToInfo(userModel), nil
+}
+
+// BootstrapAdmin da el rol de administrador al usuario username, para
+// instalaciones que ya tenían usuarios antes de existir los roles.
+func (s *userService) BootstrapAdmin(ctx context.Context, username string) error {
+	userModel, _, err := s.userDB.GetUserByUsername(ctx, username)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrUserNotFound
+		}
+		return fmt.Errorf("failed to get user %s: %w", username, err)
+	}
+	if userModel.IsAdmin() {
+		return nil
+	}
+	if err := s.userDB.UpdateUserAccess(ctx, userModel.ID, models.RoleAdmin, nil); err != nil {
+		return fmt.Errorf("failed to promote user %s to admin: %w", username, err)
+	}
+	log.Printf("Service: User %s promoted to admin", username)
+	return nil
+}
+
+// ListUsers devuelve una página de usuarios para la administración.
+func (s *userService) ListUsers(ctx context.Context, query user.ListUsersQuery) (*user.ListUsersResponse, error) {
+	if query.Limit == 0 {
+		query.Limit = defaultUserListLimit
 	}
 
-	return userInfo, nil
+	users, total, err := s.userDB.ListUsers(ctx, query.Limit, query.Offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get users from DB: %w", err)
+	}
+
+	response := &user.ListUsersResponse{
+		Users:  make([]user.AdminUserResponse, 0, len(users)),
+		Total:  int(total),
+		Limit:  query.Limit,
+		Offset: query.Offset,
+	}
+	for i := range users {
+		userModel := &users[i]
+		adminUser := user.AdminUserResponse{
+			UserInfo:   *MapUserToInfo(userModel),
+			CreatedAt:  userModel.CreatedAt,
+			DisabledAt: userModel.DisabledAt,
+		}
+		if userModel.Authentication != nil {
+			adminUser.LastLogin = userModel.Authentication.LastLogin
+		}
+		response.Users = append(response.Users, adminUser)
+	}
+	return response, nil
+}
+
+// GetUserSessions devuelve las sesiones vigentes de un usuario.
+func (s *userService) GetUserSessions(ctx context.Context, userID uuid.UUID) ([]auth.SessionResponse, error) {
+	if _, err := s.getUser(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	sessions, err := s.sessionDB.GetSessionsByUser(ctx, userID)
+	if err != nil {
+		log.Printf("Service: Failed to get sessions of user %s: %v", userID.String(), err)
+		return nil, errors.New("failed to retrieve sessions")
+	}
+
+	response := make([]auth.SessionResponse, 0, len(sessions))
+	for i := range sessions {
+		response = append(response, mapSessionToResponse(&sessions[i]))
+	}
+	return response, nil
+}
+
+// SetUserDisabled desactiva o reactiva una cuenta. Al desactivarla se cierran
+// todas sus sesiones.
+func (s *userService) SetUserDisabled(ctx context.Context, actorID, userID uuid.UUID, disabled bool) error {
+	if actorID == userID {
+		return ErrCannotModifySelf
+	}
+	userModel, err := s.getUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	var disabledAt *time.Time
+	if disabled {
+		now := time.Now()
+		disabledAt = &now
+	}
+
+	if err := s.userDB.SetUserDisabled(ctx, userID, disabledAt); err != nil {
+		if errors.Is(err, ErrLastAdmin) {
+			return ErrLastAdmin
+		}
+		log.Printf("Service: Failed to update disabled state of user %s: %v", userID.String(), err)
+		return errors.New("failed to update user")
+	}
+	if disabled {
+		if err := s.sessionDB.DeleteSessionsByUser(ctx, userID); err != nil {
+			log.Printf("Service: Failed to revoke sessions of disabled user %s: %v", userID.String(), err)
+		}
+	}
+	log.Printf("Service: User %s disabled=%t", userModel.Username, disabled)
+	return nil
+}
+
+// ForcePasswordReset obliga al usuario a cambiar su contraseña y cierra sus sesiones.
+func (s *userService) ForcePasswordReset(ctx context.Context, userID uuid.UUID) error {
+	userModel, err := s.getUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if err := s.userDB.SetPasswordResetRequired(ctx, userID, true); err != nil {
+		log.Printf("Service: Failed to require password reset for user %s: %v", userID.String(), err)
+		return errors.New("failed to update user")
+	}
+	if err := s.sessionDB.DeleteSessionsByUser(ctx, userID); err != nil {
+		log.Printf("Service: Failed to revoke sessions of user %s: %v", userID.String(), err)
+	}
+	log.Printf("Service: Password reset required for user %s", userModel.Username)
+	return nil
+}
+
+// DeleteUser elimina una cuenta con sus sesiones y datos personales.
+func (s *userService) DeleteUser(ctx context.Context, actorID, userID uuid.UUID) error {
+	if actorID == userID {
+		return ErrCannotModifySelf
+	}
+	userModel, err := s.getUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if err := s.userDB.DeleteUser(ctx, userID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrUserNotFound
+		}
+		if errors.Is(err, ErrLastAdmin) {
+			return ErrLastAdmin
+		}
+		log.Printf("Service: Failed to delete user %s: %v", userID.String(), err)
+		return errors.New("failed to delete user")
+	}
+	log.Printf("Service: User %s deleted", userModel.Username)
+	return nil
+}
+
+// UpdateProfile cambia el nombre y/o el email del propio usuario. Cambiar el
+// email exige la contraseña actual, y se avisa a la dirección anterior.
+func (s *userService) UpdateProfile(ctx context.Context, userID uuid.UUID, input user.UpdateProfileInput) (*user.UserInfo, error) {
+	userModel, err := s.getUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if input.Name != nil {
+		userModel.Name = *input.Name
+	}
+	previousEmail := userModel.Email
+	emailChanged := false
+	if input.Email != nil && *input.Email != userModel.Email {
+		if input.CurrentPassword == "" {
+			return nil, ErrPasswordRequired
+		}
+		if err := verifyUserPassword(ctx, s.userDB, userID, input.CurrentPassword); err != nil {
+			return nil, err
+		}
+		taken, err := s.userDB.IsEmailTaken(ctx, *input.Email, userID)
+		if err != nil {
+			log.Printf("Service: Failed to check email of user %s: %v", userID.String(), err)
+			return nil, errors.New("failed to update profile")
+		}
+		if taken {
+			return nil, ErrEmailTaken
+		}
+		userModel.Email = *input.Email
+		userModel.EmailVerifiedAt = nil
+		emailChanged = true
+	}
+
+	if err := s.userDB.UpdateUserProfile(ctx, userModel); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUserNotFound
+		}
+		log.Printf("Service: Failed to update profile of user %s: %v", userID.String(), err)
+		return nil, errors.New("failed to update profile")
+	}
+	// El nuevo email tiene que verificarse de nuevo
+	if emailChanged {
+		if err := s.emailVerifier.SendVerification(ctx, userModel); err != nil {
+			log.Printf("Service: Failed to send verification email to user %s: %v", userModel.Username, err)
+		}
+		if previousEmail != "" {
+			go sendMailInBackground(s.mailer, mail.Message{
+				To:      previousEmail,
+				Subject: "Your Cajita Musical email was changed",
+				Body: fmt.Sprintf("Hi %s,\n\nThe email of your account %q was changed to %s.\n\n"+
+					"If you did not make this change, reset your password and contact an administrator.\n",
+					userModel.Name, userModel.Username, userModel.Email),
+			})
+		}
+	}
+	return MapUserToInfo(userModel), nil
+}
+
+// ChangePassword cambia la contraseña del propio usuario tras verificar la
+// actual, cierra el resto de sus sesiones y revoca sus tokens de API. Se le
+// avisa por email, con las passkeys que siguen pudiendo iniciar sesión.
+func (s *userService) ChangePassword(ctx context.Context, userID, currentSessionID uuid.UUID, input user.ChangePasswordInput) error {
+	if err := verifyUserPassword(ctx, s.userDB, userID, input.CurrentPassword); err != nil {
+		return err
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(input.NewPassword), bcrypt.DefaultCost)
+	if err != nil {
+		log.Printf("Service: Failed to hash password for user %s: %v", userID.String(), err)
+		return errors.New("failed to process password")
+	}
+	if err := s.userDB.UpdatePassword(ctx, userID, string(hashedPassword), currentSessionID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrUserNotFound
+		}
+		log.Printf("Service: Failed to update password of user %s: %v", userID.String(), err)
+		return errors.New("failed to change password")
+	}
+
+	if userModel, err := s.getUser(ctx, userID); err == nil {
+		sendPasswordChangedNotice(ctx, s.passkeyDB, s.mailer, userModel)
+	}
+	return nil
+}
+
+// DeleteAccount elimina la cuenta del propio usuario tras confirmar su
+// contraseña. El último administrador no puede borrarse a sí mismo.
+func (s *userService) DeleteAccount(ctx context.Context, userID uuid.UUID, input user.DeleteAccountInput) error {
+	userModel, err := s.getUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if err := verifyUserPassword(ctx, s.userDB, userID, input.Password); err != nil {
+		return err
+	}
+	if err := s.userDB.DeleteUser(ctx, userID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrUserNotFound
+		}
+		if errors.Is(err, ErrLastAdmin) {
+			return ErrLastAdmin
+		}
+		log.Printf("Service: Failed to delete account of user %s: %v", userID.String(), err)
+		return errors.New("failed to delete account")
+	}
+	log.Printf("Service: User %s deleted their account", userModel.Username)
+	return nil
+}
+
+// verifyUserPassword comprueba la contraseña del usuario contra el hash guardado.
+func verifyUserPassword(ctx context.Context, userDB db.UserDBer, userID uuid.UUID, password string) error {
+	hashedPassword, err := userDB.GetPasswordHash(ctx, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrUserNotFound
+		}
+		log.Printf("Service: Failed to get password hash of user %s: %v", userID.String(), err)
+		return errors.New("failed to verify password")
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password)); err != nil {
+		return ErrInvalidPassword
+	}
+	return nil
+}
+
+// getUser obtiene un usuario por ID, traduciendo "no encontrado" a ErrUserNotFound.
+func (s *userService) getUser(ctx context.Context, userID uuid.UUID) (*models.User, error) {
+	userModel, err := s.userDB.GetUserByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUserNotFound
+		}
+		log.Printf("Service: Failed to get user by ID %s from DB: %v", userID.String(), err)
+		return nil, errors.New("failed to retrieve user")
+	}
+	return userModel, nil
+}
+
+// mapSessionToResponse convierte una sesión en el DTO expuesto por la API.
+func mapSessionToResponse(session *models.Session) auth.SessionResponse {
+	device := parseUserAgent(session.UserAgent)
+	response := auth.SessionResponse{
+		ID:        session.SessionID,
+		CreatedAt: session.CreatedAt,
+		ExpiresAt: session.ExpiresAt,
+		UserAgent: session.UserAgent,
+
+		Browser:    device.Browser,
+		OS:         device.OS,
+		Device:     device.Device,
+		LastSeenAt: session.LastSeenAt,
+	}
+	if session.IPAddress != nil {
+		response.IPAddress = session.IPAddress.String()
+	}
+	return response
+}
+
+// MapUserToInfo convierte un modelo de usuario en el DTO expuesto por la API.
+func MapUserToInfo(userModel *models.User) *user.UserInfo {
+	return &user.UserInfo{
+		ID:          userModel.ID,
+		Username:    userModel.Username,
+		Email:       userModel.Email,
+		Name:        userModel.Name,
+		Role:        userModel.Role,
+		Permissions: userModel.EffectivePermissions(),
+
+		PasswordResetRequired: userModel.PasswordResetRequired,
+		EmailVerified:         userModel.EmailVerifiedAt != nil,
+	}
 }
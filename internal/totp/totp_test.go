@@ -0,0 +1,172 @@
+package totp
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// rfcSecret is the SHA-1 seed of the RFC 6238 test vectors,
+// "12345678901234567890", base32 encoded.
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+// rfcVectors are the SHA-1 test vectors of RFC 6238 Appendix B (8 digits).
+var rfcVectors = []struct {
+	unix int64
+	code string
+}{
+	{59, "94287082"},
+	{1111111109, "07081804"},
+	{1111111111, "14050471"},
+	{1234567890, "89005924"},
+	{2000000000, "69279037"},
+	{20000000000, "65353130"},
+}
+
+func TestGenerateRFC6238(t *testing.T) {
+	key, err := encoding.DecodeString(rfcSecret)
+	if err != nil {
+		t.Fatalf("DecodeString: %v", err)
+	}
+	for _, tt := range rfcVectors {
+		t.Run(tt.code, func(t *testing.T) {
+			step := Step(time.Unix(tt.unix, 0))
+			if got := generate(key, step, 8); got != tt.code {
+				t.Errorf("generate(T=%d) = %s, want %s", tt.unix, got, tt.code)
+			}
+		})
+	}
+}
+
+func TestValidateRFC6238(t *testing.T) {
+	for _, tt := range rfcVectors {
+		t.Run(tt.code, func(t *testing.T) {
+			now := time.Unix(tt.unix, 0)
+			code := tt.code[len(tt.code)-Digits:] // Same truncation, fewer digits
+			step, ok := Validate(rfcSecret, code, now, 0)
+			if !ok {
+				t.Fatalf("Validate(%s, T=%d) rejected the code", code, tt.unix)
+			}
+			if want := tt.unix / 30; step != want {
+				t.Errorf("step = %d, want %d", step, want)
+			}
+			if _, ok := Validate(strings.ToLower(rfcSecret), code, now, 0); !ok {
+				t.Errorf("Validate rejected a lowercase secret")
+			}
+		})
+	}
+}
+
+func TestValidateWindow(t *testing.T) {
+	const unix = 1111111111 // Step 37037037, 1 second into it
+	key, _ := encoding.DecodeString(rfcSecret)
+	current := Step(time.Unix(unix, 0))
+
+	tests := []struct {
+		name   string
+		offset int64 // Steps from the current one the code was generated for
+		skew   int
+		want   bool
+	}{
+		{"current step", 0, 1, true},
+		{"previous step", -1, 1, true},
+		{"next step", 1, 1, true},
+		{"two steps behind", -2, 1, false},
+		{"two steps ahead", 2, 1, false},
+		{"previous step without skew", -1, 0, false},
+		{"next step without skew", 1, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code := generate(key, current+tt.offset, Digits)
+			step, ok := Validate(rfcSecret, code, time.Unix(unix, 0), tt.skew)
+			if ok != tt.want {
+				t.Fatalf("Validate = %v, want %v", ok, tt.want)
+			}
+			if ok && step != current+tt.offset {
+				t.Errorf("step = %d, want %d", step, current+tt.offset)
+			}
+		})
+	}
+}
+
+// The same code is valid for the whole window and always matches the step it
+// was generated for, which is what callers store to reject its reuse.
+func TestValidateSameCodeReportsSameStep(t *testing.T) {
+	key, _ := encoding.DecodeString(rfcSecret)
+	start := time.Unix(1234567890, 0)
+	code := generate(key, Step(start), Digits)
+
+	first, ok := Validate(rfcSecret, code, start, 1)
+	if !ok {
+		t.Fatalf("Validate rejected the current code")
+	}
+	for _, later := range []time.Duration{time.Second, 10 * time.Second, Period} {
+		step, ok := Validate(rfcSecret, code, start.Add(later), 1)
+		if !ok {
+			t.Fatalf("Validate rejected the code %s later", later)
+		}
+		if step != first {
+			t.Errorf("%s later: step = %d, want %d", later, step, first)
+		}
+	}
+	if _, ok := Validate(rfcSecret, code, start.Add(3*Period), 1); ok {
+		t.Errorf("Validate accepted the code after the window")
+	}
+}
+
+func TestValidateRejectsMalformedInput(t *testing.T) {
+	now := time.Unix(59, 0)
+	tests := []struct {
+		name, secret, code string
+	}{
+		{"too short", rfcSecret, "28708"},
+		{"too long", rfcSecret, "94287082"},
+		{"empty", rfcSecret, ""},
+		{"invalid secret", "not base32!", "287082"},
+		{"wrong secret", "JBSWY3DPEHPK3PXP", "287082"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, ok := Validate(tt.secret, tt.code, now, 1); ok {
+				t.Errorf("Validate(%q, %q) accepted the code", tt.secret, tt.code)
+			}
+		})
+	}
+}
+
+func TestGenerateSecret(t *testing.T) {
+	secret, err := GenerateSecret()
+	if err != nil {
+		t.Fatalf("GenerateSecret: %v", err)
+	}
+	key, err := encoding.DecodeString(secret)
+	if err != nil {
+		t.Fatalf("secret %q is not base32: %v", secret, err)
+	}
+	if len(key) != secretSize {
+		t.Errorf("secret is %d bytes, want %d", len(key), secretSize)
+	}
+	if other, _ := GenerateSecret(); other == secret {
+		t.Errorf("GenerateSecret returned the same secret twice")
+	}
+}
+
+func TestProvisioningURI(t *testing.T) {
+	uri, err := url.Parse(ProvisioningURI(rfcSecret, "Cajita Musical", "ana"))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if uri.Scheme != "otpauth" || uri.Host != "totp" || uri.Path != "/Cajita Musical:ana" {
+		t.Errorf("URI = %s, want otpauth://totp/Cajita Musical:ana", uri)
+	}
+	query := uri.Query()
+	for key, want := range map[string]string{
+		"secret": rfcSecret, "issuer": "Cajita Musical", "algorithm": "SHA1", "digits": "6", "period": "30",
+	} {
+		if got := query.Get(key); got != want {
+			t.Errorf("%s = %q, want %q", key, got, want)
+		}
+	}
+}
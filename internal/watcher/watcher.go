@@ -0,0 +1,318 @@
+// Package watcher keeps the song library in sync with the music directory by
+// applying filesystem events as they happen, instead of waiting for a scan.
+package watcher
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/demispreviotto/cajitamusical/cajitamusical-backend/internal/db"
+	"github.com/demispreviotto/cajitamusical/cajitamusical-backend/internal/services"
+	"github.com/fsnotify/fsnotify"
+	"gorm.io/gorm"
+)
+
+// DefaultDebounce is how long a file must stay quiet before its changes are applied.
+const DefaultDebounce = 2 * time.Second
+
+// Watcher watches the music directory tree (inotify on Linux) and applies the
+// changes of each audio file through SongDBer once its events settle. When
+// events are lost (queue overflow) or a whole directory disappears, it falls
+// back to a full library scan.
+//
+// Settled files are applied in batches by a worker goroutine, so events keep
+// being drained meanwhile. A batch shares the library scan lock; while a scan
+// runs its files stay queued until the scan is over.
+type Watcher struct {
+	musicDir    string
+	debounce    time.Duration
+	songDB      db.SongDBer
+	scanService services.ScanServicer
+
+	fsw     *fsnotify.Watcher
+	dirs    map[string]bool      // Watched directories
+	pending map[string]time.Time // Audio files with unapplied events, by time of their last event
+
+	batches chan []string // Files handed to the worker
+	done    chan []string // Files of the last batch that the worker left unapplied
+	busy    bool          // A batch is being applied
+	resync  bool          // A full scan is due once the batch is over
+}
+
+// New creates a watcher over every directory under musicDir.
+func New(musicDir string, debounce time.Duration, songDB db.SongDBer, scanService services.ScanServicer) (*Watcher, error) {
+	fsw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+
+	w := &Watcher{
+		musicDir:    musicDir,
+		debounce:    debounce,
+		songDB:      songDB,
+		scanService: scanService,
+		fsw:         fsw,
+		dirs:        make(map[string]bool),
+		pending:     make(map[string]time.Time),
+		batches:     make(chan []string),
+		done:        make(chan []string),
+	}
+	if err := w.addTree(musicDir, false); err != nil {
+		fsw.Close()
+		return nil, err
+	}
+	return w, nil
+}
+
+// Run processes events until ctx is cancelled, then waits for the batch being
+// applied and releases the watches.
+func (w *Watcher) Run(ctx context.Context) {
+	defer w.fsw.Close()
+	log.Printf("Watching %s for library changes (%d directories)", w.musicDir, len(w.dirs))
+
+	workerDone := make(chan struct{})
+	go func() {
+		defer close(workerDone)
+		w.work(ctx)
+	}()
+	defer func() {
+		close(w.batches)
+		<-workerDone
+	}()
+
+	ticker := time.NewTicker(w.debounce / 2)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case unapplied := <-w.done:
+			w.busy = false
+			// Requeued as already settled, so they go in the next batch
+			for _, path := range unapplied {
+				if _, ok := w.pending[path]; !ok {
+					w.pending[path] = time.Time{}
+				}
+			}
+			if w.resync {
+				w.startScan(ctx)
+			}
+		case event, ok := <-w.fsw.Events:
+			if !ok {
+				return
+			}
+			w.handleEvent(ctx, event)
+		case err, ok := <-w.fsw.Errors:
+			if !ok {
+				return
+			}
+			if errors.Is(err, fsnotify.ErrEventOverflow) {
+				log.Printf("Watcher: Event queue overflowed, falling back to a full scan")
+				w.requestScan(ctx)
+				continue
+			}
+			log.Printf("Watcher: Error: %v", err)
+		case now := <-ticker.C:
+			w.flush(ctx, now)
+		}
+	}
+}
+
+// handleEvent records an event. Files are only read once they settle, in flush.
+func (w *Watcher) handleEvent(ctx context.Context, event fsnotify.Event) {
+	path := event.Name
+
+	if event.Has(fsnotify.Remove) || event.Has(fsnotify.Rename) {
+		if w.dirs[path] {
+			// A directory left: its songs cannot be listed from disk anymore
+			w.forgetTree(path)
+			log.Printf("Watcher: Directory %s was removed or renamed, falling back to a full scan", path)
+			w.requestScan(ctx)
+			return
+		}
+	}
+
+	if event.Has(fsnotify.Create) {
+		if info, err := os.Stat(path); err == nil && info.IsDir() {
+			// New or moved-in directory: watch it and pick up the files already inside
+			if err := w.addTree(path, true); err != nil {
+				log.Printf("Watcher: Error watching %s: %v", path, err)
+			}
+			return
+		}
+	}
+
+	if db.IsSongFile(path) {
+		w.pending[path] = time.Now()
+	}
+}
+
+// addTree watches root and its subdirectories. With queueFiles, the audio
+// files found are queued as if they had just been created.
+func (w *Watcher) addTree(root string, queueFiles bool) error {
+	return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			log.Printf("Watcher: Error accessing path %s: %v", path, err)
+			return nil
+		}
+		if !d.IsDir() {
+			if queueFiles && db.IsSongFile(path) {
+				w.pending[path] = time.Now()
+			}
+			return nil
+		}
+		if w.dirs[path] {
+			return nil
+		}
+		if err := w.fsw.Add(path); err != nil {
+			if path == root {
+				return err
+			}
+			log.Printf("Watcher: Error watching %s: %v", path, err)
+			return nil
+		}
+		w.dirs[path] = true
+		return nil
+	})
+}
+
+// forgetTree drops dir and its subdirectories from the watched set. The
+// kernel removes their watches on its own.
+func (w *Watcher) forgetTree(dir string) {
+	prefix := dir + string(os.PathSeparator)
+	for path := range w.dirs {
+		if path == dir || strings.HasPrefix(path, prefix) {
+			delete(w.dirs, path)
+			_ = w.fsw.Remove(path)
+		}
+	}
+	for path := range w.pending {
+		if strings.HasPrefix(path, prefix) {
+			delete(w.pending, path)
+		}
+	}
+}
+
+// requestScan discards the pending events and starts a full library scan, as
+// soon as the worker is idle: the batch it applies keeps scans from starting.
+func (w *Watcher) requestScan(ctx context.Context) {
+	clear(w.pending)
+	w.resync = true
+	if !w.busy {
+		w.startScan(ctx)
+	}
+}
+
+func (w *Watcher) startScan(ctx context.Context) {
+	w.resync = false
+	if _, err := w.scanService.StartScan(ctx); err != nil {
+		if errors.Is(err, services.ErrScanInProgress) {
+			log.Printf("Watcher: A library scan is already running")
+			return
+		}
+		log.Printf("Watcher: Failed to start a full scan: %v", err)
+	}
+}
+
+// flush hands the files whose last event is older than the debounce delay to
+// the worker, unless it is still busy with the previous batch.
+func (w *Watcher) flush(ctx context.Context, now time.Time) {
+	if w.busy {
+		return
+	}
+	var batch []string
+	for path, lastEvent := range w.pending {
+		if now.Sub(lastEvent) < w.debounce {
+			continue
+		}
+		delete(w.pending, path)
+		batch = append(batch, path)
+	}
+	if len(batch) == 0 {
+		return
+	}
+	select {
+	case w.batches <- batch:
+		w.busy = true
+	case <-ctx.Done():
+	}
+}
+
+// work applies the batches sent by flush, and reports back the files it could
+// not apply because a library scan is running.
+func (w *Watcher) work(ctx context.Context) {
+	for batch := range w.batches {
+		unapplied := w.applyBatch(ctx, batch)
+		select {
+		case w.done <- unapplied:
+		case <-ctx.Done():
+		}
+	}
+}
+
+func (w *Watcher) applyBatch(ctx context.Context, batch []string) []string {
+	unlock, err := w.songDB.TryShareScanLock(ctx)
+	if err != nil {
+		if !errors.Is(err, db.ErrScanInProgress) {
+			log.Printf("Watcher: Failed to share the library scan lock: %v", err)
+		}
+		return batch
+	}
+	defer unlock()
+
+	for _, path := range batch {
+		if err := w.apply(ctx, path); err != nil {
+			log.Printf("Watcher: Failed to apply changes of %s: %v", path, err)
+		}
+	}
+	return nil
+}
+
+// apply brings the song of path in line with the file: created, updated, or
+// deleted when the file is gone.
+func (w *Watcher) apply(ctx context.Context, path string) error {
+	filePath := db.SongFilePath(w.musicDir, path)
+	existing, err := w.songDB.GetSongByFilePath(ctx, filePath)
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		existing = nil
+	}
+
+	info, err := os.Stat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		if existing == nil {
+			return nil
+		}
+		log.Printf("Watcher: Removing song %s", filePath)
+		return w.songDB.DeleteSong(ctx, existing.ID)
+	}
+	if err != nil {
+		return err
+	}
+	if existing != nil && db.SameSongFile(existing, info) {
+		return nil
+	}
+
+	newSong, err := db.LoadSongFile(w.musicDir, path)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		log.Printf("Watcher: Adding song %s", filePath)
+		return w.songDB.CreateSong(ctx, newSong)
+	}
+
+	newSong.ID = existing.ID
+	newSong.CreatedAt = existing.CreatedAt
+	log.Printf("Watcher: Updating song %s", filePath)
+	return w.songDB.UpdateSong(ctx, newSong)
+}
@@ -0,0 +1,16 @@
+-- migrations/000004_favorites_by_id.down.sql
+
+ALTER TABLE favorite_albums ADD COLUMN artist varchar(255), ADD COLUMN album varchar(255);
+UPDATE favorite_albums SET artist = artists.name, album = albums.title
+    FROM albums JOIN artists ON artists.id = albums.artist_id
+    WHERE albums.id = favorite_albums.album_id;
+ALTER TABLE favorite_albums DROP CONSTRAINT favorite_albums_pkey;
+ALTER TABLE favorite_albums DROP COLUMN album_id;
+ALTER TABLE favorite_albums ADD PRIMARY KEY (user_id, artist, album);
+
+ALTER TABLE favorite_artists ADD COLUMN artist varchar(255);
+UPDATE favorite_artists SET artist = artists.name
+    FROM artists WHERE artists.id = favorite_artists.artist_id;
+ALTER TABLE favorite_artists DROP CONSTRAINT favorite_artists_pkey;
+ALTER TABLE favorite_artists DROP COLUMN artist_id;
+ALTER TABLE favorite_artists ADD PRIMARY KEY (user_id, artist);
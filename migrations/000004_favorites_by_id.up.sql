@@ -0,0 +1,24 @@
+-- migrations/000004_favorites_by_id.up.sql
+-- Los favoritos de álbumes y artistas pasan a referenciar Album y Artist por ID.
+-- Ejecutar antes de arrancar la versión que lo requiere (AutoMigrate no puede
+-- convertir las filas existentes). Se descartan los favoritos cuyo álbum o
+-- artista ya no está en el catálogo.
+
+ALTER TABLE favorite_artists ADD COLUMN artist_id uuid;
+UPDATE favorite_artists SET artist_id = artists.id
+    FROM artists WHERE artists.name = favorite_artists.artist;
+DELETE FROM favorite_artists WHERE artist_id IS NULL;
+ALTER TABLE favorite_artists DROP CONSTRAINT favorite_artists_pkey;
+ALTER TABLE favorite_artists DROP COLUMN artist;
+ALTER TABLE favorite_artists ALTER COLUMN artist_id SET NOT NULL;
+ALTER TABLE favorite_artists ADD PRIMARY KEY (user_id, artist_id);
+
+ALTER TABLE favorite_albums ADD COLUMN album_id uuid;
+UPDATE favorite_albums SET album_id = albums.id
+    FROM albums JOIN artists ON artists.id = albums.artist_id
+    WHERE artists.name = favorite_albums.artist AND albums.title = favorite_albums.album;
+DELETE FROM favorite_albums WHERE album_id IS NULL;
+ALTER TABLE favorite_albums DROP CONSTRAINT favorite_albums_pkey;
+ALTER TABLE favorite_albums DROP COLUMN artist, DROP COLUMN album;
+ALTER TABLE favorite_albums ALTER COLUMN album_id SET NOT NULL;
+ALTER TABLE favorite_albums ADD PRIMARY KEY (user_id, album_id);